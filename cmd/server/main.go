@@ -1,20 +1,36 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"trackonomy/config"
 	"trackonomy/db"
 	"trackonomy/internal"
 	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/budget"
 	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
 	"trackonomy/internal/expense"
+	"trackonomy/internal/group"
+	"trackonomy/internal/importer"
+	"trackonomy/internal/income"
 	"trackonomy/internal/logger"
+	"trackonomy/internal/money"
+	"trackonomy/internal/recurring"
 	"trackonomy/internal/response"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/sharing"
+	"trackonomy/internal/tag"
+	"trackonomy/internal/transfer"
 	"trackonomy/internal/user"
+	"trackonomy/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -30,11 +46,15 @@ func main() {
 	}
 	defer logger.Sync()
 
+	utils.SetCursorSecret([]byte(cfg.CursorSecret))
+	currency.SetDefault(cfg.DefaultCurrency)
+
 	// Connect to the database
 	db.ConnectDatabase(cfg)
 
 	// Run database migrations
 	runMigrations()
+	promoteAdmins(cfg.AdminEmails)
 
 	// Set Gin mode based on environment (development, production, etc.)
 	mode := os.Getenv("GIN_MODE")
@@ -55,6 +75,11 @@ func main() {
 		response.Error(c, 404, "The resource you requested could not be found.", nil)
 	})
 
+	// Start background workers (recurring expenses, ...)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	internal.StartWorkers(ctx, db.DB, cfg)
+
 	// Get the port from environment variables or default to 8080
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -74,14 +99,163 @@ func main() {
 
 // runMigrations handles all database migrations
 func runMigrations() {
+	if err := migrateMoneyColumns(); err != nil {
+		logger.Fatal("Failed to migrate amounts to exact decimals", zap.Error(err))
+	}
+
 	err := db.DB.AutoMigrate(
 		&user.User{},
 		&expense.Expense{},
+		&expense.ExpenseItem{},
 		&category.Category{},
 		&account.Account{},
+		&income.Income{},
+		&transfer.Transfer{},
+		&recurring.RecurringExpense{},
+		&budget.Budget{},
+		&importer.ImportProfile{},
+		&sharing.Share{},
+		&sharing.Settlement{},
+		&group.Group{},
+		&group.Member{},
+		&group.Invitation{},
+		&tag.Tag{},
+		&rule.Rule{},
+		&audit.Entry{},
+		&currency.Rate{},
 	)
 	if err != nil {
 		logger.Fatal("Database migration failed", zap.Error(err))
 	}
+
+	// Expenses created before the date field was filled in have a zero date,
+	// which would keep them out of every date-range query.
+	err = db.DB.Model(&expense.Expense{}).
+		Where("date < ?", "1900-01-01").
+		Update("date", gorm.Expr("created_at")).Error
+	if err != nil {
+		logger.Fatal("Failed to backfill expense dates", zap.Error(err))
+	}
+
+	// Records kept before currencies existed are in the default currency.
+	for _, c := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&account.Account{}, "currency"},
+		{&expense.Expense{}, "currency"},
+		{&income.Income{}, "currency"},
+		{&user.User{}, "base_currency"},
+		{&sharing.Settlement{}, "currency"},
+	} {
+		err = db.DB.Unscoped().Model(c.model).
+			Where(c.column+" IS NULL OR "+c.column+" = ''").
+			Update(c.column, currency.Default).Error
+		if err != nil {
+			logger.Fatal("Failed to backfill currencies", zap.String("column", c.column), zap.Error(err))
+		}
+	}
+
+	// Expenses recorded before currencies existed were all in the currency of
+	// their account, which was the default one.
+	err = db.DB.Unscoped().Model(&expense.Expense{}).
+		Where("base_currency IS NULL OR base_currency = ''").
+		Updates(map[string]interface{}{
+			"account_amount": gorm.Expr("amount"),
+			"base_amount":    gorm.Expr("amount"),
+			"base_currency":  gorm.Expr("currency"),
+		}).Error
+	if err != nil {
+		logger.Fatal("Failed to backfill expense currencies", zap.Error(err))
+	}
+
+	// So were incomes, credited to their account as recorded.
+	err = db.DB.Model(&income.Income{}).
+		Where("base_currency IS NULL OR base_currency = ''").
+		Updates(map[string]interface{}{
+			"account_amount": gorm.Expr("amount"),
+			"base_amount":    gorm.Expr("amount"),
+			"base_currency":  gorm.Expr("currency"),
+		}).Error
+	if err != nil {
+		logger.Fatal("Failed to backfill income currencies", zap.Error(err))
+	}
+
+	// Transfers recorded before they could cross currencies credited the
+	// amount they debited.
+	err = db.DB.Model(&transfer.Transfer{}).
+		Where("to_amount IS NULL").
+		Update("to_amount", gorm.Expr("amount")).Error
+	if err != nil {
+		logger.Fatal("Failed to backfill transfer amounts", zap.Error(err))
+	}
+
 	logger.Info("Database migration completed successfully.")
 }
+
+// promoteAdmins gives the administrator role to the users registered with the
+// given emails, so that a fresh install has someone to manage global
+// categories, accounts and other users. Emails not registered yet are skipped
+// until the next start.
+func promoteAdmins(emails []string) {
+	if len(emails) == 0 {
+		return
+	}
+	result := db.DB.Model(&user.User{}).
+		Where("email IN ? AND role <> ?", emails, user.RoleAdmin).
+		Update("role", user.RoleAdmin)
+	if result.Error != nil {
+		logger.Fatal("Failed to promote administrators", zap.Error(result.Error))
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("Promoted administrators", zap.Int64("count", result.RowsAffected))
+	}
+}
+
+// moneyColumns lists the columns that held amounts as floating point before
+// they became exact decimals.
+var moneyColumns = []struct {
+	table, column string
+}{
+	{"expenses", "amount"},
+	{"expenses", "account_amount"},
+	{"expenses", "base_amount"},
+	{"expense_items", "amount"},
+	{"accounts", "balance"},
+	{"incomes", "amount"},
+	{"transfers", "amount"},
+	{"recurring_expenses", "amount"},
+	{"budgets", "amount"},
+	{"shares", "amount"},
+	{"settlements", "amount"},
+	{"rules", "min_amount"},
+	{"rules", "max_amount"},
+}
+
+// migrateMoneyColumns converts the amount columns that are still floating
+// point to NUMERIC in place, rounding every value to the decimals a
+// money.Amount keeps. Columns already converted, or not created yet, are left
+// to AutoMigrate.
+func migrateMoneyColumns() error {
+	for _, c := range moneyColumns {
+		var dataType string
+		err := db.DB.Raw(`
+			SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`,
+			c.table, c.column).Scan(&dataType).Error
+		if err != nil {
+			return err
+		}
+		if dataType != "double precision" && dataType != "real" {
+			continue
+		}
+		err = db.DB.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING ROUND(%s::numeric, %d)",
+			c.table, c.column, money.Amount(0).GormDataType(), c.column, money.Places)).Error
+		if err != nil {
+			return err
+		}
+		logger.Info("Converted amount column to exact decimals",
+			zap.String("table", c.table), zap.String("column", c.column))
+	}
+	return nil
+}
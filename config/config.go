@@ -4,6 +4,8 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -23,6 +25,23 @@ type Config struct {
 	CloudinaryCloudName string
 	CloudinaryAPIKey    string
 	CloudinaryAPISecret string
+
+	// Background workers
+	RecurringInterval time.Duration
+
+	// Deleted records stay in the trash for TrashRetention before they are purged
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// Key used to sign pagination cursors
+	CursorSecret string
+
+	// Users registered with these emails are made administrators at startup
+	AdminEmails []string
+
+	// ISO 4217 code of the records kept before currencies existed, and of
+	// users and accounts never given one
+	DefaultCurrency string
 }
 
 // LoadConfig loads configuration from environment variables
@@ -48,6 +67,22 @@ func LoadConfig() (*Config, error) {
 		CloudinaryCloudName: os.Getenv("CLOUDINARY_CLOUD_NAME"),
 		CloudinaryAPIKey:    os.Getenv("CLOUDINARY_API_KEY"),
 		CloudinaryAPISecret: os.Getenv("CLOUDINARY_API_SECRET"),
+
+		RecurringInterval: durationFromEnv("RECURRING_INTERVAL", 15*time.Minute),
+
+		TrashRetention:     durationFromEnv("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: durationFromEnv("TRASH_PURGE_INTERVAL", time.Hour),
+
+		CursorSecret: os.Getenv("CURSOR_SECRET"),
+
+		AdminEmails: listFromEnv("ADMIN_EMAILS"),
+
+		DefaultCurrency: currencyFromEnv("DEFAULT_CURRENCY", "INR"),
+	}
+
+	// Cursors can share the JWT key unless a dedicated one is configured
+	if cfg.CursorSecret == "" {
+		cfg.CursorSecret = os.Getenv("JWT_SECRET")
 	}
 
 	// Validate required configurations based on the environment
@@ -68,3 +103,44 @@ func LoadConfig() (*Config, error) {
 
 	return cfg, nil
 }
+
+// durationFromEnv parses a duration such as "15m" from the environment,
+// falling back to def when the variable is unset or invalid.
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using default %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// listFromEnv splits a comma-separated variable such as "a@x.io, b@x.io",
+// dropping empty entries.
+func listFromEnv(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// currencyFromEnv reads an ISO 4217 code such as "usd" from the environment,
+// falling back to def when the variable is unset or not three letters.
+func currencyFromEnv(key, def string) string {
+	value := strings.ToUpper(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return def
+	}
+	if len(value) != 3 || strings.Trim(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		log.Printf("Invalid %s %q, using default %s", key, value, def)
+		return def
+	}
+	return value
+}
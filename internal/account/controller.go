@@ -1,9 +1,12 @@
 package account
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"trackonomy/internal/audit"
 	"trackonomy/internal/dto"
+	"trackonomy/internal/group"
 	"trackonomy/internal/logger"
 	"trackonomy/internal/response"
 	"trackonomy/internal/utils"
@@ -22,8 +25,8 @@ func NewAccountController(service Service) *AccountController {
 }
 
 // CreateGlobalAccount creates an account that is global (is_global = true).
+// It is reserved to administrators.
 func (ac *AccountController) CreateGlobalAccount(c *gin.Context) {
-	// userID=0 => no auth required
 	var req dto.AccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "Invalid account data", err.Error())
@@ -39,13 +42,14 @@ func (ac *AccountController) CreateGlobalAccount(c *gin.Context) {
 		Name:        req.Name,
 		AccountType: req.AccountType,
 		Balance:     req.Balance,
+		Currency:    req.Currency,
 		Description: req.Description,
 		Icon:        req.Icon,
 		IsGlobal:    true,
 		UserID:      0,
 	}
 
-	if err := ac.service.CreateAccount(acc); err != nil {
+	if err := ac.service.CreateAccount(audit.Context(c), acc); err != nil {
 		response.InternalServerError(c, "Could not create global account", err.Error())
 		return
 	}
@@ -72,13 +76,18 @@ func (ac *AccountController) CreateAccount(c *gin.Context) {
 		Name:        req.Name,
 		AccountType: req.AccountType,
 		Balance:     req.Balance,
+		Currency:    req.Currency,
 		Description: req.Description,
 		Icon:        req.Icon,
 		IsGlobal:    false,
 		UserID:      userID,
+		GroupID:     req.GroupID,
 	}
 
-	if err := ac.service.CreateAccount(acc); err != nil {
+	if err := ac.service.CreateAccount(audit.Context(c), acc); err != nil {
+		if group.RespondAccessError(c, err) {
+			return
+		}
 		logger.Error("Failed to create account", zap.Error(err), zap.Uint("userID", userID))
 		response.InternalServerError(c, "Could not create account", err.Error())
 		return
@@ -88,25 +97,47 @@ func (ac *AccountController) CreateAccount(c *gin.Context) {
 
 // GetAllGlobalAccounts fetches only global accounts (userID=0).
 func (ac *AccountController) GetAllGlobalAccounts(c *gin.Context) {
-	accounts, err := ac.service.GetAllAccounts(0)
+	pagination := utils.NewOptionalPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	accounts, page, err := ac.service.GetAllAccounts(0, pagination)
 	if err != nil {
 		response.InternalServerError(c, "Could not retrieve global accounts", err.Error())
 		return
 	}
-	response.Success(c, http.StatusOK, "Global accounts retrieved successfully", accounts)
+	response.Success(c, http.StatusOK, "Global accounts retrieved successfully", listResponse(pagination, page, accounts))
 }
 
 // GetAllAccounts lists both user + global accounts for the authenticated user.
 func (ac *AccountController) GetAllAccounts(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 
-	accounts, err := ac.service.GetAllAccounts(userID)
+	pagination := utils.NewOptionalPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	accounts, page, err := ac.service.GetAllAccounts(userID, pagination)
 	if err != nil {
 		logger.Error("Failed to retrieve accounts", zap.Error(err), zap.Uint("userID", userID))
 		response.InternalServerError(c, "Could not retrieve accounts", err.Error())
 		return
 	}
-	response.Success(c, http.StatusOK, "Accounts retrieved successfully", accounts)
+	response.Success(c, http.StatusOK, "Accounts retrieved successfully", listResponse(pagination, page, accounts))
+}
+
+// listResponse returns the bare list in unpaginated mode and a page envelope otherwise.
+func listResponse(pagination utils.Pagination, page utils.Page, accounts []Account) interface{} {
+	if pagination.Limit == 0 {
+		return accounts
+	}
+	data := pagination.Meta(page)
+	data["accounts"] = accounts
+	return data
 }
 
 // GetAccountByID retrieves a single account by ID
@@ -136,8 +167,17 @@ func (ac *AccountController) GetAccountByID(c *gin.Context) {
 
 // UpdateAccount modifies an existing account.
 func (ac *AccountController) UpdateAccount(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
+	ac.updateAccount(c, c.MustGet("userID").(uint))
+}
 
+// UpdateGlobalAccount modifies a global account. It is reserved to administrators.
+func (ac *AccountController) UpdateGlobalAccount(c *gin.Context) {
+	ac.updateAccount(c, 0)
+}
+
+// updateAccount modifies an account userID may edit. userID 0 stands for the
+// administrators, who own the global accounts.
+func (ac *AccountController) updateAccount(c *gin.Context, userID uint) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -162,13 +202,20 @@ func (ac *AccountController) UpdateAccount(c *gin.Context) {
 		ID:          uint(id),
 		Name:        req.Name,
 		AccountType: req.AccountType,
-		Balance:     req.Balance,
 		Description: req.Description,
 		Icon:        req.Icon,
-		UserID:      userID, // maintain ownership
+		UserID:      userID, // used to check the caller may edit it
+		GroupID:     req.GroupID,
 	}
 
-	if err := ac.service.UpdateAccount(acc); err != nil {
+	if err := ac.service.UpdateAccount(audit.Context(c), acc); err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			response.NotFound(c, "Account not found", nil)
+			return
+		}
+		if group.RespondAccessError(c, err) {
+			return
+		}
 		logger.Error("Failed to update account", zap.Error(err), zap.Uint("accountID", acc.ID))
 		response.InternalServerError(c, "Could not update account", err.Error())
 		return
@@ -178,8 +225,17 @@ func (ac *AccountController) UpdateAccount(c *gin.Context) {
 
 // DeleteAccount removes an account by ID
 func (ac *AccountController) DeleteAccount(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
+	ac.deleteAccount(c, c.MustGet("userID").(uint))
+}
+
+// DeleteGlobalAccount removes a global account. It is reserved to administrators.
+func (ac *AccountController) DeleteGlobalAccount(c *gin.Context) {
+	ac.deleteAccount(c, 0)
+}
 
+// deleteAccount removes an account userID may edit. userID 0 stands for the
+// administrators, who own the global accounts.
+func (ac *AccountController) deleteAccount(c *gin.Context, userID uint) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -188,10 +244,111 @@ func (ac *AccountController) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	if err := ac.service.DeleteAccount(uint(id), userID); err != nil {
+	if err := ac.service.DeleteAccount(audit.Context(c), uint(id), userID); err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			response.NotFound(c, "Account not found", nil)
+			return
+		}
 		logger.Error("Failed to delete account", zap.Error(err), zap.Int("accountID", id))
 		response.InternalServerError(c, "Could not delete account", err.Error())
 		return
 	}
 	response.Deleted(c, "Account deleted successfully")
 }
+
+// GetDeletedGlobalAccounts lists the trashed global accounts, most recently
+// deleted first. It is reserved to administrators.
+func (ac *AccountController) GetDeletedGlobalAccounts(c *gin.Context) {
+	accounts, err := ac.service.GetDeletedAccounts(0)
+	if err != nil {
+		logger.Error("Failed to retrieve deleted global accounts", zap.Error(err))
+		response.InternalServerError(c, "Could not retrieve deleted global accounts", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Deleted global accounts retrieved successfully", accounts)
+}
+
+// RestoreGlobalAccount takes a global account out of the trash before the
+// purge removes it. It is reserved to administrators.
+func (ac *AccountController) RestoreGlobalAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid account ID", nil)
+		return
+	}
+
+	acc, err := ac.service.RestoreAccount(audit.Context(c), uint(id), 0)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			response.NotFound(c, "Not found in the trash", nil)
+			return
+		}
+		logger.Error("Failed to restore global account", zap.Error(err), zap.Int("accountID", id))
+		response.InternalServerError(c, "Could not restore global account", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Global account restored successfully", acc)
+}
+
+// GetAccountHistory lists the recorded changes of an account, most recent first.
+func (ac *AccountController) GetAccountHistory(c *gin.Context) {
+	ac.getAccountHistory(c, c.MustGet("userID").(uint))
+}
+
+// GetGlobalAccountHistory lists the recorded changes of a global account. It
+// is reserved to administrators.
+func (ac *AccountController) GetGlobalAccountHistory(c *gin.Context) {
+	ac.getAccountHistory(c, 0)
+}
+
+// getAccountHistory lists the changes of an account userID may edit. userID 0
+// stands for the administrators, who own the global accounts.
+func (ac *AccountController) getAccountHistory(c *gin.Context, userID uint) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid account ID", nil)
+		return
+	}
+
+	entries, err := ac.service.GetAccountHistory(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			response.NotFound(c, "Account not found", nil)
+			return
+		}
+		logger.Error("Failed to retrieve account history", zap.Error(err), zap.Int("accountID", id))
+		response.InternalServerError(c, "Could not retrieve account history", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Account history retrieved successfully", entries)
+}
+
+// RevertAccount puts an account back to the version of one of its history entries.
+func (ac *AccountController) RevertAccount(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid account ID", nil)
+		return
+	}
+	entryID, ok := audit.ParamEntryID(c)
+	if !ok {
+		return
+	}
+
+	acc, err := ac.service.RevertAccount(audit.Context(c), uint(id), entryID, userID)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			response.NotFound(c, "Account not found", nil)
+			return
+		}
+		if audit.RespondError(c, err) || group.RespondAccessError(c, err) {
+			return
+		}
+		logger.Error("Failed to revert account", zap.Error(err), zap.Int("accountID", id))
+		response.InternalServerError(c, "Could not revert account", err.Error())
+		return
+	}
+	response.Updated(c, "Account reverted successfully", acc)
+}
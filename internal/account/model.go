@@ -2,18 +2,38 @@ package account
 
 import (
 	"time"
+	"trackonomy/internal/money"
+
+	"gorm.io/gorm"
 )
 
 // Account represents a bank account or similar on your expense tracker.
 type Account struct {
-	Name        string    `json:"name"`
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	AccountType string    `json:"account_type"`
-	Balance     float64   `json:"balance"`
-	Description string    `json:"description,omitempty"`
-	Icon        string    `json:"icon,omitempty"`
-	IsGlobal    bool      `json:"is_global" gorm:"default:false"`
-	UserID      uint      `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Name        string         `json:"name"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	AccountType string         `json:"account_type"`
+	Balance     money.Amount   `json:"balance"`
+	Currency    string         `json:"currency" gorm:"size:3"` // ISO 4217 code, fixed once the account exists
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	IsGlobal    bool           `json:"is_global" gorm:"default:false"`
+	UserID      uint           `json:"user_id"`
+	GroupID     *uint          `json:"group_id,omitempty" gorm:"index"` // shared with a group when set
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // set while the account is in the trash
+}
+
+// SortFields maps the sort names accepted by the list endpoints to columns.
+var SortFields = map[string]string{
+	"id":           "accounts.id",
+	"name":         "accounts.name",
+	"account_type": "accounts.account_type",
+	"balance":      "accounts.balance",
+	"is_global":    "accounts.is_global",
+	"created_at":   "accounts.created_at",
+	"updated_at":   "accounts.updated_at",
 }
+
+// DefaultSort is used when the request does not send ?sort=.
+const DefaultSort = "name:asc"
@@ -2,17 +2,45 @@ package account
 
 import (
 	"errors"
+	"time"
+	"trackonomy/internal/group"
+	"trackonomy/internal/money"
+	"trackonomy/internal/utils"
 
 	"gorm.io/gorm"
 )
 
+// ErrAccountNotFound is returned when an account does not exist or is not owned by the user.
+var ErrAccountNotFound = errors.New("account not found")
+
+// references lists the columns of other resources that point at an account.
+// They are named here rather than through the models because those packages
+// import this one.
+var references = []struct {
+	table, column string
+}{
+	{"expenses", "account_id"},
+	{"incomes", "account_id"},
+	{"transfers", "from_account_id"},
+	{"transfers", "to_account_id"},
+	{"recurring_expenses", "account_id"},
+	{"rules", "account_id"},
+}
+
 // Repository is the interface for CRUD on Account.
 type Repository interface {
 	Create(acc *Account) error
-	GetAll(userID uint) ([]Account, error)
+	GetAll(userID uint, p utils.Pagination) ([]Account, utils.Page, error)
 	GetByID(id, userID uint) (*Account, error)
 	Update(acc *Account) error
 	Delete(id, userID uint) error
+	AdjustBalance(id, userID uint, delta money.Amount) error
+	GetDeleted(userID uint) ([]Account, error)
+	Restore(id, userID uint) error
+	Purge(before time.Time) (int64, error)
+	Unscoped() Repository
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
 }
 
 type repository struct {
@@ -31,25 +59,17 @@ func (r *repository) Create(acc *Account) error {
 	return r.db.Create(acc).Error
 }
 
-// GetAll returns accounts for userID if user-specific, plus any global accounts.
-func (r *repository) GetAll(userID uint) ([]Account, error) {
-	var accounts []Account
-
-	if userID == 0 {
-		// Return only global accounts
-		err := r.db.Where("is_global = ?", true).Find(&accounts).Error
-		if err != nil {
-			return nil, err
-		}
-		return accounts, nil
-	}
-
-	// userID > 0 => global + user
-	err := r.db.Where("is_global = ? OR user_id = ?", true, userID).Find(&accounts).Error
-	if err != nil {
-		return nil, err
+// GetAll returns accounts for userID if user-specific, plus any global accounts
+// and the accounts of the user's groups, one page at a time (every row when
+// p.Limit is 0).
+func (r *repository) GetAll(userID uint, p utils.Pagination) ([]Account, utils.Page, error) {
+	// userID=0 => only global accounts, userID>0 => global + user + groups
+	query := r.db.Model(&Account{}).Where("is_global = ?", true)
+	if userID > 0 {
+		query = r.db.Model(&Account{}).Where("is_global = ? OR user_id = ? OR group_id IN (?)",
+			true, userID, group.IDsOf(r.db, userID))
 	}
-	return accounts, nil
+	return utils.FindPage[Account](query, p, "accounts.id")
 }
 
 // GetByID fetches an account by ID, ensuring user ownership or global
@@ -69,8 +89,9 @@ func (r *repository) GetByID(id, userID uint) (*Account, error) {
 		}
 		return &acc, nil
 	}
-	// userID>0 => either global or user
-	err := r.db.Where("(id = ?) AND (is_global = true OR user_id = ?)", id, userID).
+	// userID>0 => global, user or one of the user's groups
+	err := r.db.Where("(id = ?) AND (is_global = true OR user_id = ? OR group_id IN (?))",
+		id, userID, group.IDsOf(r.db, userID)).
 		First(&acc).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -81,23 +102,129 @@ func (r *repository) GetByID(id, userID uint) (*Account, error) {
 	return &acc, nil
 }
 
-// Update modifies an existing account.
+// Update modifies an account that acc.UserID owns or may edit through a group,
+// then reloads acc from the database. It returns ErrAccountNotFound otherwise.
+// The balance is left alone: it only follows the records through AdjustBalance.
 func (r *repository) Update(acc *Account) error {
 	if acc == nil || acc.ID == 0 {
 		return errors.New("invalid account")
 	}
-	return r.db.Save(acc).Error
+	result := r.db.Model(&Account{}).
+		Where("id = ? AND (user_id = ? OR group_id IN (?))",
+			acc.ID, acc.UserID, group.IDsOf(r.db, acc.UserID, group.EditorRoles...)).
+		Select("name", "account_type", "description", "icon", "group_id").
+		Updates(acc)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccountNotFound
+	}
+	return r.db.First(acc, acc.ID).Error
 }
 
-// Delete removes an account by ID, ensuring user ownership or global check.
+// Delete moves an account the user owns or may edit through a group to the
+// trash. Global accounts are only deleted with userID 0. It returns
+// ErrAccountNotFound when no account was deleted.
 func (r *repository) Delete(id, userID uint) error {
 	if id == 0 {
 		return errors.New("invalid account ID")
 	}
-	if userID == 0 {
-		// only delete if is_global = true
-		return r.db.Where("id = ? AND is_global = true", id).Delete(&Account{}).Error
+	query := r.db.Where("id = ? AND is_global = true", id)
+	if userID > 0 {
+		query = r.db.Where("id = ? AND is_global = false AND (user_id = ? OR group_id IN (?))",
+			id, userID, group.IDsOf(r.db, userID, group.EditorRoles...))
+	}
+	result := query.Delete(&Account{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+// AdjustBalance atomically adds delta to the balance of an account owned by
+// userID, or shared with a group where userID is an editor. Use a negative
+// delta to debit the account.
+func (r *repository) AdjustBalance(id, userID uint, delta money.Amount) error {
+	if id == 0 {
+		return errors.New("invalid account ID")
 	}
-	return r.db.Where("id = ? AND (is_global = true OR user_id = ?)", id, userID).
-		Delete(&Account{}).Error
+	result := r.db.Model(&Account{}).
+		Where("id = ? AND (user_id = ? OR group_id IN (?))", id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		UpdateColumn("balance", gorm.Expr("balance + ?", delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+// GetDeleted returns the trashed accounts the user could have deleted, most
+// recently deleted first. userID 0 stands for the administrators and gets the
+// global accounts.
+func (r *repository) GetDeleted(userID uint) ([]Account, error) {
+	query := r.db.Unscoped().Where("deleted_at IS NOT NULL AND is_global = true")
+	if userID > 0 {
+		query = r.db.Unscoped().
+			Where("deleted_at IS NOT NULL AND is_global = false AND (user_id = ? OR group_id IN (?))",
+				userID, group.IDsOf(r.db, userID, group.EditorRoles...))
+	}
+	var accounts []Account
+	err := query.Order("deleted_at DESC").Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Restore takes an account the user could have deleted out of the trash,
+// a global one for userID 0. It returns ErrAccountNotFound when there is no
+// such account in the trash.
+func (r *repository) Restore(id, userID uint) error {
+	query := r.db.Unscoped().Model(&Account{}).Where("id = ? AND deleted_at IS NOT NULL AND is_global = true", id)
+	if userID > 0 {
+		query = r.db.Unscoped().Model(&Account{}).
+			Where("id = ? AND deleted_at IS NOT NULL AND is_global = false AND (user_id = ? OR group_id IN (?))",
+				id, userID, group.IDsOf(r.db, userID, group.EditorRoles...))
+	}
+	result := query.Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+// Purge permanently removes the accounts trashed before the given time and
+// returns how many were removed. Accounts that records still point at, trashed
+// expenses included, are kept until those records are gone.
+func (r *repository) Purge(before time.Time) (int64, error) {
+	query := r.db.Unscoped().Where("deleted_at < ?", before)
+	for _, ref := range references {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM " + ref.table + " WHERE " + ref.table + "." + ref.column + " = accounts.id)")
+	}
+	result := query.Delete(&Account{})
+	return result.RowsAffected, result.Error
+}
+
+// Unscoped returns a copy of the repository that also sees trashed accounts.
+func (r *repository) Unscoped() Repository {
+	return &repository{db: r.db.Unscoped()}
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
 }
@@ -1,32 +1,70 @@
 package account
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/group"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+)
 
 type Service interface {
-	CreateAccount(acc *Account) error
-	GetAllAccounts(userID uint) ([]Account, error)
+	CreateAccount(ctx context.Context, acc *Account) error
+	GetAllAccounts(userID uint, p utils.Pagination) ([]Account, utils.Page, error)
 	GetAccountByID(id, userID uint) (*Account, error)
-	UpdateAccount(acc *Account) error
-	DeleteAccount(id, userID uint) error
+	UpdateAccount(ctx context.Context, acc *Account) error
+	DeleteAccount(ctx context.Context, id, userID uint) error
+	GetDeletedAccounts(userID uint) ([]Account, error)
+	RestoreAccount(ctx context.Context, id, userID uint) (*Account, error)
+	PurgeAccounts(before time.Time) (int64, error)
+	GetAccountHistory(id, userID uint) ([]audit.Entry, error)
+	RevertAccount(ctx context.Context, id, entryID, userID uint) (*Account, error)
 }
 
 type service struct {
-	repo Repository
+	repo      Repository
+	groups    group.Service
+	auditRepo audit.Repository
+	currency  currency.Service
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, groups group.Service, auditRepo audit.Repository, currencies currency.Service) Service {
+	return &service{repo: repo, groups: groups, auditRepo: auditRepo, currency: currencies}
 }
 
-func (s *service) CreateAccount(acc *Account) error {
+// CreateAccount stores the account. Sharing it with a group requires an
+// owner or editor role in that group. Accounts without a currency are kept in
+// the owner's base currency. The change is recorded in the audit log as made
+// by the actor carried by ctx.
+func (s *service) CreateAccount(ctx context.Context, acc *Account) error {
 	if acc == nil {
 		return errors.New("account cannot be nil")
 	}
-	return s.repo.Create(acc)
+	if err := s.checkGroup(acc); err != nil {
+		return err
+	}
+	acc.Currency = currency.Normalize(acc.Currency)
+	if acc.Currency == "" {
+		code, err := s.currency.BaseCurrency(acc.UserID)
+		if err != nil {
+			return err
+		}
+		acc.Currency = code
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(acc); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceAccount, acc.ID, audit.ActionCreate, nil, acc)
+	})
 }
 
-func (s *service) GetAllAccounts(userID uint) ([]Account, error) {
-	return s.repo.GetAll(userID)
+func (s *service) GetAllAccounts(userID uint, p utils.Pagination) ([]Account, utils.Page, error) {
+	return s.repo.GetAll(userID, p)
 }
 
 func (s *service) GetAccountByID(id, userID uint) (*Account, error) {
@@ -36,16 +74,150 @@ func (s *service) GetAccountByID(id, userID uint) (*Account, error) {
 	return s.repo.GetByID(id, userID)
 }
 
-func (s *service) UpdateAccount(acc *Account) error {
+func (s *service) UpdateAccount(ctx context.Context, acc *Account) error {
 	if acc == nil || acc.ID == 0 {
 		return errors.New("invalid account")
 	}
-	return s.repo.Update(acc)
+	if err := s.checkGroup(acc); err != nil {
+		return err
+	}
+	return s.update(ctx, acc, nil)
 }
 
-func (s *service) DeleteAccount(id, userID uint) error {
+// update saves the account and records the change, as a revert to the version
+// of the entry revertOf when that is set.
+func (s *service) update(ctx context.Context, acc *Account, revertOf *uint) error {
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		before, err := repo.GetByID(acc.ID, acc.UserID)
+		if err != nil {
+			return err
+		}
+		if err := repo.Update(acc); err != nil {
+			return err
+		}
+
+		log := s.auditRepo.WithTx(tx)
+		if revertOf != nil {
+			return audit.RecordRevert(ctx, log, audit.ResourceAccount, acc.ID, *revertOf, before, acc)
+		}
+		return audit.Record(ctx, log, audit.ResourceAccount, acc.ID, audit.ActionUpdate, before, acc)
+	})
+}
+
+// DeleteAccount moves an account to the trash. It returns ErrAccountNotFound
+// when the user cannot see the account.
+func (s *service) DeleteAccount(ctx context.Context, id, userID uint) error {
 	if id == 0 {
 		return errors.New("invalid account ID")
 	}
-	return s.repo.Delete(id, userID)
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		before, err := repo.GetByID(id, userID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrAccountNotFound
+		}
+		if err := repo.Delete(id, userID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceAccount, id, audit.ActionDelete, before, nil)
+	})
+}
+
+func (s *service) GetDeletedAccounts(userID uint) ([]Account, error) {
+	return s.repo.GetDeleted(userID)
+}
+
+// RestoreAccount takes an account out of the trash and returns it. Its balance
+// kept following its expenses while it was trashed, so nothing is re-applied.
+func (s *service) RestoreAccount(ctx context.Context, id, userID uint) (*Account, error) {
+	var restored *Account
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		trashed, err := repo.Unscoped().GetByID(id, userID)
+		if err != nil {
+			return err
+		}
+		if err := repo.Restore(id, userID); err != nil {
+			return err
+		}
+		if restored, err = repo.GetByID(id, userID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceAccount, id, audit.ActionRestore, trashed, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeAccounts permanently removes the accounts trashed before the given time.
+func (s *service) PurgeAccounts(before time.Time) (int64, error) {
+	return s.repo.Purge(before)
+}
+
+// GetAccountHistory returns the audit log of an account the user owns or
+// shares through a group, most recent change first. Global accounts only show
+// theirs to the administrators, as userID 0. Trashed accounts keep their
+// history readable. Balance changes made by expenses, incomes and transfers
+// are in their own logs.
+func (s *service) GetAccountHistory(id, userID uint) ([]audit.Entry, error) {
+	acc, err := s.repo.Unscoped().GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil || (userID > 0 && acc.IsGlobal) {
+		return nil, ErrAccountNotFound
+	}
+	return s.auditRepo.GetHistory(audit.ResourceAccount, id)
+}
+
+// RevertAccount puts an account back to the version recorded by one of its
+// history entries and returns it. The balance is not reverted: it follows the
+// records on the account, which a revert does not bring back.
+func (s *service) RevertAccount(ctx context.Context, id, entryID, userID uint) (*Account, error) {
+	current, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || (userID > 0 && current.IsGlobal) {
+		return nil, ErrAccountNotFound
+	}
+	entry, err := s.auditRepo.GetEntry(audit.ResourceAccount, id, entryID)
+	if err != nil {
+		return nil, err
+	}
+	var version Account
+	if err := entry.Version(&version); err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		ID:          id,
+		Name:        version.Name,
+		AccountType: version.AccountType,
+		Description: version.Description,
+		Icon:        version.Icon,
+		UserID:      userID, // used to check the caller may edit it
+		GroupID:     version.GroupID,
+	}
+	if err := s.checkGroup(acc); err != nil {
+		return nil, err
+	}
+	if err := s.update(ctx, acc, &entryID); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// checkGroup verifies that acc.UserID may put the account into acc.GroupID.
+func (s *service) checkGroup(acc *Account) error {
+	if acc.GroupID == nil {
+		return nil
+	}
+	return s.groups.RequireRole(*acc.GroupID, acc.UserID, group.EditorRoles...)
 }
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// Actor is who made a change: the user and the request it came with. The zero
+// Actor stands for background jobs.
+type Actor struct {
+	UserID    uint
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that carries the actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor carried by ctx, or the zero Actor.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// Context returns the context of the request with the authenticated user and
+// the request ID as the actor of any change it makes.
+func Context(c *gin.Context) context.Context {
+	return WithActor(c.Request.Context(), Actor{
+		UserID:    c.GetUint("userID"),
+		RequestID: c.GetString("requestID"),
+	})
+}
+
+// RequestIDMiddleware gives every request an ID, keeping the one the client
+// sent in X-Request-ID if any, and echoes it in the response.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
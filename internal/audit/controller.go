@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"errors"
+	"strconv"
+	"trackonomy/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParamEntryID reads the history entry ID from the path, answering 400 when it
+// is invalid.
+func ParamEntryID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid history entry ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RespondError answers the errors of reading or reverting to a history entry
+// and reports whether it did.
+func RespondError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrEntryNotFound):
+		response.NotFound(c, "History entry not found", nil)
+	case errors.Is(err, ErrNoVersion):
+		response.BadRequest(c, "Cannot revert to this entry", err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Resources whose changes are recorded, named as in their URLs.
+const (
+	ResourceExpense  = "expenses"
+	ResourceAccount  = "accounts"
+	ResourceCategory = "categories"
+)
+
+// Actions an entry can record.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionRevert  = "revert"
+)
+
+// Entry records one change to a resource. Entries are only ever added: the
+// log is the history of every version the resource went through.
+type Entry struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Resource   string `json:"resource" gorm:"index:idx_audit_resource,priority:1"`
+	ResourceID uint   `json:"resource_id" gorm:"index:idx_audit_resource,priority:2"`
+	Action     string `json:"action"`
+
+	// ActorID is the user who made the change, 0 for background jobs.
+	ActorID   uint   `json:"actor_id" gorm:"index"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Before and After are the resource as its API returns it, without Before
+	// on creation and without After on deletion. Changes holds the fields that differ.
+	Before  map[string]interface{} `json:"before,omitempty" gorm:"type:jsonb;serializer:json"`
+	After   map[string]interface{} `json:"after,omitempty" gorm:"type:jsonb;serializer:json"`
+	Changes map[string]Change      `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	// RevertOf is the entry whose version a revert went back to.
+	RevertOf *uint `json:"revert_of,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName keeps audit entries apart from any other "entries" table.
+func (Entry) TableName() string {
+	return "audit_entries"
+}
+
+// Change is the value of one field before and after a change.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ignored fields change on every write and are left out of Changes.
+var ignored = map[string]bool{"updated_at": true}
+
+// Version decodes the state of the resource after the entry into v.
+func (e *Entry) Version(v interface{}) error {
+	if e.After == nil {
+		return ErrNoVersion
+	}
+	data, err := json.Marshal(e.After)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// snapshot turns a model into the JSON object its API returns. A nil model
+// gives a nil snapshot.
+func snapshot(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diff returns the fields whose values differ between two snapshots.
+func diff(before, after map[string]interface{}) map[string]Change {
+	changes := map[string]Change{}
+	for key, from := range before {
+		if to, ok := after[key]; !ignored[key] && (!ok || !reflect.DeepEqual(from, to)) {
+			changes[key] = Change{From: from, To: to}
+		}
+	}
+	for key, to := range after {
+		if _, ok := before[key]; !ok && !ignored[key] {
+			changes[key] = Change{To: to}
+		}
+	}
+	return changes
+}
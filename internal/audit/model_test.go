@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type note struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func TestSnapshot(t *testing.T) {
+	var none *note
+	tests := []struct {
+		name string
+		in   interface{}
+		want map[string]interface{}
+	}{
+		{name: "nil", in: nil},
+		{name: "nil pointer", in: none},
+		{
+			name: "as the API returns it",
+			in:   &note{ID: 7, Title: "Rent", UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+			// JSON numbers decode as float64 and empty omitempty fields are left out.
+			want: map[string]interface{}{"id": float64(7), "title": "Rent", "updated_at": "2024-03-01T00:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snapshot(tt.in)
+			if err != nil {
+				t.Fatalf("snapshot() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   map[string]Change
+	}{
+		{
+			name:   "unchanged",
+			before: map[string]interface{}{"id": 1.0, "title": "Rent"},
+			after:  map[string]interface{}{"id": 1.0, "title": "Rent"},
+			want:   map[string]Change{},
+		},
+		{
+			name:   "changed",
+			before: map[string]interface{}{"id": 1.0, "title": "Rent", "amount": "10.0000"},
+			after:  map[string]interface{}{"id": 1.0, "title": "Rent", "amount": "12.5000"},
+			want:   map[string]Change{"amount": {From: "10.0000", To: "12.5000"}},
+		},
+		{
+			name:   "added",
+			before: map[string]interface{}{"id": 1.0},
+			after:  map[string]interface{}{"id": 1.0, "description": "monthly"},
+			want:   map[string]Change{"description": {To: "monthly"}},
+		},
+		{
+			name:   "removed",
+			before: map[string]interface{}{"id": 1.0, "description": "monthly"},
+			after:  map[string]interface{}{"id": 1.0},
+			want:   map[string]Change{"description": {From: "monthly"}},
+		},
+		{
+			name:   "nested values compared deeply",
+			before: map[string]interface{}{"tags": []interface{}{"home"}},
+			after:  map[string]interface{}{"tags": []interface{}{"home", "rent"}},
+			want:   map[string]Change{"tags": {From: []interface{}{"home"}, To: []interface{}{"home", "rent"}}},
+		},
+		{
+			name:  "creation",
+			after: map[string]interface{}{"id": 1.0, "title": "Rent", "updated_at": "2024-03-01T00:00:00Z"},
+			want:  map[string]Change{"id": {To: 1.0}, "title": {To: "Rent"}},
+		},
+		{
+			name:   "deletion",
+			before: map[string]interface{}{"id": 1.0, "updated_at": "2024-03-01T00:00:00Z"},
+			want:   map[string]Change{"id": {From: 1.0}},
+		},
+		{
+			name:   "updated_at is left out",
+			before: map[string]interface{}{"title": "Rent", "updated_at": "2024-03-01T00:00:00Z"},
+			after:  map[string]interface{}{"title": "Rent", "updated_at": "2024-03-02T00:00:00Z"},
+			want:   map[string]Change{},
+		},
+		{
+			name:   "updated_at only on one side",
+			before: map[string]interface{}{"title": "Rent"},
+			after:  map[string]interface{}{"title": "Rent", "updated_at": "2024-03-02T00:00:00Z"},
+			want:   map[string]Change{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
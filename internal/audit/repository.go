@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrEntryNotFound is returned when a history entry does not exist for the resource.
+	ErrEntryNotFound = errors.New("history entry not found")
+	// ErrNoVersion is returned when reverting to an entry that deleted the resource.
+	ErrNoVersion = errors.New("this entry has no version to revert to")
+)
+
+// Repository stores audit entries. There is no way to change or remove them.
+type Repository interface {
+	Create(entry *Entry) error
+	GetHistory(resource string, resourceID uint) ([]Entry, error)
+	GetEntry(resource string, resourceID, id uint) (*Entry, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create adds an entry to the log.
+func (r *repository) Create(entry *Entry) error {
+	if entry == nil {
+		return errors.New("entry is nil")
+	}
+	return r.db.Create(entry).Error
+}
+
+// GetHistory returns the entries of a resource, most recent first.
+func (r *repository) GetHistory(resource string, resourceID uint) ([]Entry, error) {
+	var entries []Entry
+	err := r.db.Where("resource = ? AND resource_id = ?", resource, resourceID).
+		Order("id DESC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetEntry fetches one entry of a resource. It returns ErrEntryNotFound when
+// the entry does not exist or belongs to another resource.
+func (r *repository) GetEntry(resource string, resourceID, id uint) (*Entry, error) {
+	var entry Entry
+	err := r.db.Where("id = ? AND resource = ? AND resource_id = ?", id, resource, resourceID).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEntryNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// NewEntry describes a change of a resource from before to after by the actor
+// carried by ctx. Either state may be nil.
+func NewEntry(ctx context.Context, resource string, resourceID uint, action string, before, after interface{}) (*Entry, error) {
+	from, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+	actor := ActorFrom(ctx)
+	return &Entry{
+		Resource:   resource,
+		ResourceID: resourceID,
+		Action:     action,
+		ActorID:    actor.UserID,
+		RequestID:  actor.RequestID,
+		Before:     from,
+		After:      to,
+		Changes:    diff(from, to),
+	}, nil
+}
+
+// Record adds an entry for a change to the log held by repo, usually bound to
+// the transaction that made the change.
+func Record(ctx context.Context, repo Repository, resource string, resourceID uint, action string, before, after interface{}) error {
+	entry, err := NewEntry(ctx, resource, resourceID, action, before, after)
+	if err != nil {
+		return err
+	}
+	return repo.Create(entry)
+}
+
+// RecordRevert adds an entry for a revert of a resource to the version of
+// the entry revertOf.
+func RecordRevert(ctx context.Context, repo Repository, resource string, resourceID, revertOf uint, before, after interface{}) error {
+	entry, err := NewEntry(ctx, resource, resourceID, ActionRevert, before, after)
+	if err != nil {
+		return err
+	}
+	entry.RevertOf = &revertOf
+	return repo.Create(entry)
+}
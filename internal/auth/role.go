@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"trackonomy/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleSource looks up the current role of a user.
+type RoleSource interface {
+	RoleOf(userID uint) (string, error)
+}
+
+// RequireRole only lets through users whose role is one of roles. It runs
+// after AuthMiddleware and reads the role from the database on every request,
+// so a demoted user loses access without waiting for their token to expire.
+func RequireRole(users RoleSource, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := users.RoleOf(c.GetUint("userID"))
+		if err != nil {
+			response.InternalServerError(c, "Could not check user role", err.Error())
+			c.Abort()
+			return
+		}
+
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		response.Forbidden(c, "You are not allowed to access this resource", nil)
+		c.Abort()
+	}
+}
@@ -0,0 +1,206 @@
+package budget
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxUtilizationPeriods caps how many past periods a single utilization request may ask for.
+const maxUtilizationPeriods = 36
+
+type BudgetController struct {
+	service Service
+}
+
+func NewBudgetController(service Service) *BudgetController {
+	return &BudgetController{service: service}
+}
+
+// CreateBudget creates a budget for the caller.
+func (bc *BudgetController) CreateBudget(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req dto.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("Failed to bind CreateBudget JSON", zap.Error(err))
+		response.BadRequest(c, "Invalid budget data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	budget := &Budget{UserID: userID}
+	if err := applyRequest(budget, req); err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	if err := bc.service.CreateBudget(budget); err != nil {
+		logger.Error("Failed to create budget", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not create budget", err.Error())
+		return
+	}
+	response.Created(c, "Budget created successfully", budget)
+}
+
+// GetAllBudgets lists the caller's budgets.
+func (bc *BudgetController) GetAllBudgets(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	budgets, err := bc.service.GetAllBudgets(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve budgets", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve budgets", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Budgets retrieved successfully", budgets)
+}
+
+// GetBudgetByID retrieves a single budget.
+func (bc *BudgetController) GetBudgetByID(c *gin.Context) {
+	budget, ok := bc.findBudget(c)
+	if !ok {
+		return
+	}
+	response.Success(c, http.StatusOK, "Budget retrieved successfully", budget)
+}
+
+// UpdateBudget modifies an existing budget.
+func (bc *BudgetController) UpdateBudget(c *gin.Context) {
+	var req dto.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("Failed to bind UpdateBudget JSON", zap.Error(err))
+		response.BadRequest(c, "Invalid budget data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	budget, ok := bc.findBudget(c)
+	if !ok {
+		return
+	}
+	if err := applyRequest(budget, req); err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	if err := bc.service.UpdateBudget(budget); err != nil {
+		logger.Error("Failed to update budget", zap.Error(err), zap.Uint("budgetID", budget.ID))
+		response.InternalServerError(c, "Could not update budget", err.Error())
+		return
+	}
+	response.Updated(c, "Budget updated successfully", budget)
+}
+
+// DeleteBudget removes a budget by ID.
+func (bc *BudgetController) DeleteBudget(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid budget ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid budget ID", nil)
+		return
+	}
+
+	if err := bc.service.DeleteBudget(uint(id), userID); err != nil {
+		logger.Error("Failed to delete budget", zap.Error(err), zap.Int("budgetID", id))
+		response.InternalServerError(c, "Could not delete budget", err.Error())
+		return
+	}
+	response.Deleted(c, "Budget deleted successfully")
+}
+
+// GetBudgetUtilization returns spent, remaining and percentage for the current
+// period and, with ?periods=N, the N-1 periods before it.
+func (bc *BudgetController) GetBudgetUtilization(c *gin.Context) {
+	periods := 1
+	if periodsStr := c.Query("periods"); periodsStr != "" {
+		n, err := strconv.Atoi(periodsStr)
+		if err != nil || n < 1 || n > maxUtilizationPeriods {
+			response.BadRequest(c, "Invalid periods", gin.H{"periods": "must be between 1 and " + strconv.Itoa(maxUtilizationPeriods)})
+			return
+		}
+		periods = n
+	}
+
+	budget, ok := bc.findBudget(c)
+	if !ok {
+		return
+	}
+
+	utilization, err := bc.service.GetUtilization(budget, time.Now(), periods)
+	if err != nil {
+		logger.Error("Failed to compute budget utilization", zap.Error(err), zap.Uint("budgetID", budget.ID))
+		response.InternalServerError(c, "Could not compute budget utilization", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Budget utilization retrieved successfully", gin.H{
+		"budget":      budget,
+		"utilization": utilization,
+	})
+}
+
+// findBudget loads the budget named by the :id parameter, writing an error
+// response and returning false when it cannot be found.
+func (bc *BudgetController) findBudget(c *gin.Context) (*Budget, bool) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid budget ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid budget ID", nil)
+		return nil, false
+	}
+
+	budget, err := bc.service.GetBudgetByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve budget", zap.Error(err), zap.Int("budgetID", id))
+		response.InternalServerError(c, "Failed to retrieve budget", err.Error())
+		return nil, false
+	}
+	if budget == nil {
+		response.NotFound(c, "Budget not found", nil)
+		return nil, false
+	}
+	return budget, true
+}
+
+// applyRequest copies the request fields onto the budget.
+func applyRequest(budget *Budget, req dto.BudgetRequest) error {
+	if req.StartDate != "" {
+		start, err := time.Parse(utils.DateLayout, req.StartDate)
+		if err != nil {
+			return err
+		}
+		budget.StartDate = start
+	} else if budget.StartDate.IsZero() {
+		budget.StartDate = time.Now()
+	}
+
+	budget.Name = req.Name
+	budget.Amount = req.Amount
+	budget.Period = req.Period
+	budget.CategoryID = req.CategoryID
+	budget.Rollover = req.Rollover
+	if budget.Period == "" {
+		budget.Period = PeriodMonthly
+	}
+	return nil
+}
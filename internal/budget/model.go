@@ -0,0 +1,45 @@
+package budget
+
+import (
+	"time"
+	"trackonomy/internal/category"
+	"trackonomy/internal/money"
+)
+
+// Budget periods.
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+	PeriodYearly  = "yearly"
+)
+
+// Budget is a spending limit per period, either for one category or, when
+// CategoryID is nil, for all of the user's expenses.
+type Budget struct {
+	ID     uint         `gorm:"primaryKey" json:"id"`
+	UserID uint         `json:"user_id"`
+	Name   string       `json:"name"`
+	Amount money.Amount `json:"amount"`
+	Period string       `json:"period"`
+
+	CategoryID *uint              `json:"category_id,omitempty"`
+	Category   *category.Category `json:"-" gorm:"foreignKey:CategoryID"`
+
+	// Rollover carries unspent money from one period into the next.
+	Rollover  bool      `json:"rollover"`
+	StartDate time.Time `json:"start_date"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Utilization describes how much of a budget was used in one period.
+type Utilization struct {
+	PeriodStart time.Time    `json:"period_start"`
+	PeriodEnd   time.Time    `json:"period_end"`
+	Limit       money.Amount `json:"limit"`
+	CarriedOver money.Amount `json:"carried_over"`
+	Spent       money.Amount `json:"spent"`
+	Remaining   money.Amount `json:"remaining"`
+	Percentage  float64      `json:"percentage"`
+}
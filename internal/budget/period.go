@@ -0,0 +1,42 @@
+package budget
+
+import "time"
+
+// periodStart returns the start of the period containing t. Weeks start on Monday.
+func periodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	switch period {
+	case PeriodWeekly:
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(d.Weekday()) + 6) % 7
+		return d.AddDate(0, 0, -offset)
+	case PeriodYearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// addPeriods moves a period start n periods forward (or backward when n is negative).
+func addPeriods(period string, start time.Time, n int) time.Time {
+	switch period {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodYearly:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
+
+// truncUnit maps a budget period to a PostgreSQL date_trunc unit.
+func truncUnit(period string) string {
+	switch period {
+	case PeriodWeekly:
+		return "week"
+	case PeriodYearly:
+		return "year"
+	default:
+		return "month"
+	}
+}
@@ -0,0 +1,70 @@
+package budget
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(budget *Budget) error
+	GetAll(userID uint) ([]Budget, error)
+	GetByID(id, userID uint) (*Budget, error)
+	Update(budget *Budget) error
+	Delete(id, userID uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create adds a new budget to the database.
+func (r *repository) Create(budget *Budget) error {
+	if budget == nil {
+		return errors.New("budget is nil")
+	}
+	return r.db.Create(budget).Error
+}
+
+// GetAll returns all budgets of the user.
+func (r *repository) GetAll(userID uint) ([]Budget, error) {
+	var budgets []Budget
+	err := r.db.Where("user_id = ?", userID).Find(&budgets).Error
+	if err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetByID fetches a budget by ID, ensuring user ownership.
+func (r *repository) GetByID(id, userID uint) (*Budget, error) {
+	var budget Budget
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// Update modifies an existing budget.
+func (r *repository) Update(budget *Budget) error {
+	if budget == nil || budget.ID == 0 {
+		return errors.New("invalid budget")
+	}
+	return r.db.Save(budget).Error
+}
+
+// Delete removes a budget by ID, ensuring user ownership.
+func (r *repository) Delete(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid budget ID")
+	}
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Budget{}).Error
+}
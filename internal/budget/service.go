@@ -0,0 +1,123 @@
+package budget
+
+import (
+	"errors"
+	"time"
+	"trackonomy/internal/expense"
+	"trackonomy/internal/money"
+)
+
+type Service interface {
+	CreateBudget(budget *Budget) error
+	GetAllBudgets(userID uint) ([]Budget, error)
+	GetBudgetByID(id, userID uint) (*Budget, error)
+	UpdateBudget(budget *Budget) error
+	DeleteBudget(id, userID uint) error
+	GetUtilization(budget *Budget, now time.Time, periods int) ([]Utilization, error)
+}
+
+type service struct {
+	repo        Repository
+	expenseRepo expense.Repository
+}
+
+func NewService(repo Repository, expenseRepo expense.Repository) Service {
+	return &service{repo: repo, expenseRepo: expenseRepo}
+}
+
+func (s *service) CreateBudget(budget *Budget) error {
+	if budget == nil {
+		return errors.New("budget cannot be nil")
+	}
+	return s.repo.Create(budget)
+}
+
+func (s *service) GetAllBudgets(userID uint) ([]Budget, error) {
+	return s.repo.GetAll(userID)
+}
+
+func (s *service) GetBudgetByID(id, userID uint) (*Budget, error) {
+	if id == 0 {
+		return nil, errors.New("invalid budget ID")
+	}
+	return s.repo.GetByID(id, userID)
+}
+
+func (s *service) UpdateBudget(budget *Budget) error {
+	if budget == nil || budget.ID == 0 {
+		return errors.New("invalid budget")
+	}
+	return s.repo.Update(budget)
+}
+
+func (s *service) DeleteBudget(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid budget ID")
+	}
+	return s.repo.Delete(id, userID)
+}
+
+// GetUtilization returns spent, remaining and percentage for the period containing
+// now and the periods-1 periods before it, oldest first. Periods before the
+// budget's start date are left out.
+//
+// With rollover enabled, whatever was left unspent in a period is added to the
+// next period's limit, starting from the budget's first period. Overspending is
+// not carried forward.
+func (s *service) GetUtilization(budget *Budget, now time.Time, periods int) ([]Utilization, error) {
+	if periods < 1 {
+		periods = 1
+	}
+
+	current := periodStart(budget.Period, now)
+	first := addPeriods(budget.Period, current, -(periods - 1))
+	start := periodStart(budget.Period, budget.StartDate)
+	if start.After(current) {
+		return []Utilization{}, nil
+	}
+
+	// Rollover depends on every period since the budget started.
+	from := first
+	if budget.Rollover || start.After(first) {
+		from = start
+	}
+
+	totals, err := s.expenseRepo.SumByPeriod(budget.UserID, budget.CategoryID,
+		truncUnit(budget.Period), from, addPeriods(budget.Period, current, 1))
+	if err != nil {
+		return nil, err
+	}
+	spentByPeriod := make(map[time.Time]money.Amount, len(totals))
+	for _, t := range totals {
+		spentByPeriod[t.Period.UTC()] = t.Total
+	}
+
+	result := make([]Utilization, 0, periods)
+	var carry money.Amount
+	for p := from; !p.After(current); p = addPeriods(budget.Period, p, 1) {
+		u := Utilization{
+			PeriodStart: p,
+			PeriodEnd:   addPeriods(budget.Period, p, 1).Add(-time.Nanosecond),
+			Limit:       budget.Amount,
+			Spent:       spentByPeriod[p],
+		}
+		if budget.Rollover {
+			u.CarriedOver = carry
+		}
+
+		available := u.Limit + u.CarriedOver
+		u.Remaining = available - u.Spent
+		if available > 0 {
+			u.Percentage = u.Spent.Float64() / available.Float64() * 100
+		}
+		carry = u.Remaining
+		if carry < 0 {
+			carry = 0
+		}
+
+		if !p.Before(first) {
+			result = append(result, u)
+		}
+	}
+	return result, nil
+}
@@ -1,9 +1,12 @@
 package category
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"trackonomy/internal/audit"
 	"trackonomy/internal/dto"
+	"trackonomy/internal/group"
 	"trackonomy/internal/logger"
 	"trackonomy/internal/response"
 	"trackonomy/internal/utils"
@@ -22,8 +25,8 @@ func NewCategoryController(service Service) *CategoryController {
 }
 
 // CreateGlobalCategory creates a category that is for all users (is_global = true).
+// It is reserved to administrators.
 func (cc *CategoryController) CreateGlobalCategory(c *gin.Context) {
-	// No auth check => userID = 0
 	var req dto.CategoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "Invalid category data", err.Error())
@@ -38,11 +41,16 @@ func (cc *CategoryController) CreateGlobalCategory(c *gin.Context) {
 	cat := &Category{
 		Name:     req.Name,
 		Icon:     req.Icon,
+		Kind:     kindOrDefault(req.Kind),
 		IsGlobal: true, // Mark it global
-		UserID:   0,    // userID=0 or no user
+		UserID:   0,    // global categories belong to no user
+		ParentID: req.ParentID,
 	}
 
-	if err := cc.service.CreateCategory(cat); err != nil {
+	if err := cc.service.CreateCategory(audit.Context(c), cat); err != nil {
+		if respondTreeError(c, err) {
+			return
+		}
 		response.InternalServerError(c, "Could not create global category", err.Error())
 		return
 	}
@@ -68,12 +76,18 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 	}
 
 	cat := &Category{
-		Name:   req.Name,
-		Icon:   req.Icon,
-		UserID: userID, // If categories belong to a user
+		Name:     req.Name,
+		Icon:     req.Icon,
+		Kind:     kindOrDefault(req.Kind),
+		UserID:   userID, // If categories belong to a user
+		GroupID:  req.GroupID,
+		ParentID: req.ParentID,
 	}
 
-	if err := cc.service.CreateCategory(cat); err != nil {
+	if err := cc.service.CreateCategory(audit.Context(c), cat); err != nil {
+		if group.RespondAccessError(c, err) || respondTreeError(c, err) {
+			return
+		}
 		logger.Error("Failed to create category", zap.Error(err), zap.Uint("userID", userID))
 		response.InternalServerError(c, "Could not create category", err.Error())
 		return
@@ -84,25 +98,48 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 // GetAllGlobalCategories fetches only global categories (no user).
 func (cc *CategoryController) GetAllGlobalCategories(c *gin.Context) {
 	// userID=0 => repository returns is_global = true categories
-	cats, err := cc.service.GetAllCategories(0)
+	pagination := utils.NewOptionalPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	cats, page, err := cc.service.GetAllCategories(0, pagination)
 	if err != nil {
 		response.InternalServerError(c, "Could not retrieve global categories", err.Error())
 		return
 	}
-	response.Success(c, http.StatusOK, "Global categories retrieved successfully", cats)
+	response.Success(c, http.StatusOK, "Global categories retrieved successfully", listResponse(pagination, page, cats))
 }
 
 // GetAllCategories lists all categories for the user.
 func (cc *CategoryController) GetAllCategories(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 
-	categories, err := cc.service.GetAllCategories(userID)
+	pagination := utils.NewOptionalPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	categories, page, err := cc.service.GetAllCategories(userID, pagination)
 	if err != nil {
 		logger.Error("Failed to retrieve categories", zap.Error(err), zap.Uint("userID", userID))
 		response.InternalServerError(c, "Could not retrieve categories", err.Error())
 		return
 	}
-	response.Success(c, http.StatusOK, "Categories retrieved successfully", categories)
+	response.Success(c, http.StatusOK, "Categories retrieved successfully", listResponse(pagination, page, categories))
+}
+
+// listResponse returns the categories as a tree in unpaginated mode, and a
+// flat page envelope otherwise.
+func listResponse(pagination utils.Pagination, page utils.Page, categories []Category) interface{} {
+	if pagination.Limit == 0 {
+		return BuildTree(categories)
+	}
+	data := pagination.Meta(page)
+	data["categories"] = categories
+	return data
 }
 
 // GetCategoryByID retrieves a single category by ID.
@@ -132,8 +169,17 @@ func (cc *CategoryController) GetCategoryByID(c *gin.Context) {
 
 // UpdateCategory updates an existing category.
 func (cc *CategoryController) UpdateCategory(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
+	cc.updateCategory(c, c.MustGet("userID").(uint))
+}
 
+// UpdateGlobalCategory updates a global category. It is reserved to administrators.
+func (cc *CategoryController) UpdateGlobalCategory(c *gin.Context) {
+	cc.updateCategory(c, 0)
+}
+
+// updateCategory updates a category userID may edit. userID 0 stands for the
+// administrators, who own the global categories.
+func (cc *CategoryController) updateCategory(c *gin.Context, userID uint) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -156,13 +202,22 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 	}
 
 	cat := &Category{
-		ID:     uint(id),
-		Name:   req.Name,
-		Icon:   req.Icon,
-		UserID: userID, // keep user ownership
+		ID:      uint(id),
+		Name:    req.Name,
+		Icon:    req.Icon,
+		Kind:    kindOrDefault(req.Kind),
+		UserID:  userID, // used to check the caller may edit it
+		GroupID: req.GroupID,
 	}
 
-	if err := cc.service.UpdateCategory(cat); err != nil {
+	if err := cc.service.UpdateCategory(audit.Context(c), cat); err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found", nil)
+			return
+		}
+		if group.RespondAccessError(c, err) {
+			return
+		}
 		logger.Error("Failed to update category", zap.Error(err), zap.Uint("categoryID", cat.ID))
 		response.InternalServerError(c, "Could not update category", err.Error())
 		return
@@ -170,8 +225,8 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 	response.Updated(c, "Category updated successfully", cat)
 }
 
-// DeleteCategory removes a category by ID.
-func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+// MoveCategory moves a category under another one, or to the top level.
+func (cc *CategoryController) MoveCategory(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 
 	idStr := c.Param("id")
@@ -182,10 +237,244 @@ func (cc *CategoryController) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := cc.service.DeleteCategory(uint(id), userID); err != nil {
+	var req dto.CategoryMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid category data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	cat, err := cc.service.MoveCategory(audit.Context(c), uint(id), userID, req.ParentID)
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found", nil)
+			return
+		}
+		if respondTreeError(c, err) {
+			return
+		}
+		logger.Error("Failed to move category", zap.Error(err), zap.Int("categoryID", id))
+		response.InternalServerError(c, "Could not move category", err.Error())
+		return
+	}
+	response.Updated(c, "Category moved successfully", cat)
+}
+
+// DeleteCategory removes a category by ID. A category with sub-categories needs
+// ?children=promote to move them up a level, or ?children=reassign&children_to=
+// to move them under another category. A category still used by the caller's
+// expenses or other records needs ?reassign_to= to move them to another
+// category first. A category other users still use, or filed their own
+// sub-categories under, cannot be deleted (409).
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	cc.deleteCategory(c, c.MustGet("userID").(uint))
+}
+
+// DeleteGlobalCategory removes a global category, taking the same parameters as
+// DeleteCategory. Records of every user count as usage, and ?reassign_to= must
+// name another global category. It is reserved to administrators.
+func (cc *CategoryController) DeleteGlobalCategory(c *gin.Context) {
+	cc.deleteCategory(c, 0)
+}
+
+// deleteCategory removes a category userID may edit. userID 0 stands for the
+// administrators, who own the global categories.
+func (cc *CategoryController) deleteCategory(c *gin.Context, userID uint) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid category ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid category ID", nil)
+		return
+	}
+
+	opts := DeleteOptions{Children: c.Query("children")}
+	if opts.Children != "" && opts.Children != ChildrenPromote && opts.Children != ChildrenReassign {
+		response.BadRequest(c, "Invalid parameters", gin.H{"children": "must be promote or reassign"})
+		return
+	}
+	if raw := c.Query("children_to"); raw != "" {
+		to, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || to == 0 {
+			response.BadRequest(c, "Invalid parameters", gin.H{"children_to": "must be a category ID"})
+			return
+		}
+		parentID := uint(to)
+		opts.ChildrenTo = &parentID
+	}
+	if opts.Children == ChildrenReassign && opts.ChildrenTo == nil {
+		response.BadRequest(c, "Invalid parameters", gin.H{"children_to": "is required when children=reassign"})
+		return
+	}
+	if raw := c.Query("reassign_to"); raw != "" {
+		to, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || to == 0 {
+			response.BadRequest(c, "Invalid parameters", gin.H{"reassign_to": "must be a category ID"})
+			return
+		}
+		targetID := uint(to)
+		opts.ReassignTo = &targetID
+	}
+
+	if err := cc.service.DeleteCategory(audit.Context(c), uint(id), userID, opts); err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found", nil)
+			return
+		}
+		if errors.Is(err, ErrParentNotFound) || errors.Is(err, ErrCategoryCycle) {
+			response.BadRequest(c, "Invalid target category", gin.H{"children_to": err.Error()})
+			return
+		}
+		if respondInUseError(c, err) || respondTreeError(c, err) || respondTargetError(c, err, "reassign_to") {
+			return
+		}
 		logger.Error("Failed to delete category", zap.Error(err), zap.Int("categoryID", id))
 		response.InternalServerError(c, "Could not delete category", err.Error())
 		return
 	}
 	response.Deleted(c, "Category deleted successfully")
 }
+
+// MergeCategories folds the source categories into the target: the caller's
+// expenses, incomes, budgets and rules using them and their sub-categories move
+// to the target, and the sources are deleted. Sources other users still use
+// are refused with 409.
+func (cc *CategoryController) MergeCategories(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req dto.CategoryMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid merge data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	target, err := cc.service.MergeCategories(audit.Context(c), userID, req.SourceIDs, req.TargetID)
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found", nil)
+			return
+		}
+		if errors.Is(err, ErrCategoryCycle) {
+			response.BadRequest(c, "Invalid target category", gin.H{"target_id": err.Error()})
+			return
+		}
+		if respondInUseError(c, err) || respondTreeError(c, err) || respondTargetError(c, err, "target_id") {
+			return
+		}
+		logger.Error("Failed to merge categories", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not merge categories", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Categories merged successfully", target)
+}
+
+// respondInUseError answers requests removing a category that records still
+// use, and reports whether err was such an error.
+func respondInUseError(c *gin.Context, err error) bool {
+	var inUse *InUseError
+	if !errors.As(err, &inUse) {
+		return false
+	}
+	if inUse.Foreign {
+		response.Error(c, http.StatusConflict, "Category is in use by other users", inUse.Usage)
+	} else {
+		response.Error(c, http.StatusConflict, "Category is in use; pass reassign_to to move its records", inUse.Usage)
+	}
+	return true
+}
+
+// respondTargetError answers requests naming an unusable category to move
+// records to, under the given field, and reports whether err was such an error.
+func respondTargetError(c *gin.Context, err error, field string) bool {
+	switch {
+	case errors.Is(err, ErrTargetNotFound), errors.Is(err, ErrInvalidTarget), errors.Is(err, ErrKindMismatch):
+		response.BadRequest(c, "Invalid target category", gin.H{field: err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// GetCategoryHistory lists the recorded changes of a category, most recent first.
+func (cc *CategoryController) GetCategoryHistory(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid category ID", nil)
+		return
+	}
+
+	entries, err := cc.service.GetCategoryHistory(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found", nil)
+			return
+		}
+		logger.Error("Failed to retrieve category history", zap.Error(err), zap.Int("categoryID", id))
+		response.InternalServerError(c, "Could not retrieve category history", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Category history retrieved successfully", entries)
+}
+
+// RevertCategory puts a category back to the version of one of its history entries.
+func (cc *CategoryController) RevertCategory(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid category ID", nil)
+		return
+	}
+	entryID, ok := audit.ParamEntryID(c)
+	if !ok {
+		return
+	}
+
+	cat, err := cc.service.RevertCategory(audit.Context(c), uint(id), entryID, userID)
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			response.NotFound(c, "Category not found", nil)
+			return
+		}
+		if audit.RespondError(c, err) || respondTreeError(c, err) || group.RespondAccessError(c, err) {
+			return
+		}
+		logger.Error("Failed to revert category", zap.Error(err), zap.Int("categoryID", id))
+		response.InternalServerError(c, "Could not revert category", err.Error())
+		return
+	}
+	response.Updated(c, "Category reverted successfully", cat)
+}
+
+// respondTreeError answers requests that would break the category hierarchy,
+// and reports whether err was such an error.
+func respondTreeError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrParentNotFound), errors.Is(err, ErrCategoryCycle):
+		response.BadRequest(c, "Invalid parent category", gin.H{"parent_id": err.Error()})
+	case errors.Is(err, ErrHasChildren):
+		response.BadRequest(c, "Category has sub-categories", gin.H{"children": err.Error()})
+	case errors.Is(err, ErrForeignChildren):
+		response.Error(c, http.StatusConflict, "Category has sub-categories of other users", nil)
+	default:
+		return false
+	}
+	return true
+}
+
+// kindOrDefault treats categories without an explicit kind as expense categories.
+func kindOrDefault(kind string) string {
+	if kind == "" {
+		return KindExpense
+	}
+	return kind
+}
@@ -2,15 +2,50 @@ package category
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of transactions a category can be used for.
+const (
+	KindExpense = "expense"
+	KindIncome  = "income"
+	KindBoth    = "both"
 )
 
-// Category represents a category for an expense.
+// Category represents a category for an expense or an income.
 type Category struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `json:"name"`
-	UserID    uint      `json:"user_id"` // If categories are user-specific
-	IsGlobal  bool      `json:"is_global" gorm:"default:false"`
-	Icon      string    `json:"icon,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `json:"name"`
+	UserID    uint           `json:"user_id"`                         // If categories are user-specific
+	GroupID   *uint          `json:"group_id,omitempty" gorm:"index"` // shared with a group when set
+	IsGlobal  bool           `json:"is_global" gorm:"default:false"`
+	Icon      string         `json:"icon,omitempty"`
+	Kind      string         `json:"kind" gorm:"default:expense"`
+	ParentID  *uint          `json:"parent_id,omitempty" gorm:"index"` // nil for top-level categories
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // set while the category is in the trash
+
+	// Children is only filled in when categories are returned as a tree.
+	Children []Category `json:"children,omitempty" gorm:"-"`
 }
+
+// Accepts reports whether records of kind, KindExpense or KindIncome, can be
+// filed under the category.
+func (c *Category) Accepts(kind string) bool {
+	return c.Kind == kind || c.Kind == KindBoth
+}
+
+// SortFields maps the sort names accepted by the list endpoints to columns.
+var SortFields = map[string]string{
+	"id":         "categories.id",
+	"name":       "categories.name",
+	"kind":       "categories.kind",
+	"is_global":  "categories.is_global",
+	"created_at": "categories.created_at",
+	"updated_at": "categories.updated_at",
+}
+
+// DefaultSort is used when the request does not send ?sort=.
+const DefaultSort = "name:asc"
@@ -2,16 +2,73 @@ package category
 
 import (
 	"errors"
+	"time"
+	"trackonomy/internal/group"
+	"trackonomy/internal/utils"
 
 	"gorm.io/gorm"
 )
 
+// ErrCategoryNotFound is returned when a category does not exist or the user may not change it.
+var ErrCategoryNotFound = errors.New("category not found")
+
+// references lists the columns of other resources that point at a category.
+// They are named here rather than through the models because those packages
+// import this one. owner narrows a query on the table to the rows a user owns
+// or may edit.
+var references = []struct {
+	table, column string
+	owner         func(query *gorm.DB, userID uint) *gorm.DB
+}{
+	{"expenses", "category_id", editableRows},
+	{"expense_items", "category_id", editableItems},
+	{"incomes", "category_id", ownRows},
+	{"recurring_expenses", "category_id", ownRows},
+	{"budgets", "category_id", ownRows},
+	{"rules", "category_id", ownRows},
+	{"import_profiles", "default_category_id", ownRows},
+}
+
+// ownRows keeps the rows of the user.
+func ownRows(query *gorm.DB, userID uint) *gorm.DB {
+	return query.Where("user_id = ?", userID)
+}
+
+// editableRows keeps the rows of the user and those shared with a group they
+// may edit in.
+func editableRows(query *gorm.DB, userID uint) *gorm.DB {
+	return query.Where("user_id = ? OR group_id IN (?)", userID, group.IDsOf(query, userID, group.EditorRoles...))
+}
+
+// editableItems keeps the items of the expenses editableRows keeps.
+func editableItems(query *gorm.DB, userID uint) *gorm.DB {
+	expenses := editableRows(query.Session(&gorm.Session{NewDB: true}).Table("expenses").Select("id"), userID)
+	return query.Where("expense_id IN (?)", expenses)
+}
+
+// Usage counts the records of each table that use a category. Tables without
+// any are left out.
+type Usage map[string]int64
+
 type Repository interface {
 	Create(category *Category) error
-	GetAll(userID uint) ([]Category, error)
+	GetAll(userID uint, p utils.Pagination) ([]Category, utils.Page, error)
 	GetByID(id, userID uint) (*Category, error)
+	GetVisible(id, userID uint) (*Category, error)
 	Update(category *Category) error
+	SetParent(id, userID uint, parentID *uint) error
+	AncestorIDs(id uint) ([]uint, error)
+	CountChildren(id uint) (int64, error)
+	MoveChildren(fromID, userID uint, parentID *uint) error
+	Usage(id, userID uint) (Usage, error)
+	Reassign(fromID, toID, userID uint) error
 	Delete(id, userID uint) error
+	GetDeleted(userID uint) ([]Category, error)
+	Restore(id, userID uint) error
+	Purge(before time.Time) (int64, error)
+	Unscoped() Repository
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
 }
 
 type repository struct {
@@ -30,36 +87,44 @@ func (r *repository) Create(category *Category) error {
 	return r.db.Create(category).Error
 }
 
-// GetAll returns all categories for the given userID (if categories are user-specific).
-func (r *repository) GetAll(userID uint) ([]Category, error) {
-	var categories []Category
-
+// GetAll returns all categories for the given userID (if categories are user-specific),
+// one page at a time (every row when p.Limit is 0).
+func (r *repository) GetAll(userID uint, p utils.Pagination) ([]Category, utils.Page, error) {
 	// If userID is 0, we only want the global categories.
-	// If userID is > 0, we want both global + user-specific.
-	if userID == 0 {
-		// Unauthenticated or explicitly says "0"
-		// Return only global categories
-		err := r.db.Where("is_global = ?", true).Find(&categories).Error
-		if err != nil {
-			return nil, err
-		}
-		return categories, nil
+	// If userID is > 0, we want global + user-specific + the user's groups.
+	query := r.db.Model(&Category{}).Where("is_global = ?", true)
+	if userID > 0 {
+		query = r.db.Model(&Category{}).Where("is_global = ? OR user_id = ? OR group_id IN (?)",
+			true, userID, group.IDsOf(r.db, userID))
 	}
+	return utils.FindPage[Category](query, p, "categories.id")
+}
 
-	// Otherwise, userID > 0 => Return global + user
-	err := r.db.Where("is_global = ? OR user_id = ?", true, userID).
-		Find(&categories).Error
+// GetByID fetches a category by ID that the user owns or shares through a group.
+// userID 0 reaches the global categories, which belong to no user.
+func (r *repository) GetByID(id, userID uint) (*Category, error) {
+	var cat Category
+	err := r.db.Where("id = ? AND (user_id = ? OR group_id IN (?))", id, userID, group.IDsOf(r.db, userID)).
+		First(&cat).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
-	return categories, nil
+	return &cat, nil
 }
 
-// GetByID fetches a category by ID (and optionally checks user ownership).
-func (r *repository) GetByID(id, userID uint) (*Category, error) {
+// GetVisible fetches a category the user can see: a global one, one of their
+// own or one shared through a group. userID 0 only sees global categories.
+func (r *repository) GetVisible(id, userID uint) (*Category, error) {
+	query := r.db.Where("id = ? AND is_global = ?", id, true)
+	if userID > 0 {
+		query = r.db.Where("id = ? AND (is_global = ? OR user_id = ? OR group_id IN (?))",
+			id, true, userID, group.IDsOf(r.db, userID))
+	}
 	var cat Category
-	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&cat).Error
-	if err != nil {
+	if err := query.First(&cat).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -68,18 +133,189 @@ func (r *repository) GetByID(id, userID uint) (*Category, error) {
 	return &cat, nil
 }
 
-// Update modifies an existing category.
+// Update modifies a category that category.UserID owns or may edit through a
+// group, then reloads it. It returns ErrCategoryNotFound otherwise.
 func (r *repository) Update(category *Category) error {
 	if category == nil || category.ID == 0 {
 		return errors.New("invalid category")
 	}
-	return r.db.Save(category).Error
+	result := r.db.Model(&Category{}).
+		Where("id = ? AND (user_id = ? OR group_id IN (?))",
+			category.ID, category.UserID, group.IDsOf(r.db, category.UserID, group.EditorRoles...)).
+		Select("name", "icon", "kind", "group_id").
+		Updates(category)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+	return r.db.First(category, category.ID).Error
+}
+
+// SetParent moves a category the user may edit under parentID, or to the top
+// level when parentID is nil. It returns ErrCategoryNotFound otherwise.
+func (r *repository) SetParent(id, userID uint, parentID *uint) error {
+	result := r.db.Model(&Category{}).
+		Where("id = ? AND (user_id = ? OR group_id IN (?))",
+			id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		Update("parent_id", parentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// AncestorIDs returns the IDs of the category and of every category above it.
+func (r *repository) AncestorIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN up ON c.id = up.parent_id
+		)
+		SELECT id FROM up`, id).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CountChildren returns the number of direct sub-categories of a category.
+func (r *repository) CountChildren(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
 }
 
-// Delete removes a category by ID (check user ownership if needed).
+// MoveChildren puts the direct sub-categories of fromID that the user owns or
+// may edit through a group under parentID, or at the top level when parentID
+// is nil. userID 0 stands for the administrators and moves every one.
+func (r *repository) MoveChildren(fromID, userID uint, parentID *uint) error {
+	query := r.db.Model(&Category{}).Where("parent_id = ?", fromID)
+	if userID > 0 {
+		query = query.Where("user_id = ? OR group_id IN (?)", userID, group.IDsOf(r.db, userID, group.EditorRoles...))
+	}
+	return query.Update("parent_id", parentID).Error
+}
+
+// Usage counts the records of userID that still reference the category, the
+// ones they own or may edit through a group. userID 0 stands for the
+// administrators and counts the records of every user. Trashed records count
+// too, as they come back with the category ID they had.
+func (r *repository) Usage(id, userID uint) (Usage, error) {
+	usage := Usage{}
+	for _, ref := range references {
+		query := r.db.Table(ref.table).Where(ref.column+" = ?", id)
+		if userID > 0 {
+			query = ref.owner(query, userID)
+		}
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			usage[ref.table] = count
+		}
+	}
+	return usage, nil
+}
+
+// Reassign points the records of userID that reference fromID at toID
+// instead, with the same scope as Usage. Records of other users are left
+// alone.
+func (r *repository) Reassign(fromID, toID, userID uint) error {
+	for _, ref := range references {
+		query := r.db.Table(ref.table).Where(ref.column+" = ?", fromID)
+		if userID > 0 {
+			query = ref.owner(query, userID)
+		}
+		if err := query.Update(ref.column, toID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete moves a category the user owns or may edit through a group to the
+// trash. It returns ErrCategoryNotFound otherwise.
 func (r *repository) Delete(id, userID uint) error {
 	if id == 0 {
 		return errors.New("invalid category ID")
 	}
-	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Category{}).Error
+	result := r.db.Where("id = ? AND (user_id = ? OR group_id IN (?))",
+		id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		Delete(&Category{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// GetDeleted returns the trashed categories the user owns or may edit through
+// a group, most recently deleted first.
+func (r *repository) GetDeleted(userID uint) ([]Category, error) {
+	var categories []Category
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND (user_id = ? OR group_id IN (?))",
+			userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		Order("deleted_at DESC").
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Restore takes a category the user may edit out of the trash. It returns to
+// the top level when its parent is no longer there. It returns
+// ErrCategoryNotFound when there is no such category in the trash.
+func (r *repository) Restore(id, userID uint) error {
+	result := r.db.Unscoped().Model(&Category{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND (user_id = ? OR group_id IN (?))",
+			id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCategoryNotFound
+	}
+	return r.db.Model(&Category{}).
+		Where("id = ? AND parent_id IS NOT NULL AND parent_id NOT IN (?)", id, r.db.Model(&Category{}).Select("id")).
+		Update("parent_id", nil).Error
+}
+
+// Purge permanently removes the categories trashed before the given time and
+// returns how many were removed. Categories that records still point at,
+// trashed expenses included, are kept until those records are gone.
+func (r *repository) Purge(before time.Time) (int64, error) {
+	query := r.db.Unscoped().Where("deleted_at < ?", before)
+	for _, ref := range references {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM " + ref.table + " WHERE " + ref.table + "." + ref.column + " = categories.id)")
+	}
+	result := query.Delete(&Category{})
+	return result.RowsAffected, result.Error
+}
+
+// Unscoped returns a copy of the repository that also sees trashed categories.
+func (r *repository) Unscoped() Repository {
+	return &repository{db: r.db.Unscoped()}
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
 }
@@ -1,34 +1,142 @@
 package category
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/group"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// What to do with the sub-categories of a deleted category.
+const (
+	// ChildrenPromote moves them up to the parent of the deleted category.
+	ChildrenPromote = "promote"
+	// ChildrenReassign moves them under another category.
+	ChildrenReassign = "reassign"
 )
 
+var (
+	// ErrParentNotFound is returned when the parent category is not visible to the user.
+	ErrParentNotFound = errors.New("parent category not found")
+	// ErrCategoryCycle is returned when a category would end up below itself.
+	ErrCategoryCycle = errors.New("a category cannot be moved below itself or one of its sub-categories")
+	// ErrHasChildren is returned when deleting a category with sub-categories without saying what happens to them.
+	ErrHasChildren = errors.New("category has sub-categories; choose children=promote or children=reassign")
+	// ErrForeignChildren is returned when deleting a category that other users filed their own sub-categories under.
+	ErrForeignChildren = errors.New("category has sub-categories of other users")
+	// ErrCategoryInUse is wrapped by InUseError.
+	ErrCategoryInUse = errors.New("category is still in use")
+	// ErrTargetNotFound is returned when the category to move records to is not visible to the user.
+	ErrTargetNotFound = errors.New("target category not found")
+	// ErrInvalidTarget is returned when records would be moved to the category being removed.
+	ErrInvalidTarget = errors.New("the target category cannot be one of the categories being removed")
+	// ErrKindMismatch is returned when the target category cannot hold the records of the source.
+	ErrKindMismatch = errors.New("the target category is of a different kind")
+	// ErrWrongKind is returned when a record is filed under a category meant for the other kind of transaction.
+	ErrWrongKind = errors.New("category cannot be used for this kind of transaction")
+)
+
+// CheckUsable verifies that the user can see the category, being global, their
+// own or shared with one of their groups, and file records of kind under it.
+// It returns an error wrapping ErrCategoryNotFound or ErrWrongKind otherwise.
+func CheckUsable(repo Repository, id, userID uint, kind string) error {
+	cat, err := repo.GetVisible(id, userID)
+	if err != nil {
+		return err
+	}
+	if cat == nil {
+		return fmt.Errorf("%w: %d", ErrCategoryNotFound, id)
+	}
+	if !cat.Accepts(kind) {
+		return fmt.Errorf("%w: %d is an %s category", ErrWrongKind, id, cat.Kind)
+	}
+	return nil
+}
+
+// InUseError is returned when deleting a category that records still
+// reference. Usage counts the records of every user; Foreign is set when some
+// belong to other users, which reassigning the caller's records does not move.
+type InUseError struct {
+	Usage   Usage
+	Foreign bool
+}
+
+func (e *InUseError) Error() string {
+	return ErrCategoryInUse.Error()
+}
+
+func (e *InUseError) Is(target error) bool {
+	return target == ErrCategoryInUse
+}
+
+// DeleteOptions say what happens to the sub-categories and the records of a
+// deleted category. ChildrenTo is the new parent when Children is
+// ChildrenReassign. ReassignTo receives the expenses, incomes, budgets, rules
+// and other records of the caller using the category; without it a category
+// they still use cannot be deleted. Records of other users are never moved, so
+// a category they still use cannot be deleted either way.
+type DeleteOptions struct {
+	Children   string
+	ChildrenTo *uint
+	ReassignTo *uint
+}
+
 type Service interface {
-	CreateCategory(cat *Category) error
-	GetAllCategories(userID uint) ([]Category, error)
+	CreateCategory(ctx context.Context, cat *Category) error
+	GetAllCategories(userID uint, p utils.Pagination) ([]Category, utils.Page, error)
 	GetCategoryByID(id, userID uint) (*Category, error)
-	UpdateCategory(cat *Category) error
-	DeleteCategory(id, userID uint) error
+	UpdateCategory(ctx context.Context, cat *Category) error
+	MoveCategory(ctx context.Context, id, userID uint, parentID *uint) (*Category, error)
+	DeleteCategory(ctx context.Context, id, userID uint, opts DeleteOptions) error
+	MergeCategories(ctx context.Context, userID uint, sourceIDs []uint, targetID uint) (*Category, error)
+	GetDeletedCategories(userID uint) ([]Category, error)
+	RestoreCategory(ctx context.Context, id, userID uint) (*Category, error)
+	PurgeCategories(before time.Time) (int64, error)
+	GetCategoryHistory(id, userID uint) ([]audit.Entry, error)
+	RevertCategory(ctx context.Context, id, entryID, userID uint) (*Category, error)
 }
 
 type service struct {
-	repo Repository
+	repo      Repository
+	groups    group.Service
+	auditRepo audit.Repository
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, groups group.Service, auditRepo audit.Repository) Service {
+	return &service{repo: repo, groups: groups, auditRepo: auditRepo}
 }
 
-func (s *service) CreateCategory(cat *Category) error {
+// CreateCategory stores the category. Sharing it with a group requires an
+// owner or editor role in that group, and a parent must be visible to the
+// owner (global categories can only be nested under global ones). The change
+// is recorded in the audit log as made by the actor carried by ctx.
+func (s *service) CreateCategory(ctx context.Context, cat *Category) error {
 	if cat == nil {
 		return errors.New("category cannot be nil")
 	}
-	return s.repo.Create(cat)
+	if err := s.checkGroup(cat); err != nil {
+		return err
+	}
+	if cat.ParentID != nil {
+		if err := s.checkParent(cat, *cat.ParentID); err != nil {
+			return err
+		}
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(cat); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceCategory, cat.ID, audit.ActionCreate, nil, cat)
+	})
 }
 
-func (s *service) GetAllCategories(userID uint) ([]Category, error) {
-	return s.repo.GetAll(userID)
+func (s *service) GetAllCategories(userID uint, p utils.Pagination) ([]Category, utils.Page, error) {
+	return s.repo.GetAll(userID, p)
 }
 
 func (s *service) GetCategoryByID(id, userID uint) (*Category, error) {
@@ -38,16 +146,381 @@ func (s *service) GetCategoryByID(id, userID uint) (*Category, error) {
 	return s.repo.GetByID(id, userID)
 }
 
-func (s *service) UpdateCategory(cat *Category) error {
+func (s *service) UpdateCategory(ctx context.Context, cat *Category) error {
 	if cat == nil || cat.ID == 0 {
 		return errors.New("invalid category")
 	}
-	return s.repo.Update(cat)
+	if err := s.checkGroup(cat); err != nil {
+		return err
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		before, err := repo.GetByID(cat.ID, cat.UserID)
+		if err != nil {
+			return err
+		}
+		if err := repo.Update(cat); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceCategory, cat.ID, audit.ActionUpdate, before, cat)
+	})
 }
 
-func (s *service) DeleteCategory(id, userID uint) error {
+// MoveCategory puts a category under parentID, or at the top level when
+// parentID is nil, and returns it.
+func (s *service) MoveCategory(ctx context.Context, id, userID uint, parentID *uint) (*Category, error) {
+	cat, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cat == nil {
+		return nil, ErrCategoryNotFound
+	}
+	if parentID != nil {
+		if err := s.checkParent(cat, *parentID); err != nil {
+			return nil, err
+		}
+	}
+	moved := *cat
+	moved.ParentID = parentID
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).SetParent(id, userID, parentID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceCategory, id, audit.ActionUpdate, cat, &moved)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+// DeleteCategory removes a category. Its sub-categories, if any, are promoted
+// or reassigned, and the caller's records moved to opts.ReassignTo, in the
+// same transaction. It returns an InUseError while records of any user, such
+// as members of a group the category is shared with, still use it, and
+// ErrForeignChildren when other users filed sub-categories under it.
+func (s *service) DeleteCategory(ctx context.Context, id, userID uint, opts DeleteOptions) error {
 	if id == 0 {
 		return errors.New("invalid category ID")
 	}
-	return s.repo.Delete(id, userID)
+	cat, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if cat == nil {
+		return ErrCategoryNotFound
+	}
+
+	children, err := s.repo.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	var newParent *uint
+	if children > 0 {
+		switch opts.Children {
+		case ChildrenPromote:
+			newParent = cat.ParentID
+		case ChildrenReassign:
+			if opts.ChildrenTo == nil {
+				return ErrParentNotFound
+			}
+			// The new parent must not sit below the deleted category.
+			if err := s.checkParent(cat, *opts.ChildrenTo); err != nil {
+				return err
+			}
+			newParent = opts.ChildrenTo
+		default:
+			return ErrHasChildren
+		}
+	}
+	if opts.ReassignTo != nil {
+		if err := s.checkTarget(userID, []Category{*cat}, *opts.ReassignTo); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if children > 0 {
+			if err := moveChildren(repo, id, userID, newParent); err != nil {
+				return err
+			}
+		}
+		if opts.ReassignTo != nil {
+			if err := repo.Reassign(id, *opts.ReassignTo, userID); err != nil {
+				return err
+			}
+		}
+		if err := checkUnused(repo, id, userID); err != nil {
+			return err
+		}
+		if err := repo.Delete(id, userID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceCategory, id, audit.ActionDelete, cat, nil)
+	})
+}
+
+// MergeCategories moves the caller's records and the sub-categories of the
+// source categories to the target and deletes the sources, in one
+// transaction. Like DeleteCategory, it refuses sources that other users still
+// use or filed sub-categories under. It returns the target.
+func (s *service) MergeCategories(ctx context.Context, userID uint, sourceIDs []uint, targetID uint) (*Category, error) {
+	var sources []Category
+	for _, id := range sourceIDs {
+		cat, err := s.repo.GetByID(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if cat == nil {
+			return nil, ErrCategoryNotFound
+		}
+		sources = append(sources, *cat)
+	}
+	if err := s.checkTarget(userID, sources, targetID); err != nil {
+		return nil, err
+	}
+	// Sub-categories of a source move under the target, so the target must not be one of them.
+	ancestors, err := s.repo.AncestorIDs(targetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ancestor := range ancestors {
+		for _, src := range sources {
+			if ancestor == src.ID {
+				return nil, ErrCategoryCycle
+			}
+		}
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		log := s.auditRepo.WithTx(tx)
+		for i := range sources {
+			src := &sources[i]
+			if err := moveChildren(repo, src.ID, userID, &targetID); err != nil {
+				return err
+			}
+			if err := repo.Reassign(src.ID, targetID, userID); err != nil {
+				return err
+			}
+			if err := checkUnused(repo, src.ID, userID); err != nil {
+				return err
+			}
+			if err := repo.Delete(src.ID, userID); err != nil {
+				return err
+			}
+			if err := audit.Record(ctx, log, audit.ResourceCategory, src.ID, audit.ActionDelete, src, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetVisible(targetID, userID)
+}
+
+// moveChildren puts the sub-categories of a category the user may edit under
+// parentID. It returns ErrForeignChildren when some belong to other users,
+// which are not the caller's to move.
+func moveChildren(repo Repository, id, userID uint, parentID *uint) error {
+	if err := repo.MoveChildren(id, userID, parentID); err != nil {
+		return err
+	}
+	left, err := repo.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	if left > 0 {
+		return ErrForeignChildren
+	}
+	return nil
+}
+
+// checkUnused returns an InUseError while records of any user still reference
+// the category.
+func checkUnused(repo Repository, id, userID uint) error {
+	usage, err := repo.Usage(id, 0)
+	if err != nil {
+		return err
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+	own, err := repo.Usage(id, userID)
+	if err != nil {
+		return err
+	}
+	for table, count := range usage {
+		if own[table] != count {
+			return &InUseError{Usage: usage, Foreign: true}
+		}
+	}
+	return &InUseError{Usage: usage}
+}
+
+func (s *service) GetDeletedCategories(userID uint) ([]Category, error) {
+	return s.repo.GetDeleted(userID)
+}
+
+// RestoreCategory takes a category out of the trash and returns it. Its
+// sub-categories were moved away when it was deleted and stay where they are.
+func (s *service) RestoreCategory(ctx context.Context, id, userID uint) (*Category, error) {
+	var restored *Category
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		trashed, err := repo.Unscoped().GetByID(id, userID)
+		if err != nil {
+			return err
+		}
+		if err := repo.Restore(id, userID); err != nil {
+			return err
+		}
+		if restored, err = repo.GetByID(id, userID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceCategory, id, audit.ActionRestore, trashed, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeCategories permanently removes the categories trashed before the given time.
+func (s *service) PurgeCategories(before time.Time) (int64, error) {
+	return s.repo.Purge(before)
+}
+
+// GetCategoryHistory returns the audit log of a category the user owns or
+// shares through a group, most recent change first. Trashed categories keep
+// their history readable.
+func (s *service) GetCategoryHistory(id, userID uint) ([]audit.Entry, error) {
+	cat, err := s.repo.Unscoped().GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cat == nil {
+		return nil, ErrCategoryNotFound
+	}
+	return s.auditRepo.GetHistory(audit.ResourceCategory, id)
+}
+
+// RevertCategory puts a category back to the version recorded by one of its
+// history entries, including where it sat in the hierarchy, and returns it.
+func (s *service) RevertCategory(ctx context.Context, id, entryID, userID uint) (*Category, error) {
+	current, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrCategoryNotFound
+	}
+	entry, err := s.auditRepo.GetEntry(audit.ResourceCategory, id, entryID)
+	if err != nil {
+		return nil, err
+	}
+	var version Category
+	if err := entry.Version(&version); err != nil {
+		return nil, err
+	}
+
+	cat := &Category{
+		ID:      id,
+		Name:    version.Name,
+		Icon:    version.Icon,
+		Kind:    version.Kind,
+		UserID:  userID, // used to check the caller may edit it
+		GroupID: version.GroupID,
+	}
+	if err := s.checkGroup(cat); err != nil {
+		return nil, err
+	}
+	if version.ParentID != nil {
+		if err := s.checkParent(cat, *version.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	var reverted *Category
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Update(cat); err != nil {
+			return err
+		}
+		if err := repo.SetParent(id, userID, version.ParentID); err != nil {
+			return err
+		}
+		if reverted, err = repo.GetByID(id, userID); err != nil {
+			return err
+		}
+		return audit.RecordRevert(ctx, s.auditRepo.WithTx(tx), audit.ResourceCategory, id, entryID, current, reverted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// checkTarget verifies that the records of the sources can be moved to
+// targetID: a category visible to the user, not one of the sources, whose kind
+// accepts what the sources were used for.
+func (s *service) checkTarget(userID uint, sources []Category, targetID uint) error {
+	for _, src := range sources {
+		if src.ID == targetID {
+			return ErrInvalidTarget
+		}
+	}
+	target, err := s.repo.GetVisible(targetID, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrTargetNotFound
+	}
+	if target.Kind == KindBoth {
+		return nil
+	}
+	for _, src := range sources {
+		if src.Kind != target.Kind {
+			return ErrKindMismatch
+		}
+	}
+	return nil
+}
+
+// checkGroup verifies that cat.UserID may put the category into cat.GroupID.
+func (s *service) checkGroup(cat *Category) error {
+	if cat.GroupID == nil {
+		return nil
+	}
+	return s.groups.RequireRole(*cat.GroupID, cat.UserID, group.EditorRoles...)
+}
+
+// checkParent verifies that parentID is visible to the owner of cat and that
+// it is neither cat itself nor one of its descendants.
+func (s *service) checkParent(cat *Category, parentID uint) error {
+	parent, err := s.repo.GetVisible(parentID, cat.UserID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return ErrParentNotFound
+	}
+	if cat.ID == 0 {
+		return nil
+	}
+	ancestors, err := s.repo.AncestorIDs(parentID)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == cat.ID {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
 }
@@ -0,0 +1,63 @@
+package category
+
+import "gorm.io/gorm"
+
+// BuildTree nests categories under their parents, keeping the order of cats
+// among siblings. Categories whose parent is not in cats become roots.
+func BuildTree(cats []Category) []Category {
+	index := make(map[uint]int, len(cats))
+	for i, c := range cats {
+		index[c.ID] = i
+	}
+	children := make(map[uint][]int, len(cats))
+	var roots []int
+	for i, c := range cats {
+		if c.ParentID != nil {
+			if _, ok := index[*c.ParentID]; ok {
+				children[*c.ParentID] = append(children[*c.ParentID], i)
+				continue
+			}
+		}
+		roots = append(roots, i)
+	}
+
+	var build func(i int) Category
+	build = func(i int) Category {
+		node := cats[i]
+		node.Children = nil
+		for _, child := range children[node.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	tree := make([]Category, 0, len(roots))
+	for _, i := range roots {
+		tree = append(tree, build(i))
+	}
+	return tree
+}
+
+// SubtreeIDs returns a subquery selecting the ID of the category and of all
+// its descendants.
+func SubtreeIDs(db *gorm.DB, id uint) *gorm.DB {
+	return db.Raw(`
+		WITH RECURSIVE sub AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id FROM categories c JOIN sub ON c.parent_id = sub.id
+		)
+		SELECT id FROM sub`, id)
+}
+
+// Ancestry returns a subquery with one row per category and each of its
+// ancestors, including the category itself, in the columns id and root. It is
+// used to roll the spending of sub-categories up into their parents.
+func Ancestry(db *gorm.DB) *gorm.DB {
+	return db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, id AS root FROM categories
+			UNION
+			SELECT c.id, t.root FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT id, root FROM tree`)
+}
@@ -0,0 +1,118 @@
+package currency
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/upload"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxRatesSize is the largest rates file accepted; the full ECB history is
+// well below it.
+const maxRatesSize = 20 * 1024 * 1024
+
+type CurrencyController struct {
+	service Service
+}
+
+func NewCurrencyController(service Service) *CurrencyController {
+	return &CurrencyController{service: service}
+}
+
+// GetRates lists the latest rate of every currency on ?date= (default today),
+// quoted against the reference currency.
+func (cc *CurrencyController) GetRates(c *gin.Context) {
+	date, err := utils.ParseDateOrNow(c.Query("date"))
+	if err != nil {
+		response.BadRequest(c, "Invalid date format, expected YYYY-MM-DD", err.Error())
+		return
+	}
+
+	rates, err := cc.service.GetRates(date)
+	if err != nil {
+		logger.Error("Failed to retrieve exchange rates", zap.Error(err))
+		response.InternalServerError(c, "Could not retrieve exchange rates", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Exchange rates retrieved successfully", gin.H{
+		"reference": Reference,
+		"date":      date.Format(utils.DateLayout),
+		"rates":     rates,
+	})
+}
+
+// ImportRates loads reference rates from an ECB-style file (multipart field
+// "file"). The format is taken from ?format= or else from the file extension.
+func (cc *CurrencyController) ImportRates(c *gin.Context) {
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "A rates file is required", err.Error())
+		return
+	}
+	defer file.Close()
+	if err := upload.ValidateFile(fileHeader, []string{".csv", ".xml"}, maxRatesSize); err != nil {
+		response.BadRequest(c, "File validation failed", err.Error())
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	count, err := cc.service.ImportRates(file, format)
+	if err != nil {
+		logger.Error("Failed to import exchange rates", zap.Error(err))
+		response.BadRequest(c, "Could not import exchange rates", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Exchange rates imported successfully", gin.H{"imported": count})
+}
+
+// GetBaseCurrency returns the currency the caller's reports are in.
+func (cc *CurrencyController) GetBaseCurrency(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	code, err := cc.service.BaseCurrency(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve base currency", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve base currency", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Base currency retrieved successfully", gin.H{"currency": code})
+}
+
+// SetBaseCurrency changes the caller's base currency and converts their
+// expenses to it. It is refused when a rate needed for that is missing.
+func (cc *CurrencyController) SetBaseCurrency(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.BaseCurrencyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	if err := cc.service.SetBaseCurrency(userID, request.Currency); err != nil {
+		if errors.Is(err, ErrNoRate) {
+			response.BadRequest(c, "Could not convert expenses to the new base currency", err.Error())
+			return
+		}
+		logger.Error("Failed to set base currency", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not set base currency", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Base currency updated successfully", gin.H{"currency": Normalize(request.Currency)})
+}
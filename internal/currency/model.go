@@ -0,0 +1,37 @@
+package currency
+
+import (
+	"strings"
+	"time"
+)
+
+// Reference is the currency exchange rates are quoted against, as in the
+// reference rates published by the ECB.
+const Reference = "EUR"
+
+// Default is the currency of accounts, expenses and users that were never
+// given one, and of the records kept before currencies existed.
+var Default = "INR"
+
+// SetDefault changes the default currency. It is called once at startup.
+func SetDefault(code string) {
+	Default = Normalize(code)
+}
+
+// Rate is the value of one unit of the reference currency in Currency on Date.
+type Rate struct {
+	ID       uint      `gorm:"primaryKey" json:"-"`
+	Date     time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_rate_date_currency,priority:1"`
+	Currency string    `json:"currency" gorm:"size:3;uniqueIndex:idx_rate_date_currency,priority:2"`
+	Rate     float64   `json:"rate"`
+}
+
+// TableName keeps rates apart from any other "rates" table.
+func (Rate) TableName() string {
+	return "exchange_rates"
+}
+
+// Normalize returns an ISO 4217 code in upper case without surrounding spaces.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,125 @@
+package currency
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"trackonomy/internal/utils"
+)
+
+// ErrUnknownFormat is returned for a rates file that is neither CSV nor XML.
+var ErrUnknownFormat = errors.New("format must be csv or xml")
+
+// Parse reads reference rates in one of the formats the ECB publishes them in.
+func Parse(r io.Reader, format string) ([]Rate, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return ParseCSV(r)
+	case "xml":
+		return ParseXML(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ParseCSV reads rates laid out like the ECB history file: a Date column
+// followed by one column per currency, one row per day. Empty and N/A cells
+// are skipped.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return nil, errors.New("the first column must be Date")
+	}
+	codes := make([]string, len(header))
+	for i := 1; i < len(header); i++ {
+		codes[i] = Normalize(header[i])
+	}
+
+	var rates []Rate
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		date, err := time.Parse(utils.DateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, record[0])
+		}
+		for i := 1; i < len(record) && i < len(codes); i++ {
+			rate, ok, err := parseRate(record[i])
+			if err != nil {
+				return nil, fmt.Errorf("line %d, %s: %w", line, codes[i], err)
+			}
+			if ok && codes[i] != "" {
+				rates = append(rates, Rate{Date: date, Currency: codes[i], Rate: rate})
+			}
+		}
+	}
+	return rates, nil
+}
+
+// ecbEnvelope is the layout of the ECB XML feeds: a Cube per day holding a
+// Cube per currency.
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseXML reads rates in the layout of the ECB daily and history XML feeds.
+func ParseXML(r io.Reader) ([]Rate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	for _, day := range envelope.Days {
+		date, err := time.Parse(utils.DateLayout, day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", day.Time)
+		}
+		for _, cube := range day.Rates {
+			rate, ok, err := parseRate(cube.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("%s, %s: %w", day.Time, cube.Currency, err)
+			}
+			if ok {
+				rates = append(rates, Rate{Date: date, Currency: Normalize(cube.Currency), Rate: rate})
+			}
+		}
+	}
+	return rates, nil
+}
+
+// parseRate reads one rate, reporting false for cells without one.
+func parseRate(s string) (float64, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "N/A") {
+		return 0, false, nil
+	}
+	rate, err := strconv.ParseFloat(s, 64)
+	if err != nil || rate <= 0 {
+		return 0, false, fmt.Errorf("invalid rate %q", s)
+	}
+	return rate, true, nil
+}
@@ -0,0 +1,76 @@
+package currency
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	f, err := os.Open("testdata/eurofxref-hist.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ParseCSV(f)
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+	assertRates(t, got, []Rate{
+		{Date: date(2024, 1, 5), Currency: "USD", Rate: 1.0921},
+		{Date: date(2024, 1, 5), Currency: "JPY", Rate: 158.37},
+		{Date: date(2024, 1, 5), Currency: "GBP", Rate: 0.86153},
+		{Date: date(2024, 1, 4), Currency: "USD", Rate: 1.0953},
+		{Date: date(2024, 1, 4), Currency: "JPY", Rate: 157.96},
+		{Date: date(2024, 1, 4), Currency: "GBP", Rate: 0.86328},
+	})
+}
+
+func TestParseCSVInvalidRate(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("Date,USD\n2024-01-05,abc\n"))
+	if err == nil {
+		t.Fatal("ParseCSV() error = nil, want an error for an invalid rate")
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	f, err := os.Open("testdata/eurofxref-daily.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ParseXML(f)
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+	assertRates(t, got, []Rate{
+		{Date: date(2024, 1, 5), Currency: "USD", Rate: 1.0921},
+		{Date: date(2024, 1, 5), Currency: "JPY", Rate: 158.37},
+		{Date: date(2024, 1, 5), Currency: "GBP", Rate: 0.86153},
+	})
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	if _, err := Parse(strings.NewReader(""), "json"); err != ErrUnknownFormat {
+		t.Fatalf("Parse() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func assertRates(t *testing.T, got, want []Rate) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d rates, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) || got[i].Currency != want[i].Currency || got[i].Rate != want[i].Rate {
+			t.Errorf("rate %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,138 @@
+package currency
+
+import (
+	"errors"
+	"time"
+	"trackonomy/internal/money"
+	"trackonomy/internal/user"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	SaveRates(rates []Rate) error
+	RateOn(code string, date time.Time) (*Rate, error)
+	RatesOn(date time.Time) ([]Rate, error)
+	BaseCurrency(userID uint) (string, error)
+	SetBaseCurrency(userID uint, code string) error
+	ConvertExpenses(userID uint, code string) (int64, error)
+	ConvertIncomes(userID uint, code string) (int64, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// SaveRates stores rates, replacing any already stored for the same day and currency.
+func (r *repository) SaveRates(rates []Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate"}),
+	}).CreateInBatches(rates, 500).Error
+}
+
+// RateOn returns the latest rate of a currency published on or before date,
+// or nil when there is none. Days without publication, such as weekends, use
+// the last rate before them.
+func (r *repository) RateOn(code string, date time.Time) (*Rate, error) {
+	var rate Rate
+	err := r.db.Where("currency = ? AND date <= ?", code, date).Order("date DESC").First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// RatesOn returns, for every currency, the latest rate on or before date.
+func (r *repository) RatesOn(date time.Time) ([]Rate, error) {
+	var rates []Rate
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (currency) *
+		FROM exchange_rates
+		WHERE date <= ?
+		ORDER BY currency, date DESC`, date).Scan(&rates).Error
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// BaseCurrency returns the base currency of a user, or "" for an unknown user.
+func (r *repository) BaseCurrency(userID uint) (string, error) {
+	var codes []string
+	err := r.db.Model(&user.User{}).Where("id = ?", userID).Pluck("base_currency", &codes).Error
+	if err != nil || len(codes) == 0 {
+		return "", err
+	}
+	return codes[0], nil
+}
+
+// SetBaseCurrency changes the base currency of a user.
+func (r *repository) SetBaseCurrency(userID uint, code string) error {
+	return r.db.Model(&user.User{}).Where("id = ?", userID).Update("base_currency", code).Error
+}
+
+// ConvertExpenses recomputes the base amounts of all of a user's expenses,
+// trashed ones included, in the currency code at the rate of each expense
+// date, rounded to the minor unit of code. Expenses whose currency has no
+// rate on or before their date are left without a base amount; it returns
+// how many.
+func (r *repository) ConvertExpenses(userID uint, code string) (int64, error) {
+	return r.convertBase("expenses", userID, code)
+}
+
+// ConvertIncomes does the same as ConvertExpenses for the user's incomes.
+func (r *repository) ConvertIncomes(userID uint, code string) (int64, error) {
+	return r.convertBase("incomes", userID, code)
+}
+
+// convertBase recomputes the base amounts of the user's rows of table, which
+// has the currency columns of expenses, and counts the rows left without one.
+func (r *repository) convertBase(table string, userID uint, code string) (int64, error) {
+	err := r.db.Exec(`
+		UPDATE `+table+` e SET base_currency = @code, base_amount = CASE
+			WHEN e.currency = @code THEN e.amount
+			ELSE ROUND(e.amount / (`+rateOn("e.currency")+`)::numeric * (`+rateOn("@code")+`)::numeric, @decimals)
+		END
+		WHERE e.user_id = @user`,
+		map[string]interface{}{"code": code, "user": userID, "reference": Reference, "decimals": money.Decimals(code)},
+	).Error
+	if err != nil {
+		return 0, err
+	}
+	var missing int64
+	err = r.db.Table(table).Where("user_id = ? AND base_amount IS NULL", userID).Count(&missing).Error
+	return missing, err
+}
+
+// rateOn is the SQL for the rate of the given currency expression on the date
+// of the expense or income e, or NULL when there is none.
+func rateOn(code string) string {
+	return `CASE WHEN ` + code + ` = @reference THEN 1 ELSE (
+		SELECT r.rate FROM exchange_rates r
+		WHERE r.currency = ` + code + ` AND r.date <= e.date
+		ORDER BY r.date DESC LIMIT 1) END`
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
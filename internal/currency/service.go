@@ -0,0 +1,124 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"trackonomy/internal/money"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoRate is returned when an amount cannot be converted for lack of a rate.
+var ErrNoRate = errors.New("no exchange rate")
+
+type Service interface {
+	Convert(amount money.Amount, from, to string, date time.Time) (money.Amount, error)
+	BaseCurrency(userID uint) (string, error)
+	SetBaseCurrency(userID uint, code string) error
+	ImportRates(r io.Reader, format string) (int, error)
+	GetRates(date time.Time) ([]Rate, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Convert converts an amount between two currencies at the rates of the given
+// date, rounded to the minor unit of the target currency. Both rates are
+// quoted against the reference currency, so any pair can be converted.
+func (s *service) Convert(amount money.Amount, from, to string, date time.Time) (money.Amount, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := s.rate(from, date)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := s.rate(to, date)
+	if err != nil {
+		return 0, err
+	}
+	return amount.Mul(toRate / fromRate).Round(to), nil
+}
+
+// rate returns the value of one unit of the reference currency in code on date.
+func (s *service) rate(code string, date time.Time) (float64, error) {
+	if code == Reference {
+		return 1, nil
+	}
+	rate, err := s.repo.RateOn(code, date)
+	if err != nil {
+		return 0, err
+	}
+	if rate == nil {
+		return 0, fmt.Errorf("%w for %s on or before %s", ErrNoRate, code, date.Format(utils.DateLayout))
+	}
+	return rate.Rate, nil
+}
+
+// BaseCurrency returns the currency the user's reports are in. Background
+// jobs and global records without a user get the default currency.
+func (s *service) BaseCurrency(userID uint) (string, error) {
+	code, err := s.repo.BaseCurrency(userID)
+	if err != nil {
+		return "", err
+	}
+	if code == "" {
+		return Default, nil
+	}
+	return code, nil
+}
+
+// SetBaseCurrency changes the user's base currency and converts the base
+// amounts of all their expenses and incomes to it, in one transaction. It
+// fails with ErrNoRate, changing nothing, when one cannot be converted.
+func (s *service) SetBaseCurrency(userID uint, code string) error {
+	code = Normalize(code)
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.SetBaseCurrency(userID, code); err != nil {
+			return err
+		}
+		missing, err := repo.ConvertExpenses(userID, code)
+		if err != nil {
+			return err
+		}
+		if missing > 0 {
+			return fmt.Errorf("%w to convert %d expenses to %s", ErrNoRate, missing, code)
+		}
+		missing, err = repo.ConvertIncomes(userID, code)
+		if err != nil {
+			return err
+		}
+		if missing > 0 {
+			return fmt.Errorf("%w to convert %d incomes to %s", ErrNoRate, missing, code)
+		}
+		return nil
+	})
+}
+
+// ImportRates stores the rates of an ECB-style CSV or XML file and returns how
+// many were read. Rates already stored for the same day are replaced.
+func (s *service) ImportRates(r io.Reader, format string) (int, error) {
+	rates, err := Parse(r, format)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.SaveRates(rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// GetRates returns the rate of every known currency on date.
+func (s *service) GetRates(date time.Time) ([]Rate, error) {
+	return s.repo.RatesOn(date)
+}
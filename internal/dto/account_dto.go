@@ -1,9 +1,13 @@
 package dto
 
+import "trackonomy/internal/money"
+
 type AccountRequest struct {
-	Name        string  `json:"name" binding:"required" validate:"required,min=2,max=100"`
-	AccountType string  `json:"account_type" binding:"required" validate:"required,min=2,max=100"`
-	Balance     float64 `json:"balance" validate:"min=0"`
-	Description string  `json:"description" validate:"max=255"`
-	Icon        string  `json:"icon" validate:"max=100"`
+	Name        string       `json:"name" binding:"required" validate:"required,min=2,max=100"`
+	AccountType string       `json:"account_type" binding:"required" validate:"required,min=2,max=100"`
+	Balance     money.Amount `json:"balance" validate:"min=0"`              // opening balance, ignored on update
+	Currency    string       `json:"currency" validate:"omitempty,iso4217"` // defaults to the user's base currency
+	Description string       `json:"description" validate:"max=255"`
+	Icon        string       `json:"icon" validate:"max=100"`
+	GroupID     *uint        `json:"group_id" validate:"omitempty,gt=0"`
 }
@@ -0,0 +1,14 @@
+package dto
+
+import "trackonomy/internal/money"
+
+// BudgetRequest represents the payload to create or update a Budget.
+// Leave CategoryID empty for an overall budget across all categories.
+type BudgetRequest struct {
+	Name       string       `json:"name" binding:"required" validate:"required,min=2,max=100"`
+	Amount     money.Amount `json:"amount" binding:"required" validate:"required,gt=0"`
+	Period     string       `json:"period" validate:"omitempty,oneof=weekly monthly yearly"`
+	CategoryID *uint        `json:"category_id" validate:"omitempty,gt=0"`
+	Rollover   bool         `json:"rollover"`
+	StartDate  string       `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+}
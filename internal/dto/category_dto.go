@@ -4,4 +4,22 @@ package dto
 type CategoryRequest struct {
 	Name string `json:"name" binding:"required" validate:"required,min=2,max=100"`
 	Icon string `json:"icon" validate:"max=100"`
+	Kind string `json:"kind" validate:"omitempty,oneof=expense income both"`
+
+	GroupID *uint `json:"group_id" validate:"omitempty,gt=0"`
+
+	// ParentID nests a new category under another one. It is ignored on
+	// update; use CategoryMoveRequest to move an existing category.
+	ParentID *uint `json:"parent_id" validate:"omitempty,gt=0"`
+}
+
+// CategoryMoveRequest moves a category under ParentID, or to the top level when it is null.
+type CategoryMoveRequest struct {
+	ParentID *uint `json:"parent_id" validate:"omitempty,gt=0"`
+}
+
+// CategoryMergeRequest folds the source categories into the target category.
+type CategoryMergeRequest struct {
+	SourceIDs []uint `json:"source_ids" validate:"required,min=1,unique,dive,gt=0"`
+	TargetID  uint   `json:"target_id" validate:"required,gt=0"`
 }
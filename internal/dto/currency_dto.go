@@ -0,0 +1,6 @@
+package dto
+
+// BaseCurrencyRequest changes the currency the caller's reports are in.
+type BaseCurrencyRequest struct {
+	Currency string `json:"currency" binding:"required" validate:"required,iso4217"`
+}
@@ -1,9 +1,31 @@
 package dto
 
+import "trackonomy/internal/money"
+
 type ExpenseRequest struct {
-	Title       string  `json:"title" binding:"required" validate:"required,min=3,max=100"`
-	Description string  `json:"description" validate:"max=255"`
-	Amount      float64 `json:"amount" binding:"required" validate:"required,gt=0"`
+	Title       string       `json:"title" binding:"required" validate:"required,min=3,max=100"`
+	Description string       `json:"description" validate:"max=255"`
+	Amount      money.Amount `json:"amount" binding:"required" validate:"required,gt=0"`
+	Date        string       `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	// Currency defaults to the currency of the account.
+	Currency string `json:"currency" validate:"omitempty,iso4217"`
+
+	// CategoryID may be left out when the expense is split into Items or a rule
+	// sets it, and AccountID when a rule sets it.
+	CategoryID uint  `json:"category_id" validate:"omitempty,gt=0"`
+	AccountID  uint  `json:"account_id" validate:"omitempty,gt=0"`
+	GroupID    *uint `json:"group_id" validate:"omitempty,gt=0"`
+
+	Items []ExpenseItemRequest `json:"items" validate:"omitempty,dive"`
+
+	// Tags are names such as "#trip-goa"; unknown names are created as new tags.
+	Tags []string `json:"tags" validate:"omitempty,max=20,dive,required,max=50,excludesall=0x2C"`
+}
 
-	CategoryID uint `json:"category_id" validate:"required,gt=0"`
+// ExpenseItemRequest is one category split of an expense. The amounts of all
+// items must add up to the expense amount.
+type ExpenseItemRequest struct {
+	CategoryID uint         `json:"category_id" validate:"required,gt=0"`
+	Amount     money.Amount `json:"amount" validate:"required,gt=0"`
+	Note       string       `json:"note" validate:"max=255"`
 }
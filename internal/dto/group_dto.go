@@ -0,0 +1,14 @@
+package dto
+
+type GroupRequest struct {
+	Name string `json:"name" binding:"required" validate:"required,min=2,max=100"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type InvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
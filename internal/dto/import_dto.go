@@ -0,0 +1,21 @@
+package dto
+
+// ImportProfileRequest represents the payload to create or update a CSV column mapping.
+type ImportProfileRequest struct {
+	Name      string `json:"name" binding:"required" validate:"required,min=2,max=100"`
+	Delimiter string `json:"delimiter" validate:"omitempty,len=1"`
+	HasHeader bool   `json:"has_header"`
+
+	DateFormat        string `json:"date_format" validate:"max=50"`
+	DateColumn        string `json:"date_column" validate:"required,max=100"`
+	DescriptionColumn string `json:"description_column" validate:"required,max=100"`
+	AmountColumn      string `json:"amount_column" validate:"required_without_all=DebitColumn CreditColumn,max=100"`
+	DebitColumn       string `json:"debit_column" validate:"max=100"`
+	CreditColumn      string `json:"credit_column" validate:"max=100"`
+	CategoryColumn    string `json:"category_column" validate:"max=100"`
+
+	AmountSign   string `json:"amount_sign" validate:"omitempty,oneof=negative_is_expense positive_is_expense"`
+	DecimalComma bool   `json:"decimal_comma"`
+
+	DefaultCategoryID *uint `json:"default_category_id" validate:"omitempty,gt=0"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "trackonomy/internal/money"
+
+type IncomeRequest struct {
+	Title       string       `json:"title" binding:"required" validate:"required,min=3,max=100"`
+	Description string       `json:"description" validate:"max=255"`
+	Amount      money.Amount `json:"amount" binding:"required" validate:"required,gt=0"`
+	Date        string       `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	// Currency defaults to the currency of the account.
+	Currency string `json:"currency" validate:"omitempty,iso4217"`
+
+	CategoryID uint `json:"category_id" validate:"required,gt=0"`
+	AccountID  uint `json:"account_id" validate:"required,gt=0"`
+}
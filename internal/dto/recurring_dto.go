@@ -0,0 +1,21 @@
+package dto
+
+import "trackonomy/internal/money"
+
+// RecurringExpenseRequest represents the payload to create or update a recurring expense template.
+type RecurringExpenseRequest struct {
+	Title       string       `json:"title" binding:"required" validate:"required,min=3,max=100"`
+	Description string       `json:"description" validate:"max=255"`
+	Amount      money.Amount `json:"amount" binding:"required" validate:"required,gt=0"`
+
+	CategoryID uint `json:"category_id" validate:"required,gt=0"`
+	AccountID  uint `json:"account_id" validate:"required,gt=0"`
+
+	Frequency   string `json:"frequency" validate:"required,oneof=daily weekly monthly yearly"`
+	Interval    int    `json:"interval" validate:"min=0,max=366"`
+	DayOfMonth  int    `json:"day_of_month" validate:"min=-1,max=31"`
+	BusinessDay string `json:"business_day" validate:"omitempty,oneof=none following preceding"`
+	StartDate   string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate     string `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	Active      *bool  `json:"active"`
+}
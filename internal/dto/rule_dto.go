@@ -0,0 +1,23 @@
+package dto
+
+import "trackonomy/internal/money"
+
+// RuleRequest creates or replaces an auto-categorization rule. A rule needs a
+// text condition (match_type and pattern) or an amount range, and at least one
+// of category_id, account_id and tags.
+type RuleRequest struct {
+	Name     string `json:"name" binding:"required" validate:"required,min=2,max=100"`
+	Priority int    `json:"priority" validate:"gte=0"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled"`
+
+	Field     string        `json:"field" validate:"omitempty,oneof=title description any"`
+	MatchType string        `json:"match_type" validate:"omitempty,oneof=contains regex"`
+	Pattern   string        `json:"pattern" validate:"required_with=MatchType,max=255"`
+	MinAmount *money.Amount `json:"min_amount" validate:"omitempty,gte=0"`
+	MaxAmount *money.Amount `json:"max_amount" validate:"omitempty,gte=0"`
+
+	CategoryID *uint    `json:"category_id" validate:"omitempty,gt=0"`
+	AccountID  *uint    `json:"account_id" validate:"omitempty,gt=0"`
+	Tags       []string `json:"tags" validate:"omitempty,max=20,dive,required,max=50,excludesall=0x2C"`
+}
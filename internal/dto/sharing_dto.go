@@ -0,0 +1,27 @@
+package dto
+
+import "trackonomy/internal/money"
+
+type SplitRequest struct {
+	Method       string             `json:"method" validate:"required,oneof=equal exact percent"`
+	Participants []SplitParticipant `json:"participants" validate:"required,min=1,dive"`
+}
+
+// SplitParticipant is one user sharing an expense. Amount is required for
+// exact splits and Percent for percent splits.
+type SplitParticipant struct {
+	UserID  uint         `json:"user_id" validate:"required,gt=0"`
+	Amount  money.Amount `json:"amount" validate:"gte=0"`
+	Percent float64      `json:"percent" validate:"gte=0,lte=100"`
+}
+
+// SettlementRequest records a payment. FromUserID defaults to the caller;
+// the caller must be one of the two parties.
+type SettlementRequest struct {
+	FromUserID uint         `json:"from_user_id" validate:"omitempty,gt=0"`
+	ToUserID   uint         `json:"to_user_id" validate:"required,gt=0"`
+	Amount     money.Amount `json:"amount" binding:"required" validate:"required,gt=0"`
+	Currency   string       `json:"currency" validate:"omitempty,iso4217"` // defaults to the caller's base currency
+	Note       string       `json:"note" validate:"max=255"`
+	Date       string       `json:"date" validate:"omitempty,datetime=2006-01-02"`
+}
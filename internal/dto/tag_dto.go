@@ -0,0 +1,13 @@
+package dto
+
+// TagRequest creates or renames a tag. A leading "#" is ignored and names are
+// stored in lower case.
+type TagRequest struct {
+	Name string `json:"name" binding:"required" validate:"required,max=50,excludesall=0x2C"`
+}
+
+// TagMergeRequest folds the source tags into the target tag.
+type TagMergeRequest struct {
+	SourceIDs []uint `json:"source_ids" validate:"required,min=1,dive,gt=0"`
+	TargetID  uint   `json:"target_id" validate:"required,gt=0"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "trackonomy/internal/money"
+
+type TransferRequest struct {
+	FromAccountID uint         `json:"from_account_id" validate:"required,gt=0"`
+	ToAccountID   uint         `json:"to_account_id" validate:"required,gt=0,nefield=FromAccountID"`
+	Amount        money.Amount `json:"amount" binding:"required" validate:"required,gt=0"`
+	Note          string       `json:"note" validate:"max=255"`
+	Date          string       `json:"date" validate:"omitempty,datetime=2006-01-02"`
+}
@@ -10,3 +10,8 @@ type UserLoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
+
+// UserRoleRequest changes the role of a user.
+type UserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
@@ -0,0 +1,431 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/money"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/tag"
+
+	"gorm.io/gorm"
+)
+
+// The fakes below keep their records in memory and scope them by owner the
+// way the database queries do for users outside any group. Each embeds the
+// interface it fakes, so a method the tests do not expect to be called panics.
+
+const (
+	alice uint = 1
+	bob   uint = 2
+
+	aliceAccount uint = 10
+	bobAccount   uint = 20
+
+	globalCategory uint = 1
+	aliceCategory  uint = 100
+	bobCategory    uint = 200
+	aliceIncome    uint = 300 // one of Alice's income categories
+)
+
+type fakeRepo struct {
+	Repository
+	expenses map[uint]Expense
+	shared   map[uint]bool
+	nextID   uint
+}
+
+func (r *fakeRepo) visible(id, userID uint) *Expense {
+	e, ok := r.expenses[id]
+	if !ok || e.UserID != userID {
+		return nil
+	}
+	return &e
+}
+
+func (r *fakeRepo) Create(e *Expense) error {
+	r.nextID++
+	e.ID = r.nextID
+	r.expenses[e.ID] = *e
+	return nil
+}
+
+func (r *fakeRepo) GetByID(id, userID uint) (*Expense, error) {
+	return r.visible(id, userID), nil
+}
+
+func (r *fakeRepo) GetByIDForUpdate(id, userID uint) (*Expense, error) {
+	return r.visible(id, userID), nil
+}
+
+func (r *fakeRepo) Update(e *Expense) error {
+	r.expenses[e.ID] = *e
+	return nil
+}
+
+func (r *fakeRepo) EachByUser(userID uint, fn func(batch []Expense) error) error {
+	var batch []Expense
+	for _, e := range r.expenses {
+		if e.UserID == userID {
+			batch = append(batch, e)
+		}
+	}
+	return fn(batch)
+}
+
+func (r *fakeRepo) IsShared(id uint) (bool, error) { return r.shared[id], nil }
+
+func (r *fakeRepo) ReplaceItems(uint, []ExpenseItem) error { return nil }
+func (r *fakeRepo) ReplaceTags(uint, []tag.Tag) error      { return nil }
+
+func (r *fakeRepo) Delete(id, userID uint) error {
+	if r.visible(id, userID) == nil {
+		return ErrExpenseNotFound
+	}
+	delete(r.expenses, id)
+	return nil
+}
+
+func (r *fakeRepo) Transaction(fn func(tx *gorm.DB) error) error { return fn(nil) }
+func (r *fakeRepo) WithTx(*gorm.DB) Repository                   { return r }
+
+type fakeAccounts struct {
+	account.Repository
+	balances map[uint]money.Amount
+	owners   map[uint]uint
+}
+
+func (r *fakeAccounts) GetByID(id, userID uint) (*account.Account, error) {
+	if r.owners[id] != userID {
+		return nil, nil
+	}
+	return &account.Account{ID: id, UserID: userID, Currency: "EUR", Balance: r.balances[id]}, nil
+}
+
+func (r *fakeAccounts) AdjustBalance(id, userID uint, delta money.Amount) error {
+	if r.owners[id] != userID {
+		return account.ErrAccountNotFound
+	}
+	r.balances[id] += delta
+	return nil
+}
+
+func (r *fakeAccounts) Unscoped() account.Repository       { return r }
+func (r *fakeAccounts) WithTx(*gorm.DB) account.Repository { return r }
+
+type fakeCategories struct {
+	category.Repository
+	owners map[uint]uint   // 0 for global categories
+	kinds  map[uint]string // expense when missing
+}
+
+func (r *fakeCategories) GetVisible(id, userID uint) (*category.Category, error) {
+	owner, ok := r.owners[id]
+	if !ok || owner != 0 && owner != userID {
+		return nil, nil
+	}
+	kind := r.kinds[id]
+	if kind == "" {
+		kind = category.KindExpense
+	}
+	return &category.Category{ID: id, UserID: owner, IsGlobal: owner == 0, Kind: kind}, nil
+}
+
+type fakeRules struct {
+	rule.Repository
+	rules map[uint]rule.Rule
+}
+
+func (r *fakeRules) GetEnabled(uint) ([]rule.Rule, error) { return nil, nil }
+
+func (r *fakeRules) GetByID(id, userID uint) (*rule.Rule, error) {
+	found, ok := r.rules[id]
+	if !ok || found.UserID != userID {
+		return nil, nil
+	}
+	return &found, nil
+}
+
+type fakeAudit struct {
+	audit.Repository
+	entries []audit.Entry
+}
+
+func (r *fakeAudit) Create(entry *audit.Entry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakeAudit) WithTx(*gorm.DB) audit.Repository { return r }
+
+type fakeCurrency struct {
+	currency.Service
+}
+
+func (fakeCurrency) Convert(amount money.Amount, _, _ string, _ time.Time) (money.Amount, error) {
+	return amount, nil
+}
+
+func (fakeCurrency) BaseCurrency(uint) (string, error) { return currency.Default, nil }
+
+type fixture struct {
+	service  Service
+	repo     *fakeRepo
+	accounts *fakeAccounts
+	rules    *fakeRules
+	expense  Expense // Alice's expense as stored
+}
+
+// newFixture returns a service over fakes holding one expense of Alice's.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		repo: &fakeRepo{expenses: map[uint]Expense{}, shared: map[uint]bool{}},
+		accounts: &fakeAccounts{
+			balances: map[uint]money.Amount{aliceAccount: money.MustParse("100"), bobAccount: money.MustParse("100")},
+			owners:   map[uint]uint{aliceAccount: alice, bobAccount: bob},
+		},
+		rules: &fakeRules{rules: map[uint]rule.Rule{}},
+	}
+	categories := &fakeCategories{
+		owners: map[uint]uint{globalCategory: 0, aliceCategory: alice, bobCategory: bob, aliceIncome: alice},
+		kinds:  map[uint]string{aliceIncome: category.KindIncome},
+	}
+	f.service = NewService(f.repo, f.accounts, categories, nil, f.rules, nil, &fakeAudit{}, fakeCurrency{})
+
+	e := &Expense{
+		Title:      "Groceries",
+		Amount:     money.MustParse("12.50"),
+		Date:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		UserID:     alice,
+		CategoryID: aliceCategory,
+		AccountID:  aliceAccount,
+	}
+	if err := f.service.CreateExpense(context.Background(), e); err != nil {
+		t.Fatalf("creating Alice's expense: %v", err)
+	}
+	f.expense = f.repo.expenses[e.ID]
+	return f
+}
+
+func TestGetExpenseByIDIsScopedToOwner(t *testing.T) {
+	f := newFixture(t)
+
+	got, err := f.service.GetExpenseByID(f.expense.ID, alice)
+	if err != nil || got == nil {
+		t.Fatalf("owner lookup = %v, %v; want the expense", got, err)
+	}
+	got, err = f.service.GetExpenseByID(f.expense.ID, bob)
+	if err != nil || got != nil {
+		t.Fatalf("other user lookup = %v, %v; want nil", got, err)
+	}
+}
+
+func TestUpdateExpenseByOtherUserIsRefused(t *testing.T) {
+	f := newFixture(t)
+
+	edited := f.expense
+	edited.Title = "Mine now"
+	edited.Amount = money.MustParse("1")
+	err := f.service.UpdateExpense(context.Background(), &edited, bob)
+	if !errors.Is(err, ErrExpenseNotFound) {
+		t.Fatalf("UpdateExpense by another user: err = %v, want ErrExpenseNotFound", err)
+	}
+	if stored := f.repo.expenses[f.expense.ID]; stored.Title != "Groceries" || stored.Amount != f.expense.Amount {
+		t.Errorf("expense changed to %q, %s", stored.Title, stored.Amount)
+	}
+	if got, want := f.accounts.balances[aliceAccount], money.MustParse("87.50"); got != want {
+		t.Errorf("account balance = %s, want %s", got, want)
+	}
+}
+
+func TestUpdateExpenseByOwner(t *testing.T) {
+	f := newFixture(t)
+
+	edited := f.expense
+	edited.Amount = money.MustParse("20")
+	if err := f.service.UpdateExpense(context.Background(), &edited, alice); err != nil {
+		t.Fatalf("UpdateExpense by owner: %v", err)
+	}
+	if got, want := f.accounts.balances[aliceAccount], money.MustParse("80"); got != want {
+		t.Errorf("account balance = %s, want %s", got, want)
+	}
+}
+
+func TestUpdateSharedExpense(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		wantErr  error
+	}{
+		{name: "amount", amount: "20", wantErr: ErrShared},
+		{name: "currency", amount: "12.50", currency: "USD", wantErr: ErrShared},
+		{name: "neither", amount: "12.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.repo.shared[f.expense.ID] = true
+
+			edited := f.expense
+			edited.Title = "Weekly groceries"
+			edited.Amount = money.MustParse(tt.amount)
+			if tt.currency != "" {
+				edited.Currency = tt.currency
+			}
+			err := f.service.UpdateExpense(context.Background(), &edited, alice)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored := f.repo.expenses[f.expense.ID]
+			if tt.wantErr != nil && (stored.Amount != f.expense.Amount || stored.Currency != f.expense.Currency) {
+				t.Errorf("expense changed to %s %s", stored.Amount, stored.Currency)
+			}
+			if tt.wantErr == nil && stored.Title != "Weekly groceries" {
+				t.Errorf("title = %q, want the edit saved", stored.Title)
+			}
+			if got, want := f.accounts.balances[aliceAccount], money.MustParse("87.50"); got != want {
+				t.Errorf("account balance = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestDeleteExpenseByOtherUserIsRefused(t *testing.T) {
+	f := newFixture(t)
+
+	err := f.service.DeleteExpense(context.Background(), f.expense.ID, bob)
+	if !errors.Is(err, ErrExpenseNotFound) {
+		t.Fatalf("DeleteExpense by another user: err = %v, want ErrExpenseNotFound", err)
+	}
+	if _, ok := f.repo.expenses[f.expense.ID]; !ok {
+		t.Error("expense was deleted")
+	}
+	if err := f.service.DeleteExpense(context.Background(), f.expense.ID, alice); err != nil {
+		t.Fatalf("DeleteExpense by owner: %v", err)
+	}
+	if _, ok := f.repo.expenses[f.expense.ID]; ok {
+		t.Error("expense was not deleted by its owner")
+	}
+}
+
+func TestCreateExpenseChecksCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		categoryID uint
+		items      []ExpenseItem
+		wantErr    error
+	}{
+		{name: "global", categoryID: globalCategory},
+		{name: "own", categoryID: aliceCategory},
+		{name: "other user's", categoryID: bobCategory, wantErr: category.ErrCategoryNotFound},
+		{name: "unknown", categoryID: 999, wantErr: category.ErrCategoryNotFound},
+		{name: "income category", categoryID: aliceIncome, wantErr: category.ErrWrongKind},
+		{name: "other user's item", items: []ExpenseItem{
+			{CategoryID: aliceCategory, Amount: money.MustParse("5")},
+			{CategoryID: bobCategory, Amount: money.MustParse("5")},
+		}, wantErr: category.ErrCategoryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			e := &Expense{
+				Title:      "Lunch",
+				Amount:     money.MustParse("10"),
+				UserID:     alice,
+				CategoryID: tt.categoryID,
+				AccountID:  aliceAccount,
+				Items:      tt.items,
+			}
+			err := f.service.CreateExpense(context.Background(), e)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if got, want := f.accounts.balances[aliceAccount], money.MustParse("87.50"); got != want {
+					t.Errorf("account balance = %s, want %s", got, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateExpenseChecksCategory(t *testing.T) {
+	f := newFixture(t)
+
+	edited := f.expense
+	edited.CategoryID = bobCategory
+	err := f.service.UpdateExpense(context.Background(), &edited, alice)
+	if !errors.Is(err, category.ErrCategoryNotFound) {
+		t.Fatalf("err = %v, want ErrCategoryNotFound", err)
+	}
+	if stored := f.repo.expenses[f.expense.ID]; stored.CategoryID != aliceCategory {
+		t.Errorf("category changed to %d", stored.CategoryID)
+	}
+}
+
+func TestCreateExpenseWithOtherUsersAccountIsRefused(t *testing.T) {
+	f := newFixture(t)
+
+	e := &Expense{
+		Title:      "Lunch",
+		Amount:     money.MustParse("10"),
+		UserID:     alice,
+		CategoryID: globalCategory,
+		AccountID:  bobAccount,
+	}
+	err := f.service.CreateExpense(context.Background(), e)
+	if !errors.Is(err, account.ErrAccountNotFound) {
+		t.Fatalf("err = %v, want ErrAccountNotFound", err)
+	}
+	if got, want := f.accounts.balances[bobAccount], money.MustParse("100"); got != want {
+		t.Errorf("Bob's balance = %s, want %s", got, want)
+	}
+}
+
+func TestApplyRuleChecksCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		categoryID uint
+		wantErr    error
+	}{
+		{name: "global", categoryID: globalCategory},
+		{name: "other user's", categoryID: bobCategory, wantErr: category.ErrCategoryNotFound},
+		{name: "deleted", categoryID: 999, wantErr: category.ErrCategoryNotFound},
+		{name: "income category", categoryID: aliceIncome, wantErr: category.ErrWrongKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.rules.rules[1] = rule.Rule{ID: 1, UserID: alice, Field: rule.FieldAny, MatchType: rule.MatchContains,
+				Pattern: "groceries", CategoryID: &tt.categoryID}
+
+			changes, err := f.service.ApplyRule(context.Background(), 1, alice)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if stored := f.repo.expenses[f.expense.ID]; stored.CategoryID != aliceCategory {
+					t.Errorf("category changed to %d", stored.CategoryID)
+				}
+				return
+			}
+			if err != nil || len(changes) != 1 {
+				t.Fatalf("ApplyRule() = %v, %v; want one change", changes, err)
+			}
+			if stored := f.repo.expenses[f.expense.ID]; stored.CategoryID != tt.categoryID {
+				t.Errorf("category = %d, want %d", stored.CategoryID, tt.categoryID)
+			}
+		})
+	}
+}
@@ -1,11 +1,21 @@
 package expense
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
 	"trackonomy/internal/dto"
+	"trackonomy/internal/group"
 	"trackonomy/internal/logger"
+	"trackonomy/internal/money"
 	"trackonomy/internal/response"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/tag"
 	"trackonomy/internal/upload"
 	"trackonomy/internal/utils"
 	"trackonomy/internal/validators"
@@ -59,18 +69,29 @@ func (ctrl *ExpenseController) CreateExpense(c *gin.Context) {
 		}
 	}
 
+	date, err := utils.ParseDateOrNow(request.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
 	expense := &Expense{
 		Title:       request.Title,
 		Description: request.Description,
 		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Date:        date,
 		UserID:      userID,
 		CategoryID:  request.CategoryID,
+		AccountID:   request.AccountID,
 		FileURL:     fileURL,
+		GroupID:     request.GroupID,
+		Items:       itemsFromRequest(request.Items),
+		Tags:        tagsFromRequest(request.Tags),
 	}
 
-	if err := ctrl.service.CreateExpense(expense); err != nil {
-		logger.Error("Failed to create expense", zap.Error(err))
-		response.InternalServerError(c, "Could not create expense", err.Error())
+	if err := ctrl.service.CreateExpense(audit.Context(c), expense); err != nil {
+		respondExpenseError(c, err, "create expense")
 		return
 	}
 	response.Created(c, "Expense created successfully", expense)
@@ -79,8 +100,19 @@ func (ctrl *ExpenseController) CreateExpense(c *gin.Context) {
 func (ctrl *ExpenseController) GetAllExpenses(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 
+	filter, filterErrs := NewFilterFromRequest(c)
+	if len(filterErrs) > 0 {
+		response.BadRequest(c, "Invalid filters", filterErrs)
+		return
+	}
+
 	pagination := utils.NewPaginationFromRequest(c)
-	expenses, totalRecords, err := ctrl.service.GetExpensesByUserPaginated(userID, pagination)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	expenses, page, err := ctrl.service.GetExpensesByUserPaginated(userID, filter, pagination)
 
 	if err != nil {
 		logger.Error("Failed to retrieve expenses", zap.Error(err), zap.Uint("userID", userID))
@@ -88,16 +120,50 @@ func (ctrl *ExpenseController) GetAllExpenses(c *gin.Context) {
 		return
 	}
 
-	responseData := gin.H{
-		"expenses":     expenses,
-		"total":        totalRecords,
-		"current_page": pagination.Page,
-		"limit":        pagination.Limit,
-	}
+	responseData := pagination.Meta(page)
+	responseData["expenses"] = expenses
+	responseData["filters"] = filter
 	response.Success(c, http.StatusOK, "Expenses retrieved successfully", responseData)
 }
 
+// ExportExpenses streams the caller's expenses as ?format=csv|jsonl|xlsx,
+// honouring the same filters, search and sort parameters as GetAllExpenses.
+func (ctrl *ExpenseController) ExportExpenses(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		response.BadRequest(c, "Invalid export format", gin.H{"format": "must be one of csv, jsonl, xlsx"})
+		return
+	}
+	filter, filterErrs := NewFilterFromRequest(c, "format")
+	if len(filterErrs) > 0 {
+		response.BadRequest(c, "Invalid filters", filterErrs)
+		return
+	}
+	pagination := utils.NewPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	filename := "expenses-" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	// Headers are already sent once rows start streaming, so a failure can only be logged.
+	if err := ctrl.service.ExportExpenses(userID, filter, pagination, format, c.Writer); err != nil {
+		logger.Error("Failed to export expenses", zap.Error(err), zap.Uint("userID", userID))
+	}
+}
+
+// GetExpenseByID returns an expense the caller owns or shares through a group.
+// Other users' expenses are reported as not found.
 func (ctrl *ExpenseController) GetExpenseByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -106,7 +172,7 @@ func (ctrl *ExpenseController) GetExpenseByID(c *gin.Context) {
 		return
 	}
 
-	expense, err := ctrl.service.GetExpenseByID(uint(id))
+	expense, err := ctrl.service.GetExpenseByID(uint(id), userID)
 	if err != nil {
 		logger.Error("Failed to retrieve expense", zap.Error(err), zap.Int("expenseID", id))
 		response.InternalServerError(c, "Could not retrieve expense", err.Error())
@@ -122,7 +188,10 @@ func (ctrl *ExpenseController) GetExpenseByID(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Expense retrieved successfully", expense)
 }
 
+// UpdateExpense edits an expense the caller owns or may edit through a group.
 func (ctrl *ExpenseController) UpdateExpense(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		response.BadRequest(c, "Invalid expense ID", err.Error())
@@ -141,7 +210,7 @@ func (ctrl *ExpenseController) UpdateExpense(c *gin.Context) {
 	}
 
 	// Retrieve existing expense from DB
-	existingExpense, err := ctrl.service.GetExpenseByID(uint(id))
+	existingExpense, err := ctrl.service.GetExpenseByID(uint(id), userID)
 	if err != nil {
 		logger.Error("Failed to retrieve expense for update", zap.Error(err))
 		response.InternalServerError(c, "Could not retrieve expense", err.Error())
@@ -175,30 +244,207 @@ func (ctrl *ExpenseController) UpdateExpense(c *gin.Context) {
 	}
 
 	// Update other fields
+	if request.Date != "" {
+		date, err := utils.ParseDateOrNow(request.Date)
+		if err != nil {
+			response.BadRequest(c, "Invalid date", err.Error())
+			return
+		}
+		existingExpense.Date = date
+	}
 	existingExpense.Title = request.Title
 	existingExpense.Description = request.Description
 	existingExpense.Amount = request.Amount
+	if request.Currency != "" {
+		existingExpense.Currency = request.Currency
+	}
 	existingExpense.CategoryID = request.CategoryID
+	existingExpense.AccountID = request.AccountID
+	existingExpense.GroupID = request.GroupID
+	existingExpense.Items = itemsFromRequest(request.Items)
+	existingExpense.Tags = tagsFromRequest(request.Tags)
 
-	if err := ctrl.service.UpdateExpense(existingExpense); err != nil {
-		logger.Error("Failed to update expense", zap.Error(err), zap.Int("expenseID", id))
-		response.InternalServerError(c, "Could not update expense", err.Error())
+	if err := ctrl.service.UpdateExpense(audit.Context(c), existingExpense, userID); err != nil {
+		respondExpenseError(c, err, "update expense", zap.Int("expenseID", id))
 		return
 	}
 	response.Updated(c, "Expense updated successfully", existingExpense)
 }
 
+// DeleteExpense moves an expense the caller owns or may edit through a group
+// to the trash.
 func (ctrl *ExpenseController) DeleteExpense(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		response.BadRequest(c, "Invalid expense ID", err.Error())
 		return
 	}
 
-	if err := ctrl.service.DeleteExpense(uint(id)); err != nil {
+	if err := ctrl.service.DeleteExpense(audit.Context(c), uint(id), userID); err != nil {
+		if errors.Is(err, ErrExpenseNotFound) {
+			response.NotFound(c, "Expense not found", nil)
+			return
+		}
 		logger.Error("Failed to delete expense", zap.Error(err), zap.Int("expenseID", id))
 		response.InternalServerError(c, "Could not delete expense", err.Error())
 		return
 	}
 	response.Deleted(c, "Expense deleted successfully")
 }
+
+// DryRunRule lists the changes a rule would make to the caller's existing expenses.
+func (ctrl *ExpenseController) DryRunRule(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID", nil)
+		return
+	}
+
+	changes, err := ctrl.service.PreviewRule(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, rule.ErrRuleNotFound) {
+			response.NotFound(c, "Rule not found", nil)
+			return
+		}
+		if errors.Is(err, category.ErrCategoryNotFound) || errors.Is(err, category.ErrWrongKind) {
+			response.BadRequest(c, "Validation error", gin.H{"category_id": err.Error()})
+			return
+		}
+		logger.Error("Failed to dry-run rule", zap.Error(err), zap.Int("ruleID", id))
+		response.InternalServerError(c, "Could not dry-run rule", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Rule dry-run completed", gin.H{
+		"count":   len(changes),
+		"changes": changes,
+	})
+}
+
+// ApplyRule applies a rule to all of the caller's existing expenses at once.
+func (ctrl *ExpenseController) ApplyRule(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID", nil)
+		return
+	}
+
+	changes, err := ctrl.service.ApplyRule(audit.Context(c), uint(id), userID)
+	if err != nil {
+		respondExpenseError(c, err, "apply rule", zap.Int("ruleID", id))
+		return
+	}
+	response.Success(c, http.StatusOK, "Rule applied successfully", gin.H{
+		"count":   len(changes),
+		"changes": changes,
+	})
+}
+
+// GetExpenseHistory lists the recorded changes of an expense, most recent first.
+func (ctrl *ExpenseController) GetExpenseHistory(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid expense ID", nil)
+		return
+	}
+
+	entries, err := ctrl.service.GetExpenseHistory(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, ErrExpenseNotFound) {
+			response.NotFound(c, "Expense not found", nil)
+			return
+		}
+		logger.Error("Failed to retrieve expense history", zap.Error(err), zap.Int("expenseID", id))
+		response.InternalServerError(c, "Could not retrieve expense history", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Expense history retrieved successfully", entries)
+}
+
+// RevertExpense puts an expense back to the version of one of its history entries.
+func (ctrl *ExpenseController) RevertExpense(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid expense ID", nil)
+		return
+	}
+	entryID, ok := audit.ParamEntryID(c)
+	if !ok {
+		return
+	}
+
+	expense, err := ctrl.service.RevertExpense(audit.Context(c), uint(id), entryID, userID)
+	if err != nil {
+		respondExpenseError(c, err, "revert expense", zap.Int("expenseID", id))
+		return
+	}
+	response.Updated(c, "Expense reverted successfully", expense)
+}
+
+// respondExpenseError answers a failed change to expenses, such as "create
+// expense", mapping the errors of the service to client errors and logging
+// anything else with the given fields.
+func respondExpenseError(c *gin.Context, err error, action string, fields ...zap.Field) {
+	switch {
+	case errors.Is(err, ErrExpenseNotFound):
+		response.NotFound(c, "Expense not found", nil)
+	case errors.Is(err, rule.ErrRuleNotFound):
+		response.NotFound(c, "Rule not found", nil)
+	case errors.Is(err, ErrShared):
+		response.Error(c, http.StatusConflict, "Expense is split with other users", err.Error())
+	case errors.Is(err, account.ErrAccountNotFound):
+		response.BadRequest(c, "Invalid account", err.Error())
+	case errors.Is(err, currency.ErrNoRate):
+		response.BadRequest(c, "Could not convert currency", err.Error())
+	case errors.Is(err, money.ErrInvalid):
+		response.BadRequest(c, "Validation error", gin.H{"amount": err.Error()})
+	case errors.Is(err, ErrItemsTotalMismatch):
+		response.BadRequest(c, "Invalid items", gin.H{"items": err.Error()})
+	case errors.Is(err, ErrCategoryRequired), errors.Is(err, category.ErrCategoryNotFound),
+		errors.Is(err, category.ErrWrongKind):
+		response.BadRequest(c, "Validation error", gin.H{"category_id": err.Error()})
+	case errors.Is(err, ErrAccountRequired):
+		response.BadRequest(c, "Validation error", gin.H{"account_id": err.Error()})
+	default:
+		if audit.RespondError(c, err) || group.RespondAccessError(c, err) {
+			return
+		}
+		logger.Error("Failed to "+action, append(fields, zap.Error(err))...)
+		response.InternalServerError(c, "Could not "+action, err.Error())
+	}
+}
+
+// itemsFromRequest converts the split items of a request into models.
+func itemsFromRequest(reqItems []dto.ExpenseItemRequest) []ExpenseItem {
+	if len(reqItems) == 0 {
+		return nil
+	}
+	items := make([]ExpenseItem, len(reqItems))
+	for i, item := range reqItems {
+		items[i] = ExpenseItem{
+			CategoryID: item.CategoryID,
+			Amount:     item.Amount,
+			Note:       item.Note,
+		}
+	}
+	return items
+}
+
+// tagsFromRequest converts the tag names of a request into unsaved tags. The
+// service looks them up, or creates them, by name.
+func tagsFromRequest(names []string) []tag.Tag {
+	var tags []tag.Tag
+	for _, name := range tag.Normalized(names) {
+		tags = append(tags, tag.Tag{Name: name})
+	}
+	return tags
+}
@@ -0,0 +1,98 @@
+package expense
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"trackonomy/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := logger.InitLogger(true); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newRouter serves the expense endpoints with the caller taken from the
+// X-User-ID header in place of a JWT.
+func newRouter(service Service) *gin.Engine {
+	ctrl := NewExpenseController(service, nil)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.GetHeader("X-User-ID"))
+		c.Set("userID", uint(id))
+	})
+	router.POST("/expenses", ctrl.CreateExpense)
+	router.GET("/expenses/:id", ctrl.GetExpenseByID)
+	router.PUT("/expenses/:id", ctrl.UpdateExpense)
+	router.DELETE("/expenses/:id", ctrl.DeleteExpense)
+	return router
+}
+
+func serve(router *gin.Engine, method, path string, userID uint, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-User-ID", strconv.Itoa(int(userID)))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestExpenseEndpointsHideOtherUsersExpenses(t *testing.T) {
+	f := newFixture(t)
+	router := newRouter(f.service)
+	path := "/expenses/" + strconv.Itoa(int(f.expense.ID))
+	update := `{"title":"Mine now","amount":"1.00","category_id":1,"account_id":20}`
+
+	tests := []struct {
+		method string
+		userID uint
+		body   string
+		want   int
+	}{
+		{http.MethodGet, bob, "", http.StatusNotFound},
+		{http.MethodPut, bob, update, http.StatusNotFound},
+		{http.MethodDelete, bob, "", http.StatusNotFound},
+		{http.MethodGet, alice, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := serve(router, tt.method, path, tt.userID, tt.body)
+		if w.Code != tt.want {
+			t.Errorf("%s %s as user %d: status %d, want %d: %s", tt.method, path, tt.userID, w.Code, tt.want, w.Body)
+		}
+	}
+
+	stored, ok := f.repo.expenses[f.expense.ID]
+	if !ok {
+		t.Fatal("expense was deleted by another user")
+	}
+	if stored.Title != "Groceries" || stored.AccountID != aliceAccount {
+		t.Errorf("expense was changed by another user: %+v", stored)
+	}
+}
+
+func TestCreateExpenseEndpointRejectsOtherUsersCategory(t *testing.T) {
+	f := newFixture(t)
+	router := newRouter(f.service)
+
+	body := `{"title":"Lunch","amount":"10.00","category_id":200,"account_id":10}`
+	w := serve(router, http.MethodPost, "/expenses", alice, body)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "category_id") {
+		t.Errorf("response does not point at category_id: %s", w.Body)
+	}
+	if len(f.repo.expenses) != 1 {
+		t.Errorf("%d expenses stored, want 1", len(f.repo.expenses))
+	}
+}
@@ -0,0 +1,66 @@
+package expense
+
+import (
+	"trackonomy/internal/account"
+	"trackonomy/internal/currency"
+)
+
+// convert fills in the currency of the expense, defaulting to the currency of
+// its account, and works out the amount to debit from the account and the
+// amount in the owner's base currency at the rates of the expense date. The
+// amounts entered are first rounded as in roundAmounts. It returns an error
+// wrapping currency.ErrNoRate when a rate is missing.
+func (s *service) convert(expense *Expense) error {
+	acc, err := s.accountRepo.Unscoped().GetByID(expense.AccountID, expense.UserID)
+	if err != nil {
+		return err
+	}
+	if acc == nil {
+		return account.ErrAccountNotFound
+	}
+	accountCurrency := currency.Normalize(acc.Currency)
+	if accountCurrency == "" {
+		accountCurrency = currency.Default
+	}
+
+	expense.Currency = currency.Normalize(expense.Currency)
+	if expense.Currency == "" {
+		expense.Currency = accountCurrency
+	}
+	if err := roundAmounts(expense); err != nil {
+		return err
+	}
+	expense.AccountAmount, err = s.currency.Convert(expense.Amount, expense.Currency, accountCurrency, expense.Date)
+	if err != nil {
+		return err
+	}
+
+	base, err := s.currency.BaseCurrency(expense.UserID)
+	if err != nil {
+		return err
+	}
+	expense.BaseAmount, err = s.currency.Convert(expense.Amount, expense.Currency, base, expense.Date)
+	if err != nil {
+		return err
+	}
+	expense.BaseCurrency = base
+	return nil
+}
+
+// roundAmounts rounds the amount of the expense and of its items to the minor
+// unit of the expense currency. Amounts that round to nothing return an error
+// wrapping money.ErrInvalid, and items that no longer add up to the amount
+// return ErrItemsTotalMismatch.
+func roundAmounts(expense *Expense) error {
+	amount, err := expense.Amount.RoundPositive(expense.Currency)
+	if err != nil {
+		return err
+	}
+	expense.Amount = amount
+	for i := range expense.Items {
+		if expense.Items[i].Amount, err = expense.Items[i].Amount.RoundPositive(expense.Currency); err != nil {
+			return err
+		}
+	}
+	return checkItems(expense)
+}
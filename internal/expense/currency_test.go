@@ -0,0 +1,59 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"trackonomy/internal/money"
+)
+
+func TestCreateExpenseRoundsAmounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    string
+		items     []string
+		want      string
+		wantItems []string
+		wantErr   error
+	}{
+		{name: "to the cent", amount: "10.004", want: "10.00"},
+		{name: "items adding up", amount: "10", items: []string{"4.996", "5.004"}, want: "10.00", wantItems: []string{"5.00", "5.00"}},
+		{name: "items no longer adding up", amount: "10.006", items: []string{"5.003", "5.003"}, wantErr: ErrItemsTotalMismatch},
+		{name: "less than a cent", amount: "0.004", wantErr: money.ErrInvalid},
+		{name: "item of less than a cent", amount: "10.001", items: []string{"10", "0.001"}, wantErr: money.ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			e := &Expense{
+				Title:      "Lunch",
+				Amount:     money.MustParse(tt.amount),
+				UserID:     alice,
+				CategoryID: aliceCategory,
+				AccountID:  aliceAccount,
+			}
+			for _, amount := range tt.items {
+				e.Items = append(e.Items, ExpenseItem{CategoryID: aliceCategory, Amount: money.MustParse(amount)})
+			}
+			err := f.service.CreateExpense(context.Background(), e)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			stored := f.repo.expenses[e.ID]
+			if stored.Amount != money.MustParse(tt.want) {
+				t.Errorf("amount = %s, want %s", stored.Amount, tt.want)
+			}
+			for i, want := range tt.wantItems {
+				if stored.Items[i].Amount != money.MustParse(want) {
+					t.Errorf("item %d amount = %s, want %s", i, stored.Items[i].Amount, want)
+				}
+			}
+		})
+	}
+}
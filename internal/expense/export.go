@@ -0,0 +1,137 @@
+package expense
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/xlsx"
+)
+
+// Supported export formats and their content types.
+var exportContentTypes = map[string]string{
+	"csv":   "text/csv; charset=utf-8",
+	"jsonl": "application/x-ndjson",
+	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var exportHeader = []string{
+	"id", "date", "title", "description", "amount", "currency",
+	"category_id", "category", "account_id", "account", "file_url", "created_at",
+}
+
+// rowWriter writes export rows in one format.
+type rowWriter interface {
+	Write(row ExportRow) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer) (rowWriter, error) {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportHeader); err != nil {
+			return nil, err
+		}
+		return &csvRowWriter{w: cw}, nil
+	case "jsonl":
+		return &jsonlRowWriter{enc: json.NewEncoder(w)}, nil
+	case "xlsx":
+		xw, err := xlsx.NewWriter(w, "Expenses")
+		if err != nil {
+			return nil, err
+		}
+		header := make([]interface{}, len(exportHeader))
+		for i, h := range exportHeader {
+			header[i] = h
+		}
+		if err := xw.WriteRow(header...); err != nil {
+			return nil, err
+		}
+		return &xlsxRowWriter{w: xw}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvRowWriter struct {
+	w     *csv.Writer
+	count int
+}
+
+func (c *csvRowWriter) Write(row ExportRow) error {
+	err := c.w.Write([]string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.Date.Format(utils.DateLayout),
+		safeCell(row.Title),
+		safeCell(row.Description),
+		row.Amount.String(),
+		row.Currency,
+		strconv.FormatUint(uint64(row.CategoryID), 10),
+		safeCell(row.CategoryName),
+		strconv.FormatUint(uint64(row.AccountID), 10),
+		safeCell(row.AccountName),
+		row.FileURL,
+		row.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	})
+	// Flush now and then so rows reach the client while the export is running.
+	c.count++
+	if c.count%500 == 0 {
+		c.w.Flush()
+	}
+	return err
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlRowWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlRowWriter) Write(row ExportRow) error {
+	return j.enc.Encode(row)
+}
+
+func (j *jsonlRowWriter) Close() error {
+	return nil
+}
+
+type xlsxRowWriter struct {
+	w *xlsx.Writer
+}
+
+func (x *xlsxRowWriter) Write(row ExportRow) error {
+	return x.w.WriteRow(
+		row.ID,
+		row.Date.Format(utils.DateLayout),
+		row.Title,
+		row.Description,
+		row.Amount.Float64(), // spreadsheets keep numbers as floats anyway
+		row.Currency,
+		row.CategoryID,
+		row.CategoryName,
+		row.AccountID,
+		row.AccountName,
+		row.FileURL,
+		row.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	)
+}
+
+func (x *xlsxRowWriter) Close() error {
+	return x.w.Close()
+}
+
+// safeCell stops spreadsheet programs from treating user text as a formula
+// when a CSV export is opened.
+func safeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
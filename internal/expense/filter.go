@@ -0,0 +1,174 @@
+package expense
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"trackonomy/internal/money"
+	"trackonomy/internal/tag"
+	"trackonomy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// listParams are the query parameters handled by utils.Pagination.
+var listParams = []string{"page", "limit", "sort", "search"}
+
+// Filter narrows down the expense list. Nil or empty fields are not applied.
+type Filter struct {
+	From          *time.Time    `json:"from,omitempty"`
+	To            *time.Time    `json:"to,omitempty"` // inclusive
+	MinAmount     *money.Amount `json:"min_amount,omitempty"`
+	MaxAmount     *money.Amount `json:"max_amount,omitempty"`
+	CategoryIDs   []uint        `json:"category_ids,omitempty"`
+	AccountIDs    []uint        `json:"account_ids,omitempty"`
+	TagIDs        []uint        `json:"tag_ids,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+	HasAttachment *bool         `json:"has_attachment,omitempty"`
+}
+
+// NewFilterFromRequest parses the expense filters from the query string:
+//
+//	from, to                  dates in YYYY-MM-DD format (inclusive)
+//	min_amount, max_amount    numbers
+//	category_ids, account_ids comma-separated or repeated IDs
+//	tag_ids                   comma-separated or repeated IDs
+//	tags                      comma-separated or repeated tag names
+//	has_attachment            true or false
+//
+// Any other parameter, apart from the pagination ones and the given extras, is
+// rejected. The returned map holds one message per invalid field and is empty
+// when the query is valid.
+func NewFilterFromRequest(c *gin.Context, extraParams ...string) (Filter, map[string]string) {
+	var f Filter
+	errs := map[string]string{}
+
+	allowed := map[string]bool{
+		"from": true, "to": true, "min_amount": true, "max_amount": true,
+		"category_ids": true, "account_ids": true, "tag_ids": true, "tags": true,
+		"has_attachment": true,
+	}
+	for _, p := range append(listParams, extraParams...) {
+		allowed[p] = true
+	}
+	for key := range c.Request.URL.Query() {
+		if !allowed[key] {
+			errs[key] = "unknown filter"
+		}
+	}
+
+	f.From = parseDateParam(c, "from", errs)
+	f.To = parseDateParam(c, "to", errs)
+	if f.From != nil && f.To != nil && f.From.After(*f.To) {
+		errs["from"] = "must not be after to"
+	}
+
+	f.MinAmount = parseAmountParam(c, "min_amount", errs)
+	f.MaxAmount = parseAmountParam(c, "max_amount", errs)
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		errs["min_amount"] = "must not be greater than max_amount"
+	}
+
+	f.CategoryIDs = parseIDsParam(c, "category_ids", errs)
+	f.AccountIDs = parseIDsParam(c, "account_ids", errs)
+	f.TagIDs = parseIDsParam(c, "tag_ids", errs)
+	for _, v := range c.QueryArray("tags") {
+		f.Tags = append(f.Tags, strings.Split(v, ",")...)
+	}
+	f.Tags = tag.Normalized(f.Tags)
+
+	if v, ok := c.GetQuery("has_attachment"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs["has_attachment"] = "must be true or false"
+		} else {
+			f.HasAttachment = &b
+		}
+	}
+
+	return f, errs
+}
+
+// apply adds the filter conditions to a query on the expenses table.
+func (f Filter) apply(query *gorm.DB) *gorm.DB {
+	if f.From != nil {
+		query = query.Where("expenses.date >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("expenses.date < ?", f.To.AddDate(0, 0, 1))
+	}
+	if f.MinAmount != nil {
+		query = query.Where("expenses.amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("expenses.amount <= ?", *f.MaxAmount)
+	}
+	if len(f.CategoryIDs) > 0 {
+		// Split expenses match when any of their items is in one of the categories
+		query = query.Where("(expenses.category_id IN ? OR EXISTS "+
+			"(SELECT 1 FROM expense_items i WHERE i.expense_id = expenses.id AND i.category_id IN ?))",
+			f.CategoryIDs, f.CategoryIDs)
+	}
+	if len(f.AccountIDs) > 0 {
+		query = query.Where("expenses.account_id IN ?", f.AccountIDs)
+	}
+	// Tag filters match expenses carrying any of the tags
+	if len(f.TagIDs) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM expense_tags et WHERE et.expense_id = expenses.id AND et.tag_id IN ?)",
+			f.TagIDs)
+	}
+	if len(f.Tags) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id "+
+			"WHERE et.expense_id = expenses.id AND t.name IN ?)", f.Tags)
+	}
+	if f.HasAttachment != nil {
+		if *f.HasAttachment {
+			query = query.Where("expenses.file_url <> ''")
+		} else {
+			query = query.Where("(expenses.file_url = '' OR expenses.file_url IS NULL)")
+		}
+	}
+	return query
+}
+
+func parseDateParam(c *gin.Context, key string, errs map[string]string) *time.Time {
+	v, ok := c.GetQuery(key)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(utils.DateLayout, v)
+	if err != nil {
+		errs[key] = "must be a date in YYYY-MM-DD format"
+		return nil
+	}
+	return &t
+}
+
+func parseAmountParam(c *gin.Context, key string, errs map[string]string) *money.Amount {
+	v, ok := c.GetQuery(key)
+	if !ok {
+		return nil
+	}
+	n, err := money.Parse(v)
+	if err != nil || n < 0 {
+		errs[key] = "must be a non-negative number"
+		return nil
+	}
+	return &n
+}
+
+func parseIDsParam(c *gin.Context, key string, errs map[string]string) []uint {
+	var ids []uint
+	for _, v := range c.QueryArray(key) {
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil || id == 0 {
+				errs[key] = "must be a comma-separated list of IDs"
+				return nil
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,87 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/group"
+	"trackonomy/internal/tag"
+)
+
+// GetExpenseHistory returns the audit log of an expense, most recent change
+// first. Its owner and the members of its group can read it, also while the
+// expense is in the trash.
+func (s *service) GetExpenseHistory(id, userID uint) ([]audit.Entry, error) {
+	expense, err := s.repo.GetWithTrashed(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(expense, userID); err != nil {
+		return nil, err
+	}
+	return s.auditRepo.GetHistory(audit.ResourceExpense, id)
+}
+
+// RevertExpense puts an expense back to the version recorded by one of its
+// history entries and returns it. The revert is an update like any other:
+// balances move with the amount and account, and it is recorded in the log.
+func (s *service) RevertExpense(ctx context.Context, id, entryID, userID uint) (*Expense, error) {
+	current, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(current, userID, group.EditorRoles...); err != nil {
+		return nil, err
+	}
+	entry, err := s.auditRepo.GetEntry(audit.ResourceExpense, id, entryID)
+	if err != nil {
+		return nil, err
+	}
+	var version Expense
+	if err := entry.Version(&version); err != nil {
+		return nil, err
+	}
+
+	// Only what the API lets users edit goes back; ownership and the
+	// recurring and import links stay as they are.
+	reverted := *current
+	reverted.Title = version.Title
+	reverted.Description = version.Description
+	reverted.Amount = version.Amount
+	reverted.Currency = version.Currency
+	reverted.Date = version.Date
+	reverted.CategoryID = version.CategoryID
+	reverted.AccountID = version.AccountID
+	reverted.GroupID = version.GroupID
+	reverted.FileURL = version.FileURL
+	reverted.Items = version.Items
+	reverted.Tags = make([]tag.Tag, len(version.Tags))
+	for i, t := range version.Tags {
+		reverted.Tags[i] = tag.Tag{Name: t.Name}
+	}
+
+	if err := s.update(ctx, &reverted, userID, &entryID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id, userID)
+}
+
+// checkAccess verifies that the user owns the expense or, with one of the
+// given roles if any, belongs to its group. Anyone else gets
+// ErrExpenseNotFound, as does a missing expense.
+func (s *service) checkAccess(expense *Expense, userID uint, roles ...string) error {
+	if expense == nil {
+		return ErrExpenseNotFound
+	}
+	if expense.UserID == userID {
+		return nil
+	}
+	if expense.GroupID == nil {
+		return ErrExpenseNotFound
+	}
+	err := s.groups.RequireRole(*expense.GroupID, userID, roles...)
+	if errors.Is(err, group.ErrGroupNotFound) {
+		return ErrExpenseNotFound
+	}
+	return err
+}
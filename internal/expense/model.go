@@ -2,25 +2,106 @@ package expense
 
 import (
 	"time"
+	"trackonomy/internal/account"
 	"trackonomy/internal/category"
+	"trackonomy/internal/money"
+	"trackonomy/internal/tag"
 	"trackonomy/internal/user"
+
+	"gorm.io/gorm"
 )
 
 type Expense struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Amount      float64   `json:"amount"`
-	Date        time.Time `json:"date"`
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+	Date        time.Time    `json:"date" gorm:"index:idx_expense_user_date,priority:2"`
+
+	// Currency is the ISO 4217 code Amount was paid in. AccountAmount is what
+	// was debited from the account in the account's currency, and BaseAmount
+	// is Amount in the owner's base currency, which reports add up. Both are
+	// converted at the rates of Date.
+	Currency      string       `json:"currency" gorm:"size:3"`
+	AccountAmount money.Amount `json:"account_amount"`
+	BaseAmount    money.Amount `json:"base_amount"`
+	BaseCurrency  string       `json:"base_currency" gorm:"size:3"`
 
-	UserID uint      `json:"user_id"`
+	UserID uint      `json:"user_id" gorm:"index:idx_expense_user_date,priority:1"`
 	User   user.User `json:"-" gorm:"foreignKey:UserID"`
 
+	// GroupID shares the expense with the members of a group.
+	GroupID *uint `json:"group_id,omitempty" gorm:"index"`
+
 	CategoryID uint               `json:"category_id"`
 	Category   *category.Category `json:"-" gorm:"foreignKey:CategoryID"`
 
+	// Items split the expense across categories. When present their amounts add
+	// up to Amount, and CategoryID defaults to the category of the first item.
+	Items []ExpenseItem `json:"items,omitempty" gorm:"foreignKey:ExpenseID;constraint:OnDelete:CASCADE"`
+
+	AccountID uint             `json:"account_id"`
+	Account   *account.Account `json:"-" gorm:"foreignKey:AccountID"`
+
+	// Tags label the expense independently of its categories.
+	Tags []tag.Tag `json:"tags,omitempty" gorm:"many2many:expense_tags"`
+
 	FileURL string `json:"file_url"`
 
+	// Fingerprint identifies imported statement rows so re-imports skip them.
+	Fingerprint string `json:"-" gorm:"index"`
+
+	// RecurringID and OccurrenceDate are set on expenses created from a recurring
+	// template. Their unique index guarantees an occurrence is only created once.
+	RecurringID    *uint      `json:"recurring_id,omitempty" gorm:"uniqueIndex:idx_expense_recurring_occurrence"`
+	OccurrenceDate *time.Time `json:"occurrence_date,omitempty" gorm:"type:date;uniqueIndex:idx_expense_recurring_occurrence"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // set while the expense is in the trash
+}
+
+// ExpenseItem is the part of a split expense that belongs to one category.
+type ExpenseItem struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	ExpenseID uint         `json:"expense_id" gorm:"index"`
+	Amount    money.Amount `json:"amount"`
+	Note      string       `json:"note,omitempty"`
+
+	CategoryID uint               `json:"category_id" gorm:"index"`
+	Category   *category.Category `json:"-" gorm:"foreignKey:CategoryID"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// ExportRow is an expense flattened for export, with category and account names resolved.
+type ExportRow struct {
+	ID           uint         `json:"id"`
+	Date         time.Time    `json:"date"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	Amount       money.Amount `json:"amount"`
+	Currency     string       `json:"currency"`
+	CategoryID   uint         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	AccountID    uint         `json:"account_id"`
+	AccountName  string       `json:"account_name"`
+	FileURL      string       `json:"file_url"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// SortFields maps the sort names accepted by the list and export endpoints to columns.
+var SortFields = map[string]string{
+	"id":          "expenses.id",
+	"title":       "expenses.title",
+	"amount":      "expenses.amount",
+	"date":        "expenses.date",
+	"category_id": "expenses.category_id",
+	"account_id":  "expenses.account_id",
+	"created_at":  "expenses.created_at",
+	"updated_at":  "expenses.updated_at",
+}
+
+// DefaultSort is used when the request does not send ?sort=.
+const DefaultSort = "created_at:desc"
@@ -2,20 +2,48 @@ package expense
 
 import (
 	"errors"
+	"time"
+	"trackonomy/internal/category"
+	"trackonomy/internal/group"
+	"trackonomy/internal/money"
+	"trackonomy/internal/tag"
 	"trackonomy/internal/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository defines the methods that any data storage provider needs to implement to get and store expenses.
 type Repository interface {
 	Create(expense *Expense) error
-	GetAll() ([]Expense, error)
-	GetByID(id uint) (*Expense, error)
+	GetByID(id, userID uint) (*Expense, error)
 	Update(expense *Expense) error
-	Delete(id uint) error
+	ReplaceItems(expenseID uint, items []ExpenseItem) error
+	ReplaceTags(expenseID uint, tags []tag.Tag) error
+	AddTags(expenseID uint, tags []tag.Tag) error
+	Delete(id, userID uint) error
+	GetWithTrashed(id uint) (*Expense, error)
+	GetDeleted(userID uint) ([]Expense, error)
+	GetDeletedForUpdate(id, userID uint) (*Expense, error)
+	Restore(id uint) error
+	Purge(before time.Time) (int64, error)
 	GetByUserID(userID uint) ([]Expense, error)
-	GetAllByUserPaginated(userID uint, p utils.Pagination) ([]Expense, int64, error)
+	GetAllByUserPaginated(userID uint, f Filter, p utils.Pagination) ([]Expense, utils.Page, error)
+	EachByUser(userID uint, fn func(batch []Expense) error) error
+	StreamByUser(userID uint, f Filter, p utils.Pagination, fn func(row ExportRow) error) error
+	GetByIDForUpdate(id, userID uint) (*Expense, error)
+	ExistsOccurrence(recurringID uint, date time.Time) (bool, error)
+	IsShared(id uint) (bool, error)
+	ExistingFingerprints(userID, accountID uint, fingerprints []string) (map[string]bool, error)
+	SumByPeriod(userID uint, categoryID *uint, unit string, from, to time.Time) ([]PeriodTotal, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+// PeriodTotal is the sum of expense amounts within one period.
+type PeriodTotal struct {
+	Period time.Time    `json:"period"`
+	Total  money.Amount `json:"total"`
 }
 
 type repository struct {
@@ -35,20 +63,13 @@ func (r *repository) Create(expense *Expense) error {
 	return r.db.Create(expense).Error
 }
 
-// GetAll retrieves all expenses from the database.
-func (r *repository) GetAll() ([]Expense, error) {
-	var expenses []Expense
-	err := r.db.Find(&expenses).Error
-	if err != nil {
-		return nil, err
-	}
-	return expenses, nil
-}
-
-// GetByID retrieves an expense by its ID from the database.
-func (r *repository) GetByID(id uint) (*Expense, error) {
+// GetByID retrieves an expense the user owns or shares through a group, with
+// its split items and tags. It returns nil when there is no such expense.
+func (r *repository) GetByID(id, userID uint) (*Expense, error) {
 	var expense Expense
-	err := r.db.First(&expense, id).Error
+	err := r.db.Preload("Items").Preload("Tags").
+		Where("id = ? AND (user_id = ? OR group_id IN (?))", id, userID, group.IDsOf(r.db, userID)).
+		First(&expense).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -58,20 +79,130 @@ func (r *repository) GetByID(id uint) (*Expense, error) {
 	return &expense, nil
 }
 
-// Update modifies an existing expense in the database.
+// Update modifies an existing expense in the database. Split items and tags
+// are written separately with ReplaceItems and ReplaceTags.
 func (r *repository) Update(expense *Expense) error {
 	if expense == nil {
 		return errors.New("expense is nil")
 	}
-	return r.db.Save(expense).Error
+	return r.db.Omit("Items", "Tags").Save(expense).Error
+}
+
+// ReplaceItems deletes the split items of an expense and stores items instead.
+// The new IDs are written back into items.
+func (r *repository) ReplaceItems(expenseID uint, items []ExpenseItem) error {
+	if err := r.db.Where("expense_id = ?", expenseID).Delete(&ExpenseItem{}).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	for i := range items {
+		items[i].ID = 0
+		items[i].ExpenseID = expenseID
+	}
+	return r.db.Create(&items).Error
+}
+
+// ReplaceTags links the expense to exactly the given stored tags.
+func (r *repository) ReplaceTags(expenseID uint, tags []tag.Tag) error {
+	association := r.db.Model(&Expense{ID: expenseID}).Association("Tags")
+	if len(tags) == 0 {
+		return association.Clear()
+	}
+	return association.Replace(tags)
+}
+
+// AddTags links the expense to the given stored tags as well.
+func (r *repository) AddTags(expenseID uint, tags []tag.Tag) error {
+	return r.db.Model(&Expense{ID: expenseID}).Association("Tags").Append(tags)
 }
 
-// Delete removes an expense by its ID from the database.
-func (r *repository) Delete(id uint) error {
+// Delete moves an expense the user owns or may edit through a group to the
+// trash. Its split items and tags are kept so that restoring it brings them
+// back. It returns ErrExpenseNotFound otherwise.
+func (r *repository) Delete(id, userID uint) error {
 	if id == 0 {
 		return errors.New("invalid ID")
 	}
-	return r.db.Delete(&Expense{}, id).Error
+	result := r.db.Where("id = ? AND (user_id = ? OR group_id IN (?))",
+		id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		Delete(&Expense{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrExpenseNotFound
+	}
+	return nil
+}
+
+// GetWithTrashed retrieves an expense by its ID whether or not it is in the trash.
+func (r *repository) GetWithTrashed(id uint) (*Expense, error) {
+	var expense Expense
+	err := r.db.Unscoped().First(&expense, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// GetDeleted returns the trashed expenses the user owns or may edit through a
+// group, most recently deleted first.
+func (r *repository) GetDeleted(userID uint) ([]Expense, error) {
+	var expenses []Expense
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND (user_id = ? OR group_id IN (?))",
+			userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		Order("deleted_at DESC").
+		Find(&expenses).Error
+	if err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// GetDeletedForUpdate retrieves a trashed expense the user owns or may edit
+// through a group and locks the row until the surrounding transaction finishes.
+func (r *repository) GetDeletedForUpdate(id, userID uint) (*Expense, error) {
+	var expense Expense
+	err := r.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NOT NULL AND (user_id = ? OR group_id IN (?))",
+			id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		First(&expense).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// Restore takes an expense out of the trash.
+func (r *repository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&Expense{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// Purge permanently removes the expenses trashed before the given time, with
+// their split items, tag links and shares, and returns how many were removed.
+func (r *repository) Purge(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"expense_tags", "expense_items", "shares"} {
+			err := tx.Exec("DELETE FROM "+table+" WHERE expense_id IN (SELECT id FROM expenses WHERE deleted_at < ?)", before).Error
+			if err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", before).Delete(&Expense{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
 }
 
 func (r *repository) GetByUserID(userID uint) ([]Expense, error) {
@@ -83,39 +214,250 @@ func (r *repository) GetByUserID(userID uint) ([]Expense, error) {
 	return expenses, nil
 }
 
-func (r *repository) GetAllByUserPaginated(userID uint, p utils.Pagination) ([]Expense, int64, error) {
-	var (
-		expenses     []Expense
-		totalRecords int64
-	)
+// GetAllByUserPaginated returns one page of the user's expenses, using the
+// cursor in p when there is one and the page offset otherwise.
+func (r *repository) GetAllByUserPaginated(userID uint, f Filter, p utils.Pagination) ([]Expense, utils.Page, error) {
+	expenses, page, err := utils.FindPage[Expense](r.filtered(userID, f, p), p, "expenses.id")
+	if err != nil {
+		return nil, page, err
+	}
+	if err := r.loadItems(expenses); err != nil {
+		return nil, page, err
+	}
+	if err := r.loadTags(expenses); err != nil {
+		return nil, page, err
+	}
+	return expenses, page, nil
+}
+
+// EachByUser calls fn with every expense the user owns, with items and tags,
+// a batch at a time.
+func (r *repository) EachByUser(userID uint, fn func(batch []Expense) error) error {
+	var batch []Expense
+	return r.db.Where("user_id = ?", userID).Order("id").
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			if err := r.loadItems(batch); err != nil {
+				return err
+			}
+			if err := r.loadTags(batch); err != nil {
+				return err
+			}
+			return fn(batch)
+		}).Error
+}
+
+// loadItems fills in the split items of a page of expenses with one query.
+func (r *repository) loadItems(expenses []Expense) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(expenses))
+	for i, e := range expenses {
+		ids[i] = e.ID
+	}
+
+	var items []ExpenseItem
+	if err := r.db.Where("expense_id IN ?", ids).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
+	byExpense := make(map[uint][]ExpenseItem)
+	for _, item := range items {
+		byExpense[item.ExpenseID] = append(byExpense[item.ExpenseID], item)
+	}
+	for i := range expenses {
+		expenses[i].Items = byExpense[expenses[i].ID]
+	}
+	return nil
+}
+
+// loadTags fills in the tags of a page of expenses with one query.
+func (r *repository) loadTags(expenses []Expense) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(expenses))
+	for i, e := range expenses {
+		ids[i] = e.ID
+	}
+
+	var links []struct {
+		ExpenseID uint
+		tag.Tag
+	}
+	err := r.db.Table("expense_tags").
+		Select("expense_tags.expense_id, tags.*").
+		Joins("JOIN tags ON tags.id = expense_tags.tag_id").
+		Where("expense_tags.expense_id IN ?", ids).
+		Order("tags.name").
+		Scan(&links).Error
+	if err != nil {
+		return err
+	}
+	byExpense := make(map[uint][]tag.Tag)
+	for _, link := range links {
+		byExpense[link.ExpenseID] = append(byExpense[link.ExpenseID], link.Tag)
+	}
+	for i := range expenses {
+		expenses[i].Tags = byExpense[expenses[i].ID]
+	}
+	return nil
+}
+
+// StreamByUser calls fn for every expense matching the same filters and sort as
+// GetAllByUserPaginated, without pagination. Rows are read one at a time from
+// the database cursor, and category and account names are looked up alongside.
+func (r *repository) StreamByUser(userID uint, f Filter, p utils.Pagination, fn func(row ExportRow) error) error {
+	query := r.filtered(userID, f, p).
+		Select("expenses.id, expenses.date, expenses.title, expenses.description, expenses.amount, expenses.currency, " +
+			"expenses.category_id, expenses.account_id, expenses.file_url, expenses.created_at, " +
+			"COALESCE((SELECT name FROM categories WHERE categories.id = expenses.category_id), '') AS category_name, " +
+			"COALESCE((SELECT name FROM accounts WHERE accounts.id = expenses.account_id), '') AS account_name")
+	query = p.Order.Apply(query, "expenses.id")
 
-	// Start building the query
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ExportRow
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filtered builds the query shared by listing and exporting a user's expenses.
+func (r *repository) filtered(userID uint, f Filter, p utils.Pagination) *gorm.DB {
 	query := r.db.Model(&Expense{}).
-		Where("user_id = ?", userID)
+		Where("(expenses.user_id = ? OR expenses.group_id IN (?))", userID, group.IDsOf(r.db, userID))
 
 	// Optional: text searching on Title or Description if you like
 	if p.Search != "" {
 		// Example: searching for matching substring in Title or Description
 		searchTerm := "%" + p.Search + "%"
-		query = query.Where("title ILIKE ? OR description ILIKE ?", searchTerm, searchTerm)
+		query = query.Where("expenses.title ILIKE ? OR expenses.description ILIKE ?", searchTerm, searchTerm)
+	}
+	return f.apply(query)
+}
+
+// GetByIDForUpdate retrieves an expense the user owns or may edit through a
+// group and locks the row until the surrounding transaction finishes, so
+// concurrent edits cannot apply the same balance change twice. It returns nil
+// when there is no such expense.
+func (r *repository) GetByIDForUpdate(id, userID uint) (*Expense, error) {
+	var expense Expense
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND (user_id = ? OR group_id IN (?))",
+			id, userID, group.IDsOf(r.db, userID, group.EditorRoles...)).
+		First(&expense).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// ExistsOccurrence reports whether the given occurrence of a recurring template
+// has already been turned into an expense. Trashed expenses count, so deleting
+// an occurrence does not bring it back on the next run.
+func (r *repository) ExistsOccurrence(recurringID uint, date time.Time) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&Expense{}).
+		Where("recurring_id = ? AND occurrence_date = ?", recurringID, date.Format(utils.DateLayout)).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsShared reports whether the expense is split with other users. Shares are
+// named by table as the sharing package imports this one.
+func (r *repository) IsShared(id uint) (bool, error) {
+	var count int64
+	if err := r.db.Table("shares").Where("expense_id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SumByPeriod totals the user's expenses in [from, to), grouped by the start of
+// each period. unit is a PostgreSQL date_trunc unit such as "week", "month" or
+// "year". When categoryID is nil every category is included; otherwise only
+// that category and its sub-categories count, and split expenses only count
+// their items in them. Totals are in the user's base currency.
+func (r *repository) SumByPeriod(userID uint, categoryID *uint, unit string, from, to time.Time) ([]PeriodTotal, error) {
+	var totals []PeriodTotal
+
+	query := r.db.Model(&Expense{}).
+		Select("date_trunc(?, date AT TIME ZONE 'UTC') AS period, SUM(base_amount) AS total", unit).
+		Where("user_id = ? AND date >= ? AND date < ?", userID, from, to)
+	if categoryID != nil {
+		query = r.db.Table("(?) AS e", CategorySplits(r.db)).
+			Select("date_trunc(?, date AT TIME ZONE 'UTC') AS period, SUM(amount) AS total", unit).
+			Where("user_id = ? AND date >= ? AND date < ? AND category_id IN (?)",
+				userID, from, to, category.SubtreeIDs(r.db, *categoryID))
 	}
 
-	// Count total records (before pagination)
-	if err := query.Count(&totalRecords).Error; err != nil {
-		return nil, 0, err
+	err := query.Group("period").Order("period").Scan(&totals).Error
+	if err != nil {
+		return nil, err
 	}
+	return totals, nil
+}
 
-	// Apply sorting
-	// p.Sort might look like "title asc", "amount desc", "created_at desc", etc.
-	if p.Sort != "" {
-		query = query.Order(p.Sort)
+// ExistingFingerprints returns which of the given import fingerprints the user
+// already has expenses for on the account.
+func (r *repository) ExistingFingerprints(userID, accountID uint, fingerprints []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(fingerprints) == 0 {
+		return existing, nil
 	}
 
-	// Apply pagination (page, limit)
-	offset := (p.Page - 1) * p.Limit
-	if err := query.Offset(offset).Limit(p.Limit).Find(&expenses).Error; err != nil {
-		return nil, 0, err
+	var found []string
+	err := r.db.Model(&Expense{}).
+		Where("user_id = ? AND account_id = ? AND fingerprint IN ?", userID, accountID, fingerprints).
+		Pluck("fingerprint", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range found {
+		existing[f] = true
 	}
+	return existing, nil
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{tx}
+}
 
-	return expenses, totalRecords, nil
+// CategorySplits returns a subquery with one row per category share of an
+// expense: the items of split expenses, and the whole amount of the others.
+// It has the columns user_id, date, category_id, expense_id and amount, and
+// leaves out trashed expenses. Amounts are in the owner's base currency; items
+// get their share of the converted expense amount.
+func CategorySplits(db *gorm.DB) *gorm.DB {
+	return db.Raw(`
+		SELECT e.user_id, e.date, i.category_id, e.id AS expense_id,
+			CASE WHEN e.amount = 0 THEN 0 ELSE i.amount * e.base_amount / e.amount END AS amount
+		FROM expense_items i
+		JOIN expenses e ON e.id = i.expense_id AND e.deleted_at IS NULL
+		UNION ALL
+		SELECT e.user_id, e.date, e.category_id, e.id AS expense_id, e.base_amount AS amount
+		FROM expenses e
+		WHERE e.deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM expense_items i WHERE i.expense_id = e.id)`)
 }
@@ -0,0 +1,191 @@
+package expense
+
+import (
+	"context"
+	"time"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/money"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/tag"
+
+	"gorm.io/gorm"
+)
+
+// RuleChange describes how applying a rule changes one expense. The New fields
+// are only set when the value changes.
+type RuleChange struct {
+	ExpenseID     uint         `json:"expense_id"`
+	Title         string       `json:"title"`
+	Amount        money.Amount `json:"amount"`
+	Date          time.Time    `json:"date"`
+	CategoryID    uint         `json:"category_id"`
+	NewCategoryID *uint        `json:"new_category_id,omitempty"`
+	AccountID     uint         `json:"account_id"`
+	NewAccountID  *uint        `json:"new_account_id,omitempty"`
+	AddTags       []string     `json:"add_tags,omitempty"`
+}
+
+// applyRules fills in what the owner's rules decide for a new expense. Values
+// the expense already has win over the rules; rule tags are added to its own.
+func (s *service) applyRules(expense *Expense) error {
+	rules, err := s.ruleRepo.GetEnabled(expense.UserID)
+	if err != nil {
+		return err
+	}
+	out := rule.Evaluate(rules, candidate(expense))
+	if expense.CategoryID == 0 && out.CategoryID != nil {
+		expense.CategoryID = *out.CategoryID
+	}
+	if expense.AccountID == 0 && out.AccountID != nil {
+		expense.AccountID = *out.AccountID
+	}
+	for _, name := range out.Tags {
+		expense.Tags = append(expense.Tags, tag.Tag{Name: name})
+	}
+	return nil
+}
+
+// PreviewRule lists the changes applying the rule to the user's existing
+// expenses would make, without making them.
+func (s *service) PreviewRule(ruleID, userID uint) ([]RuleChange, error) {
+	r, err := s.ruleRepo.GetByID(ruleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, rule.ErrRuleNotFound
+	}
+
+	changes := []RuleChange{}
+	err = s.repo.EachByUser(userID, func(batch []Expense) error {
+		for i := range batch {
+			if change, ok := ruleChange(r, &batch[i]); ok {
+				changes = append(changes, change)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ApplyRule makes the changes PreviewRule lists in one transaction. Moving an
+// expense to another account moves its amount between the account balances.
+// The rule's category is checked like one set by hand, since it may have been
+// deleted or unshared since the rule was saved. Every changed expense gets an
+// entry in the audit log.
+func (s *service) ApplyRule(ctx context.Context, ruleID, userID uint) ([]RuleChange, error) {
+	changes, err := s.PreviewRule(ruleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		accounts := s.accountRepo.WithTx(tx)
+		log := s.auditRepo.WithTx(tx)
+
+		for _, change := range changes {
+			expense, err := repo.GetByIDForUpdate(change.ExpenseID, userID)
+			if err != nil {
+				return err
+			}
+			if expense == nil {
+				continue
+			}
+			before, err := repo.GetByID(expense.ID, userID)
+			if err != nil {
+				return err
+			}
+
+			if change.NewAccountID != nil {
+				if err := refund(accounts, expense); err != nil {
+					return err
+				}
+				// The new account may be kept in another currency.
+				expense.AccountID = *change.NewAccountID
+				if err := s.convert(expense); err != nil {
+					return err
+				}
+				if err := accounts.AdjustBalance(expense.AccountID, expense.UserID, -expense.AccountAmount); err != nil {
+					return err
+				}
+			}
+			if change.NewCategoryID != nil {
+				expense.CategoryID = *change.NewCategoryID
+				if err := s.checkCategories(expense, userID, before); err != nil {
+					return err
+				}
+			}
+			if err := repo.Update(expense); err != nil {
+				return err
+			}
+
+			if len(change.AddTags) > 0 {
+				tags, err := s.tagRepo.WithTx(tx).FindOrCreate(expense.UserID, change.AddTags)
+				if err != nil {
+					return err
+				}
+				if err := repo.AddTags(expense.ID, tags); err != nil {
+					return err
+				}
+			}
+
+			after, err := repo.GetByID(expense.ID, userID)
+			if err != nil {
+				return err
+			}
+			if err := audit.Record(ctx, log, audit.ResourceExpense, expense.ID, audit.ActionUpdate, before, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ruleChange works out what the rule would change on the expense, and reports
+// whether it changes anything. The category of split expenses comes from their
+// items and is left alone.
+func ruleChange(r *rule.Rule, expense *Expense) (RuleChange, bool) {
+	change := RuleChange{
+		ExpenseID:  expense.ID,
+		Title:      expense.Title,
+		Amount:     expense.Amount,
+		Date:       expense.Date,
+		CategoryID: expense.CategoryID,
+		AccountID:  expense.AccountID,
+	}
+	if !r.Matches(candidate(expense)) {
+		return change, false
+	}
+
+	if r.CategoryID != nil && *r.CategoryID != expense.CategoryID && len(expense.Items) == 0 {
+		change.NewCategoryID = r.CategoryID
+	}
+	if r.AccountID != nil && *r.AccountID != expense.AccountID {
+		change.NewAccountID = r.AccountID
+	}
+	has := make(map[string]bool, len(expense.Tags))
+	for _, t := range expense.Tags {
+		has[t.Name] = true
+	}
+	for _, name := range r.Tags {
+		if !has[name] {
+			change.AddTags = append(change.AddTags, name)
+		}
+	}
+	return change, change.NewCategoryID != nil || change.NewAccountID != nil || len(change.AddTags) > 0
+}
+
+func candidate(expense *Expense) rule.Candidate {
+	return rule.Candidate{
+		Title:       expense.Title,
+		Description: expense.Description,
+		Amount:      expense.Amount,
+	}
+}
@@ -1,73 +1,427 @@
 package expense
 
 import (
+	"context"
 	"errors"
+	"io"
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/group"
+	"trackonomy/internal/money"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/tag"
 	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrExpenseNotFound is returned when the expense to modify does not exist.
+	ErrExpenseNotFound = errors.New("expense not found")
+	// ErrItemsTotalMismatch is returned when split items do not add up to the expense amount.
+	ErrItemsTotalMismatch = errors.New("item amounts must add up to the expense amount")
+	// ErrCategoryRequired is returned when neither the request, the items nor a rule set a category.
+	ErrCategoryRequired = errors.New("category_id is required when no item or rule sets it")
+	// ErrAccountRequired is returned when neither the request nor a rule set an account.
+	ErrAccountRequired = errors.New("account_id is required when no rule sets it")
+	// ErrShared is returned when changing the amount or currency of an expense split with other users.
+	ErrShared = errors.New("expense is split with other users; remove the split before changing its amount or currency")
 )
 
 type Service interface {
-	CreateExpense(expense *Expense) error
-	GetAllExpenses() ([]Expense, error)
-	GetExpenseByID(id uint) (*Expense, error)
-	UpdateExpense(expense *Expense) error
-	DeleteExpense(id uint) error
+	CreateExpense(ctx context.Context, expense *Expense) error
+	GetExpenseByID(id, userID uint) (*Expense, error)
+	UpdateExpense(ctx context.Context, expense *Expense, userID uint) error
+	DeleteExpense(ctx context.Context, id, userID uint) error
+	GetDeletedExpenses(userID uint) ([]Expense, error)
+	RestoreExpense(ctx context.Context, id, userID uint) (*Expense, error)
+	PurgeExpenses(before time.Time) (int64, error)
 	GetExpensesByUser(userID uint) ([]Expense, error)
-	GetExpensesByUserPaginated(userID uint, pagination utils.Pagination) ([]Expense, int64, error)
+	GetExpensesByUserPaginated(userID uint, filter Filter, pagination utils.Pagination) ([]Expense, utils.Page, error)
+	ExportExpenses(userID uint, filter Filter, pagination utils.Pagination, format string, w io.Writer) error
+	PreviewRule(ruleID, userID uint) ([]RuleChange, error)
+	ApplyRule(ctx context.Context, ruleID, userID uint) ([]RuleChange, error)
+	GetExpenseHistory(id, userID uint) ([]audit.Entry, error)
+	RevertExpense(ctx context.Context, id, entryID, userID uint) (*Expense, error)
 }
 
 type service struct {
-	repo Repository
+	repo         Repository
+	accountRepo  account.Repository
+	categoryRepo category.Repository
+	tagRepo      tag.Repository
+	ruleRepo     rule.Repository
+	groups       group.Service
+	auditRepo    audit.Repository
+	currency     currency.Service
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(
+	repo Repository,
+	accountRepo account.Repository,
+	categoryRepo category.Repository,
+	tagRepo tag.Repository,
+	ruleRepo rule.Repository,
+	groups group.Service,
+	auditRepo audit.Repository,
+	currencies currency.Service,
+) Service {
+	return &service{
+		repo:         repo,
+		accountRepo:  accountRepo,
+		categoryRepo: categoryRepo,
+		tagRepo:      tagRepo,
+		ruleRepo:     ruleRepo,
+		groups:       groups,
+		auditRepo:    auditRepo,
+		currency:     currencies,
+	}
 }
 
-func (s *service) CreateExpense(expense *Expense) error {
+// CreateExpense stores the expense and debits its account in the same
+// transaction. The user's rules fill in a missing category or account and add
+// their tags. Tags are matched by name, and created when the user has none
+// with that name yet. Amounts in another currency than the account's are
+// converted at the rate of the expense date. The change is recorded in the
+// audit log as made by the actor carried by ctx.
+func (s *service) CreateExpense(ctx context.Context, expense *Expense) error {
 	if expense == nil {
 		return errors.New("expense cannot be nil")
 	}
-	return s.repo.Create(expense)
-}
-
-func (s *service) GetAllExpenses() ([]Expense, error) {
-	expenses, err := s.repo.GetAll()
-	if err != nil {
-		return nil, err
+	if err := checkItems(expense); err != nil {
+		return err
+	}
+	if err := s.applyRules(expense); err != nil {
+		return err
 	}
-	return expenses, nil
+	if err := checkRequired(expense); err != nil {
+		return err
+	}
+	if err := s.checkCategories(expense, expense.UserID, nil); err != nil {
+		return err
+	}
+	if err := s.checkGroup(expense); err != nil {
+		return err
+	}
+	if err := s.convert(expense); err != nil {
+		return err
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.accountRepo.WithTx(tx).AdjustBalance(expense.AccountID, expense.UserID, -expense.AccountAmount); err != nil {
+			return err
+		}
+		if err := s.resolveTags(tx, expense); err != nil {
+			return err
+		}
+		if err := s.repo.WithTx(tx).Create(expense); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceExpense, expense.ID, audit.ActionCreate, nil, expense)
+	})
 }
 
-func (s *service) GetExpenseByID(id uint) (*Expense, error) {
+// GetExpenseByID returns an expense the user owns or shares through a group,
+// or nil when there is no such expense.
+func (s *service) GetExpenseByID(id, userID uint) (*Expense, error) {
 	if id == 0 {
 		return nil, errors.New("invalid ID")
 	}
-	expense, err := s.repo.GetByID(id)
+	expense, err := s.repo.GetByID(id, userID)
 	if err != nil {
 		return nil, err
 	}
 	return expense, nil
 }
 
-func (s *service) UpdateExpense(expense *Expense) error {
+// UpdateExpense saves the expense and moves its amount between account balances:
+// the previously stored amount is credited back to the old account and the new
+// amount is debited from the (possibly different) new account, converted to its
+// currency again. userID is the caller, who must own the expense or be an
+// editor of its group; anyone else gets ErrExpenseNotFound. The amount and
+// currency of an expense split with other users cannot change, as the shares
+// would no longer add up to it; that returns ErrShared.
+func (s *service) UpdateExpense(ctx context.Context, expense *Expense, userID uint) error {
+	return s.update(ctx, expense, userID, nil)
+}
+
+// update saves the expense as UpdateExpense does. It records the change as a
+// revert to the version of the entry revertOf when that is set.
+func (s *service) update(ctx context.Context, expense *Expense, userID uint, revertOf *uint) error {
 	if expense == nil || expense.ID == 0 {
 		return errors.New("invalid expense")
 	}
-	return s.repo.Update(expense)
+	if err := checkItems(expense); err != nil {
+		return err
+	}
+	if err := checkRequired(expense); err != nil {
+		return err
+	}
+	if err := s.checkGroup(expense); err != nil {
+		return err
+	}
+	if err := s.convert(expense); err != nil {
+		return err
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		accounts := s.accountRepo.WithTx(tx)
+
+		previous, err := repo.GetByIDForUpdate(expense.ID, userID)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			return ErrExpenseNotFound
+		}
+		// The row is locked now; read it again with its items and tags for the log.
+		before, err := repo.GetByID(expense.ID, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkCategories(expense, userID, before); err != nil {
+			return err
+		}
+		if expense.Amount != previous.Amount || expense.Currency != previous.Currency {
+			shared, err := repo.IsShared(expense.ID)
+			if err != nil {
+				return err
+			}
+			if shared {
+				return ErrShared
+			}
+		}
+
+		if err := refund(accounts, previous); err != nil {
+			return err
+		}
+		if err := accounts.AdjustBalance(expense.AccountID, expense.UserID, -expense.AccountAmount); err != nil {
+			return err
+		}
+		if err := repo.Update(expense); err != nil {
+			return err
+		}
+		if err := repo.ReplaceItems(expense.ID, expense.Items); err != nil {
+			return err
+		}
+		if err := s.resolveTags(tx, expense); err != nil {
+			return err
+		}
+		if err := repo.ReplaceTags(expense.ID, expense.Tags); err != nil {
+			return err
+		}
+
+		log := s.auditRepo.WithTx(tx)
+		if revertOf != nil {
+			return audit.RecordRevert(ctx, log, audit.ResourceExpense, expense.ID, *revertOf, before, expense)
+		}
+		return audit.Record(ctx, log, audit.ResourceExpense, expense.ID, audit.ActionUpdate, before, expense)
+	})
 }
 
-func (s *service) DeleteExpense(id uint) error {
+// DeleteExpense moves the expense to the trash and credits its amount back to
+// the account. Its split items and tags stay with it until it is purged. Only
+// the owner and the editors of its group may delete it; anyone else gets
+// ErrExpenseNotFound.
+func (s *service) DeleteExpense(ctx context.Context, id, userID uint) error {
 	if id == 0 {
 		return errors.New("invalid ID")
 	}
-	return s.repo.Delete(id)
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		existing, err := repo.GetByIDForUpdate(id, userID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrExpenseNotFound
+		}
+		before, err := repo.GetByID(id, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := refund(s.accountRepo.WithTx(tx), existing); err != nil {
+			return err
+		}
+		if err := repo.Delete(id, userID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceExpense, id, audit.ActionDelete, before, nil)
+	})
+}
+
+// GetDeletedExpenses lists the trashed expenses the user may restore.
+func (s *service) GetDeletedExpenses(userID uint) ([]Expense, error) {
+	return s.repo.GetDeleted(userID)
+}
+
+// RestoreExpense takes an expense out of the trash and debits its amount from
+// the account again, in one transaction, and returns it.
+func (s *service) RestoreExpense(ctx context.Context, id, userID uint) (*Expense, error) {
+	var restored *Expense
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		existing, err := repo.GetDeletedForUpdate(id, userID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrExpenseNotFound
+		}
+		if existing.AccountID != 0 {
+			// Trashed accounts keep tracking their balance, like refund does.
+			err := s.accountRepo.WithTx(tx).Unscoped().AdjustBalance(existing.AccountID, existing.UserID, -existing.AccountAmount)
+			if err != nil {
+				return err
+			}
+		}
+		if err := repo.Restore(id); err != nil {
+			return err
+		}
+		restored, err = repo.GetByID(id, userID)
+		if err != nil {
+			return err
+		}
+		trashed := *restored
+		trashed.DeletedAt = existing.DeletedAt
+		return audit.Record(ctx, s.auditRepo.WithTx(tx), audit.ResourceExpense, id, audit.ActionRestore, &trashed, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeExpenses permanently removes the expenses trashed before the given time.
+func (s *service) PurgeExpenses(before time.Time) (int64, error) {
+	return s.repo.Purge(before)
 }
 
 func (s *service) GetExpensesByUser(userID uint) ([]Expense, error) {
 	return s.repo.GetByUserID(userID)
 }
 
-func (s *service) GetExpensesByUserPaginated(userID uint, pagination utils.Pagination) ([]Expense, int64, error) {
+func (s *service) GetExpensesByUserPaginated(userID uint, filter Filter, pagination utils.Pagination) ([]Expense, utils.Page, error) {
 	// We call a new repository method that supports pagination
-	return s.repo.GetAllByUserPaginated(userID, pagination)
+	return s.repo.GetAllByUserPaginated(userID, filter, pagination)
+}
+
+// ExportExpenses streams every expense matching the listing filters to w in the
+// given format (csv, jsonl or xlsx).
+func (s *service) ExportExpenses(userID uint, filter Filter, pagination utils.Pagination, format string, w io.Writer) error {
+	writer, err := newRowWriter(format, w)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.StreamByUser(userID, filter, pagination, writer.Write); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// checkGroup verifies that the expense owner may share it with expense.GroupID.
+func (s *service) checkGroup(expense *Expense) error {
+	if expense.GroupID == nil {
+		return nil
+	}
+	return s.groups.RequireRole(*expense.GroupID, expense.UserID, group.EditorRoles...)
+}
+
+// checkCategories verifies that the category of the expense and those of its
+// items are global or visible to the user, who owns them or shares them
+// through a group, and hold expenses. Categories the stored version of the
+// expense already uses are accepted, so that group editors can change an
+// expense filed under the owner's own categories. It returns an error wrapping
+// category.ErrCategoryNotFound or category.ErrWrongKind otherwise.
+func (s *service) checkCategories(expense *Expense, userID uint, stored *Expense) error {
+	allowed := map[uint]bool{}
+	if stored != nil {
+		allowed[stored.CategoryID] = true
+		for _, item := range stored.Items {
+			allowed[item.CategoryID] = true
+		}
+	}
+
+	ids := []uint{expense.CategoryID}
+	for _, item := range expense.Items {
+		ids = append(ids, item.CategoryID)
+	}
+	for _, id := range ids {
+		if allowed[id] {
+			continue
+		}
+		if err := category.CheckUsable(s.categoryRepo, id, userID, category.KindExpense); err != nil {
+			return err
+		}
+		allowed[id] = true
+	}
+	return nil
+}
+
+// resolveTags replaces the named tags of the expense with the owner's stored
+// tags of those names, creating the missing ones.
+func (s *service) resolveTags(tx *gorm.DB, expense *Expense) error {
+	if len(expense.Tags) == 0 {
+		return nil
+	}
+	names := make([]string, len(expense.Tags))
+	for i, t := range expense.Tags {
+		names[i] = t.Name
+	}
+	tags, err := s.tagRepo.WithTx(tx).FindOrCreate(expense.UserID, tag.Normalized(names))
+	if err != nil {
+		return err
+	}
+	expense.Tags = tags
+	return nil
+}
+
+// checkItems verifies that split items add up to the expense amount exactly,
+// and defaults the expense category to the category of the first item.
+func checkItems(expense *Expense) error {
+	if len(expense.Items) == 0 {
+		return nil
+	}
+	var total money.Amount
+	for _, item := range expense.Items {
+		total += item.Amount
+	}
+	if total != expense.Amount {
+		return ErrItemsTotalMismatch
+	}
+	if expense.CategoryID == 0 {
+		expense.CategoryID = expense.Items[0].CategoryID
+	}
+	return nil
+}
+
+// checkRequired verifies that the expense ended up with a category and an account.
+func checkRequired(expense *Expense) error {
+	if expense.CategoryID == 0 {
+		return ErrCategoryRequired
+	}
+	if expense.AccountID == 0 {
+		return ErrAccountRequired
+	}
+	return nil
+}
+
+// refund credits the stored account amount of an expense back to its account. Expenses
+// recorded before accounts were linked, or whose account no longer exists, have
+// no balance to restore. Trashed accounts are credited too, so that restoring
+// one brings back the right balance.
+func refund(accounts account.Repository, expense *Expense) error {
+	if expense.AccountID == 0 {
+		return nil
+	}
+	err := accounts.Unscoped().AdjustBalance(expense.AccountID, expense.UserID, expense.AccountAmount)
+	if errors.Is(err, account.ErrAccountNotFound) {
+		return nil
+	}
+	return err
 }
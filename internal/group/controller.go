@@ -0,0 +1,332 @@
+package group
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type GroupController struct {
+	service Service
+}
+
+func NewGroupController(service Service) *GroupController {
+	return &GroupController{service: service}
+}
+
+// CreateGroup creates a group owned by the caller.
+func (ctrl *GroupController) CreateGroup(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.GroupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	group := &Group{Name: request.Name, OwnerID: userID}
+	if err := ctrl.service.CreateGroup(group); err != nil {
+		logger.Error("Failed to create group", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not create group", err.Error())
+		return
+	}
+	response.Created(c, "Group created successfully", group)
+}
+
+// GetAllGroups lists the groups the caller belongs to.
+func (ctrl *GroupController) GetAllGroups(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	groups, err := ctrl.service.GetGroups(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve groups", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve groups", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Groups retrieved successfully", groups)
+}
+
+// GetGroupByID retrieves a group the caller belongs to.
+func (ctrl *GroupController) GetGroupByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	group, err := ctrl.service.GetGroup(id, userID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve group")
+		return
+	}
+	response.Success(c, http.StatusOK, "Group retrieved successfully", group)
+}
+
+// UpdateGroup renames a group.
+func (ctrl *GroupController) UpdateGroup(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	var request dto.GroupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	group, err := ctrl.service.UpdateGroup(id, userID, request.Name)
+	if err != nil {
+		respondError(c, err, "Could not update group")
+		return
+	}
+	response.Updated(c, "Group updated successfully", group)
+}
+
+// DeleteGroup removes a group. Its shared resources go back to their creators.
+func (ctrl *GroupController) DeleteGroup(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteGroup(id, userID); err != nil {
+		respondError(c, err, "Could not delete group")
+		return
+	}
+	response.Deleted(c, "Group deleted successfully")
+}
+
+// GetMembers lists the members of a group.
+func (ctrl *GroupController) GetMembers(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	members, err := ctrl.service.GetMembers(id, userID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve members")
+		return
+	}
+	response.Success(c, http.StatusOK, "Members retrieved successfully", members)
+}
+
+// UpdateMemberRole changes the role of a member.
+func (ctrl *GroupController) UpdateMemberRole(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	memberID, ok := paramID(c, "userId", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var request dto.MemberRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	if err := ctrl.service.UpdateMemberRole(id, userID, memberID, request.Role); err != nil {
+		respondError(c, err, "Could not update member")
+		return
+	}
+	response.Updated(c, "Member updated successfully", gin.H{"user_id": memberID, "role": request.Role})
+}
+
+// RemoveMember removes a member from a group, or lets the caller leave it.
+func (ctrl *GroupController) RemoveMember(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	memberID, ok := paramID(c, "userId", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.RemoveMember(id, userID, memberID); err != nil {
+		respondError(c, err, "Could not remove member")
+		return
+	}
+	response.Deleted(c, "Member removed successfully")
+}
+
+// InviteMember invites someone to the group by email.
+func (ctrl *GroupController) InviteMember(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	var request dto.InvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	invitation, err := ctrl.service.Invite(id, userID, request.Email, request.Role)
+	if err != nil {
+		respondError(c, err, "Could not invite member")
+		return
+	}
+	response.Created(c, "Invitation sent successfully", invitation)
+}
+
+// GetGroupInvitations lists the invitations of a group.
+func (ctrl *GroupController) GetGroupInvitations(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	invitations, err := ctrl.service.GetGroupInvitations(id, userID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve invitations")
+		return
+	}
+	response.Success(c, http.StatusOK, "Invitations retrieved successfully", invitations)
+}
+
+// RevokeInvitation withdraws an invitation.
+func (ctrl *GroupController) RevokeInvitation(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	invitationID, ok := paramID(c, "invitationId", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.RevokeInvitation(id, invitationID, userID); err != nil {
+		respondError(c, err, "Could not revoke invitation")
+		return
+	}
+	response.Deleted(c, "Invitation revoked successfully")
+}
+
+// GetMyInvitations lists the pending invitations for the caller.
+func (ctrl *GroupController) GetMyInvitations(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	invitations, err := ctrl.service.GetMyInvitations(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve invitations", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve invitations", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Invitations retrieved successfully", invitations)
+}
+
+// AcceptInvitation joins the group of an invitation.
+func (ctrl *GroupController) AcceptInvitation(c *gin.Context) {
+	ctrl.respond(c, true)
+}
+
+// DeclineInvitation turns an invitation down.
+func (ctrl *GroupController) DeclineInvitation(c *gin.Context) {
+	ctrl.respond(c, false)
+}
+
+func (ctrl *GroupController) respond(c *gin.Context, accept bool) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c, "id", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	invitation, err := ctrl.service.RespondToInvitation(id, userID, accept)
+	if err != nil {
+		respondError(c, err, "Could not answer invitation")
+		return
+	}
+	response.Updated(c, "Invitation "+invitation.Status, invitation)
+}
+
+// paramID parses a positive ID path parameter, answering 400 when it is invalid.
+func paramID(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, message, nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondError maps the errors of the group service to responses.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrGroupNotFound):
+		response.NotFound(c, "Group not found", nil)
+	case errors.Is(err, ErrInvitationNotFound):
+		response.NotFound(c, "Invitation not found", nil)
+	case errors.Is(err, ErrMemberNotFound):
+		response.NotFound(c, "Member not found", nil)
+	case errors.Is(err, ErrForbidden):
+		response.Forbidden(c, err.Error(), nil)
+	case errors.Is(err, ErrLastOwner), errors.Is(err, ErrAlreadyMember):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalServerError(c, message, err.Error())
+	}
+}
+
+// RespondAccessError answers requests to other resources that name a group the
+// caller cannot use, and reports whether err was such an error.
+func RespondAccessError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrGroupNotFound):
+		response.BadRequest(c, "Invalid group", gin.H{"group_id": err.Error()})
+	case errors.Is(err, ErrForbidden):
+		response.Forbidden(c, "Not allowed to share with this group", err.Error())
+	default:
+		return false
+	}
+	return true
+}
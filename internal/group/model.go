@@ -0,0 +1,63 @@
+package group
+
+import (
+	"time"
+)
+
+// Member roles, from most to least privileged. Owners manage the group and its
+// members, editors can change shared accounts, categories and expenses, and
+// viewers can only read them.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Invitation states.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// Group is a household or team whose members share accounts, categories and expenses.
+type Group struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uint      `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Member links a user to a group with a role.
+type Member struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GroupID   uint      `json:"group_id" gorm:"uniqueIndex:idx_group_member,priority:1"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_group_member,priority:2;index"`
+	Username  string    `json:"username,omitempty" gorm:"->;-:migration"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName keeps members apart from any other "members" table.
+func (Member) TableName() string {
+	return "group_members"
+}
+
+// Invitation asks the user with the given email to join a group.
+type Invitation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GroupID   uint      `json:"group_id" gorm:"index"`
+	Email     string    `json:"email" gorm:"index"`
+	Role      string    `json:"role"`
+	InvitedBy uint      `json:"invited_by"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName keeps invitations apart from any other "invitations" table.
+func (Invitation) TableName() string {
+	return "group_invitations"
+}
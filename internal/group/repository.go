@@ -0,0 +1,222 @@
+package group
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// sharedTables hold resources that can belong to a group.
+var sharedTables = []string{"accounts", "categories", "expenses"}
+
+// Repository stores groups, their members and invitations.
+type Repository interface {
+	Create(group *Group) error
+	GetAllForUser(userID uint) ([]Group, error)
+	GetByID(id uint) (*Group, error)
+	Update(group *Group) error
+	Delete(id uint) error
+	GetRole(groupID, userID uint) (string, error)
+	GetMembers(groupID uint) ([]Member, error)
+	AddMember(member *Member) error
+	UpdateMemberRole(groupID, userID uint, role string) error
+	RemoveMember(groupID, userID uint) error
+	CountOwners(groupID uint) (int64, error)
+	CreateInvitation(invitation *Invitation) error
+	GetInvitationByID(id uint) (*Invitation, error)
+	GetPendingInvitations(email string) ([]Invitation, error)
+	GetGroupInvitations(groupID uint) ([]Invitation, error)
+	UpdateInvitation(invitation *Invitation) error
+	DeleteInvitation(id uint) error
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new group repository with the given database connection.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// Create adds a new group to the database.
+func (r *repository) Create(group *Group) error {
+	if group == nil {
+		return errors.New("group is nil")
+	}
+	return r.db.Create(group).Error
+}
+
+// GetAllForUser returns the groups the user is a member of.
+func (r *repository) GetAllForUser(userID uint) ([]Group, error) {
+	var groups []Group
+	err := r.db.Where("id IN (?)", IDsOf(r.db, userID)).Order("name").Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetByID retrieves a group by its ID.
+func (r *repository) GetByID(id uint) (*Group, error) {
+	var group Group
+	err := r.db.First(&group, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// Update modifies an existing group.
+func (r *repository) Update(group *Group) error {
+	if group == nil || group.ID == 0 {
+		return errors.New("invalid group")
+	}
+	return r.db.Save(group).Error
+}
+
+// Delete removes a group with its members and invitations. Resources that
+// belonged to the group go back to the users who created them.
+func (r *repository) Delete(id uint) error {
+	if id == 0 {
+		return errors.New("invalid group ID")
+	}
+	for _, table := range sharedTables {
+		if err := r.db.Table(table).Where("group_id = ?", id).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	if err := r.db.Where("group_id = ?", id).Delete(&Invitation{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("group_id = ?", id).Delete(&Member{}).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&Group{}, id).Error
+}
+
+// GetRole returns the user's role in the group, or "" when they are not a member.
+func (r *repository) GetRole(groupID, userID uint) (string, error) {
+	var member Member
+	err := r.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// GetMembers lists the members of a group with their usernames.
+func (r *repository) GetMembers(groupID uint) ([]Member, error) {
+	var members []Member
+	err := r.db.Model(&Member{}).
+		Select("group_members.*, COALESCE(users.username, '') AS username").
+		Joins("LEFT JOIN users ON users.id = group_members.user_id").
+		Where("group_members.group_id = ?", groupID).
+		Order("group_members.id").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember adds a user to a group.
+func (r *repository) AddMember(member *Member) error {
+	if member == nil {
+		return errors.New("member is nil")
+	}
+	return r.db.Create(member).Error
+}
+
+// UpdateMemberRole changes the role of a member.
+func (r *repository) UpdateMemberRole(groupID, userID uint, role string) error {
+	return r.db.Model(&Member{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember takes a user out of a group.
+func (r *repository) RemoveMember(groupID, userID uint) error {
+	return r.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&Member{}).Error
+}
+
+// CountOwners returns how many owners the group has.
+func (r *repository) CountOwners(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&Member{}).Where("group_id = ? AND role = ?", groupID, RoleOwner).Count(&count).Error
+	return count, err
+}
+
+// CreateInvitation adds a new invitation to the database.
+func (r *repository) CreateInvitation(invitation *Invitation) error {
+	if invitation == nil {
+		return errors.New("invitation is nil")
+	}
+	return r.db.Create(invitation).Error
+}
+
+// GetInvitationByID retrieves an invitation by its ID.
+func (r *repository) GetInvitationByID(id uint) (*Invitation, error) {
+	var invitation Invitation
+	err := r.db.First(&invitation, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetPendingInvitations lists the open invitations sent to an email address.
+func (r *repository) GetPendingInvitations(email string) ([]Invitation, error) {
+	var invitations []Invitation
+	err := r.db.Where("LOWER(email) = LOWER(?) AND status = ?", email, InvitationPending).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// GetGroupInvitations lists every invitation of a group.
+func (r *repository) GetGroupInvitations(groupID uint) ([]Invitation, error) {
+	var invitations []Invitation
+	err := r.db.Where("group_id = ?", groupID).Order("created_at DESC").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// UpdateInvitation modifies an existing invitation.
+func (r *repository) UpdateInvitation(invitation *Invitation) error {
+	if invitation == nil || invitation.ID == 0 {
+		return errors.New("invalid invitation")
+	}
+	return r.db.Save(invitation).Error
+}
+
+// DeleteInvitation removes an invitation.
+func (r *repository) DeleteInvitation(id uint) error {
+	return r.db.Delete(&Invitation{}, id).Error
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
@@ -0,0 +1,38 @@
+package group
+
+import (
+	"gorm.io/gorm"
+)
+
+// EditorRoles are the roles allowed to change a group's shared resources.
+var EditorRoles = []string{RoleOwner, RoleEditor}
+
+// IDsOf returns a subquery selecting the IDs of the groups userID is a member
+// of, optionally only those where the user has one of roles. Repositories use
+// it to extend their ownership scoping, for example:
+//
+//	Where("user_id = ? OR group_id IN (?)", userID, group.IDsOf(db, userID))
+func IDsOf(db *gorm.DB, userID uint, roles ...string) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true}).
+		Model(&Member{}).
+		Select("group_id").
+		Where("user_id = ?", userID)
+	if len(roles) > 0 {
+		query = query.Where("role IN ?", roles)
+	}
+	return query
+}
+
+// MatesOf returns a subquery selecting the IDs of the users who share at least
+// one group with userID, userID included.
+func MatesOf(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&Member{}).
+		Select("user_id").
+		Where("group_id IN (?)", IDsOf(db, userID))
+}
+
+// CanEdit reports whether role may change a group's shared resources.
+func CanEdit(role string) bool {
+	return role == RoleOwner || role == RoleEditor
+}
@@ -0,0 +1,325 @@
+package group
+
+import (
+	"errors"
+	"strings"
+	"trackonomy/internal/user"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrGroupNotFound is returned when the group does not exist or the user is not a member.
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrForbidden is returned when the user's role does not allow the action.
+	ErrForbidden = errors.New("your role in this group does not allow this")
+	// ErrLastOwner is returned when a change would leave a group without an owner.
+	ErrLastOwner = errors.New("a group must keep at least one owner")
+	// ErrAlreadyMember is returned when inviting someone who is already a member.
+	ErrAlreadyMember = errors.New("user is already a member of this group")
+	// ErrInvitationNotFound is returned when the invitation does not exist or is not for the user.
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrMemberNotFound is returned when the user to change is not a member.
+	ErrMemberNotFound = errors.New("member not found")
+)
+
+type Service interface {
+	CreateGroup(group *Group) error
+	GetGroups(userID uint) ([]Group, error)
+	GetGroup(id, userID uint) (*Group, error)
+	UpdateGroup(id, userID uint, name string) (*Group, error)
+	DeleteGroup(id, userID uint) error
+	GetMembers(groupID, userID uint) ([]Member, error)
+	MemberIDs(groupID, userID uint) ([]uint, error)
+	UpdateMemberRole(groupID, actorID, userID uint, role string) error
+	RemoveMember(groupID, actorID, userID uint) error
+	Invite(groupID, actorID uint, email, role string) (*Invitation, error)
+	GetGroupInvitations(groupID, userID uint) ([]Invitation, error)
+	RevokeInvitation(groupID, invitationID, userID uint) error
+	GetMyInvitations(userID uint) ([]Invitation, error)
+	RespondToInvitation(invitationID, userID uint, accept bool) (*Invitation, error)
+	RequireRole(groupID, userID uint, roles ...string) error
+}
+
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+}
+
+func NewService(repo Repository, userRepo user.Repository) Service {
+	return &service{repo: repo, userRepo: userRepo}
+}
+
+// CreateGroup stores the group and makes its owner the first member.
+func (s *service) CreateGroup(group *Group) error {
+	if group == nil {
+		return errors.New("group cannot be nil")
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(group); err != nil {
+			return err
+		}
+		return repo.AddMember(&Member{GroupID: group.ID, UserID: group.OwnerID, Role: RoleOwner})
+	})
+}
+
+func (s *service) GetGroups(userID uint) ([]Group, error) {
+	return s.repo.GetAllForUser(userID)
+}
+
+// GetGroup returns the group if the user is a member of it.
+func (s *service) GetGroup(id, userID uint) (*Group, error) {
+	if err := s.RequireRole(id, userID); err != nil {
+		return nil, err
+	}
+	group, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// UpdateGroup renames the group. Only owners may do this.
+func (s *service) UpdateGroup(id, userID uint, name string) (*Group, error) {
+	group, err := s.GetGroup(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RequireRole(id, userID, RoleOwner); err != nil {
+		return nil, err
+	}
+	group.Name = name
+	if err := s.repo.Update(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup removes the group. Only owners may do this.
+func (s *service) DeleteGroup(id, userID uint) error {
+	if err := s.RequireRole(id, userID, RoleOwner); err != nil {
+		return err
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		return s.repo.WithTx(tx).Delete(id)
+	})
+}
+
+// GetMembers lists the members of a group the user belongs to.
+func (s *service) GetMembers(groupID, userID uint) ([]Member, error) {
+	if err := s.RequireRole(groupID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(groupID)
+}
+
+// MemberIDs returns the user IDs of every member of a group the user belongs to.
+func (s *service) MemberIDs(groupID, userID uint) ([]uint, error) {
+	members, err := s.GetMembers(groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(members))
+	for i, m := range members {
+		ids[i] = m.UserID
+	}
+	return ids, nil
+}
+
+// UpdateMemberRole changes a member's role. Only owners may do this, and the
+// last owner cannot be demoted.
+func (s *service) UpdateMemberRole(groupID, actorID, userID uint, role string) error {
+	if err := s.RequireRole(groupID, actorID, RoleOwner); err != nil {
+		return err
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		current, err := repo.GetRole(groupID, userID)
+		if err != nil {
+			return err
+		}
+		if current == "" {
+			return ErrMemberNotFound
+		}
+		if current == RoleOwner && role != RoleOwner {
+			if err := s.keepOwner(repo, groupID); err != nil {
+				return err
+			}
+		}
+		return repo.UpdateMemberRole(groupID, userID, role)
+	})
+}
+
+// RemoveMember takes a user out of the group. Owners can remove anyone and
+// every member can leave; the last owner cannot.
+func (s *service) RemoveMember(groupID, actorID, userID uint) error {
+	if actorID != userID {
+		if err := s.RequireRole(groupID, actorID, RoleOwner); err != nil {
+			return err
+		}
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		current, err := repo.GetRole(groupID, userID)
+		if err != nil {
+			return err
+		}
+		if current == "" {
+			return ErrMemberNotFound
+		}
+		if current == RoleOwner {
+			if err := s.keepOwner(repo, groupID); err != nil {
+				return err
+			}
+		}
+		return repo.RemoveMember(groupID, userID)
+	})
+}
+
+// Invite asks the user with the given email to join the group. Only owners may
+// invite. The invitee does not need to be registered yet.
+func (s *service) Invite(groupID, actorID uint, email, role string) (*Invitation, error) {
+	if err := s.RequireRole(groupID, actorID, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	invitee, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if invitee != nil {
+		current, err := s.repo.GetRole(groupID, invitee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current != "" {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	invitation := &Invitation{
+		GroupID:   groupID,
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		Role:      role,
+		InvitedBy: actorID,
+		Status:    InvitationPending,
+	}
+	if err := s.repo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// GetGroupInvitations lists a group's invitations to its owners.
+func (s *service) GetGroupInvitations(groupID, userID uint) ([]Invitation, error) {
+	if err := s.RequireRole(groupID, userID, RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGroupInvitations(groupID)
+}
+
+// RevokeInvitation withdraws an invitation. Only owners may do this.
+func (s *service) RevokeInvitation(groupID, invitationID, userID uint) error {
+	if err := s.RequireRole(groupID, userID, RoleOwner); err != nil {
+		return err
+	}
+	invitation, err := s.repo.GetInvitationByID(invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.GroupID != groupID {
+		return ErrInvitationNotFound
+	}
+	return s.repo.DeleteInvitation(invitationID)
+}
+
+// GetMyInvitations lists the pending invitations sent to the user's email.
+func (s *service) GetMyInvitations(userID uint) ([]Invitation, error) {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, nil
+	}
+	return s.repo.GetPendingInvitations(u.Email)
+}
+
+// RespondToInvitation accepts or declines an invitation sent to the user.
+// Accepting makes the user a member with the invited role.
+func (s *service) RespondToInvitation(invitationID, userID uint, accept bool) (*Invitation, error) {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.repo.GetInvitationByID(invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || invitation == nil || invitation.Status != InvitationPending ||
+		!strings.EqualFold(invitation.Email, u.Email) {
+		return nil, ErrInvitationNotFound
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		invitation.Status = InvitationDeclined
+		if accept {
+			invitation.Status = InvitationAccepted
+
+			current, err := repo.GetRole(invitation.GroupID, userID)
+			if err != nil {
+				return err
+			}
+			if current == "" {
+				member := &Member{GroupID: invitation.GroupID, UserID: userID, Role: invitation.Role}
+				if err := repo.AddMember(member); err != nil {
+					return err
+				}
+			}
+		}
+		return repo.UpdateInvitation(invitation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// RequireRole checks that the user is a member of the group and, when roles
+// are given, that they have one of them. Non-members get ErrGroupNotFound so
+// that group IDs are not revealed.
+func (s *service) RequireRole(groupID, userID uint, roles ...string) error {
+	role, err := s.repo.GetRole(groupID, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrGroupNotFound
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	for _, r := range roles {
+		if role == r {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// keepOwner fails when the group has only one owner left.
+func (s *service) keepOwner(repo Repository, groupID uint) error {
+	owners, err := repo.CountOwners(groupID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package importer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/category"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/upload"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxStatementSize is the largest statement file accepted for import.
+const maxStatementSize = 10 * 1024 * 1024
+
+type ImportController struct {
+	service Service
+}
+
+func NewImportController(service Service) *ImportController {
+	return &ImportController{service: service}
+}
+
+// CreateProfile saves a CSV column mapping for the caller.
+func (ic *ImportController) CreateProfile(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req dto.ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid import profile data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	profile := &ImportProfile{UserID: userID}
+	applyProfileRequest(profile, req)
+
+	if err := ic.service.CreateProfile(profile); err != nil {
+		logger.Error("Failed to create import profile", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not create import profile", err.Error())
+		return
+	}
+	response.Created(c, "Import profile created successfully", profile)
+}
+
+// GetAllProfiles lists the caller's saved column mappings.
+func (ic *ImportController) GetAllProfiles(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	profiles, err := ic.service.GetAllProfiles(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve import profiles", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve import profiles", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Import profiles retrieved successfully", profiles)
+}
+
+// GetProfileByID retrieves a single column mapping.
+func (ic *ImportController) GetProfileByID(c *gin.Context) {
+	profile, ok := ic.findProfile(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	response.Success(c, http.StatusOK, "Import profile retrieved successfully", profile)
+}
+
+// UpdateProfile changes a saved column mapping.
+func (ic *ImportController) UpdateProfile(c *gin.Context) {
+	var req dto.ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid import profile data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	profile, ok := ic.findProfile(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	applyProfileRequest(profile, req)
+
+	if err := ic.service.UpdateProfile(profile); err != nil {
+		logger.Error("Failed to update import profile", zap.Error(err), zap.Uint("profileID", profile.ID))
+		response.InternalServerError(c, "Could not update import profile", err.Error())
+		return
+	}
+	response.Updated(c, "Import profile updated successfully", profile)
+}
+
+// DeleteProfile removes a saved column mapping.
+func (ic *ImportController) DeleteProfile(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid import profile ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid import profile ID", nil)
+		return
+	}
+
+	if err := ic.service.DeleteProfile(uint(id), userID); err != nil {
+		logger.Error("Failed to delete import profile", zap.Error(err), zap.Int("profileID", id))
+		response.InternalServerError(c, "Could not delete import profile", err.Error())
+		return
+	}
+	response.Deleted(c, "Import profile deleted successfully")
+}
+
+// ImportCSV imports a CSV statement (multipart field "file") into an account
+// using a saved profile. Form fields: account_id, profile_id, optional
+// default_category_id and dry_run=true to only preview the result.
+func (ic *ImportController) ImportCSV(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	opts, ok := parseOptions(c, userID)
+	if !ok {
+		return
+	}
+
+	profile, ok := ic.findProfile(c, c.PostForm("profile_id"))
+	if !ok {
+		return
+	}
+	if opts.DefaultCategoryID == nil {
+		opts.DefaultCategoryID = profile.DefaultCategoryID
+	}
+
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "A statement file is required", err.Error())
+		return
+	}
+	defer file.Close()
+	if err := upload.ValidateFile(fileHeader, []string{".csv", ".txt"}, maxStatementSize); err != nil {
+		response.BadRequest(c, "File validation failed", err.Error())
+		return
+	}
+
+	transactions, rowErrors, err := ParseCSV(file, *profile)
+	if err != nil {
+		response.BadRequest(c, "Could not read CSV file", err.Error())
+		return
+	}
+
+	ic.runImport(c, opts, transactions, rowErrors)
+}
+
+// ImportOFX imports an OFX 1.x/2.x or QFX statement (multipart field "file") into
+// an account. Transactions already imported are recognised by their FITID.
+// Form fields: account_id, optional default_category_id and dry_run=true.
+func (ic *ImportController) ImportOFX(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	opts, ok := parseOptions(c, userID)
+	if !ok {
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "A statement file is required", err.Error())
+		return
+	}
+	defer file.Close()
+	if err := upload.ValidateFile(fileHeader, []string{".ofx", ".qfx"}, maxStatementSize); err != nil {
+		response.BadRequest(c, "File validation failed", err.Error())
+		return
+	}
+
+	transactions, rowErrors, err := ParseOFX(file)
+	if err != nil {
+		response.BadRequest(c, "Could not read OFX file", err.Error())
+		return
+	}
+
+	ic.runImport(c, opts, transactions, rowErrors)
+}
+
+// ImportQIF imports a QIF statement (multipart field "file") into an account.
+// Form fields: account_id, optional date_format (Go layout, for day-first
+// dates), default_category_id and dry_run=true.
+func (ic *ImportController) ImportQIF(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	opts, ok := parseOptions(c, userID)
+	if !ok {
+		return
+	}
+
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "A statement file is required", err.Error())
+		return
+	}
+	defer file.Close()
+	if err := upload.ValidateFile(fileHeader, []string{".qif"}, maxStatementSize); err != nil {
+		response.BadRequest(c, "File validation failed", err.Error())
+		return
+	}
+
+	transactions, rowErrors, err := ParseQIF(file, c.PostForm("date_format"))
+	if err != nil {
+		response.BadRequest(c, "Could not read QIF file", err.Error())
+		return
+	}
+
+	ic.runImport(c, opts, transactions, rowErrors)
+}
+
+func (ic *ImportController) runImport(c *gin.Context, opts Options, transactions []Transaction, rowErrors []RowResult) {
+	result, err := ic.service.Import(audit.Context(c), opts, transactions, rowErrors)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			response.BadRequest(c, "Invalid account", err.Error())
+			return
+		}
+		if errors.Is(err, category.ErrCategoryNotFound) {
+			response.BadRequest(c, "Validation error", gin.H{"default_category_id": err.Error()})
+			return
+		}
+		logger.Error("Failed to import statement", zap.Error(err), zap.Uint("userID", opts.UserID))
+		response.InternalServerError(c, "Could not import statement", err.Error())
+		return
+	}
+
+	message := "Statement imported successfully"
+	if opts.DryRun {
+		message = "Import preview generated successfully"
+	}
+	response.Success(c, http.StatusOK, message, result)
+}
+
+// findProfile loads the caller's profile with the given ID, writing an error
+// response and returning false when it cannot be found.
+func (ic *ImportController) findProfile(c *gin.Context, idStr string) (*ImportProfile, bool) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid import profile ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid import profile ID", nil)
+		return nil, false
+	}
+
+	profile, err := ic.service.GetProfileByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve import profile", zap.Error(err), zap.Int("profileID", id))
+		response.InternalServerError(c, "Failed to retrieve import profile", err.Error())
+		return nil, false
+	}
+	if profile == nil {
+		response.NotFound(c, "Import profile not found", nil)
+		return nil, false
+	}
+	return profile, true
+}
+
+// parseOptions reads the form fields shared by every import endpoint.
+func parseOptions(c *gin.Context, userID uint) (Options, bool) {
+	opts := Options{UserID: userID}
+	errs := map[string]string{}
+
+	accountID, err := strconv.Atoi(c.PostForm("account_id"))
+	if err != nil || accountID <= 0 {
+		errs["account_id"] = "a valid account_id is required"
+	}
+	opts.AccountID = uint(accountID)
+
+	if v := c.PostForm("default_category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			errs["default_category_id"] = "must be a positive integer"
+		}
+		categoryID := uint(id)
+		opts.DefaultCategoryID = &categoryID
+	}
+
+	if v := c.PostForm("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			errs["dry_run"] = "must be true or false"
+		}
+		opts.DryRun = dryRun
+	}
+
+	if len(errs) > 0 {
+		response.BadRequest(c, "Invalid import parameters", errs)
+		return opts, false
+	}
+	return opts, true
+}
+
+func applyProfileRequest(profile *ImportProfile, req dto.ImportProfileRequest) {
+	profile.Name = req.Name
+	profile.Delimiter = req.Delimiter
+	profile.HasHeader = req.HasHeader
+	profile.DateFormat = req.DateFormat
+	profile.DateColumn = req.DateColumn
+	profile.DescriptionColumn = req.DescriptionColumn
+	profile.AmountColumn = req.AmountColumn
+	profile.DebitColumn = req.DebitColumn
+	profile.CreditColumn = req.CreditColumn
+	profile.CategoryColumn = req.CategoryColumn
+	profile.AmountSign = req.AmountSign
+	profile.DecimalComma = req.DecimalComma
+	profile.DefaultCategoryID = req.DefaultCategoryID
+
+	if profile.Delimiter == "" {
+		profile.Delimiter = ","
+	}
+	if profile.AmountSign == "" {
+		profile.AmountSign = SignNegativeIsExpense
+	}
+}
@@ -0,0 +1,238 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"trackonomy/internal/money"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseCSV reads a statement using the profile's column mapping. Rows that cannot
+// be parsed are returned as RowResults with StatusError instead of failing the
+// whole file. Row numbers are the 1-based lines of the file where a row starts.
+func ParseCSV(r io.Reader, profile ImportProfile) ([]Transaction, []RowResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if profile.Delimiter != "" {
+		d, _ := utf8.DecodeRuneInString(profile.Delimiter)
+		reader.Comma = d
+	}
+
+	var (
+		transactions []Transaction
+		rowErrors    []RowResult
+		columns      map[string]int
+	)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, RowResult{Row: parseErr.StartLine, Status: StatusError, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if columns == nil {
+			columns = map[string]int{}
+			if profile.HasHeader {
+				for i, name := range record {
+					columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+				}
+				continue
+			}
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		t, err := parseRecord(record, columns, profile)
+		if err != nil {
+			rowErrors = append(rowErrors, RowResult{Row: line, Status: StatusError, Error: err.Error()})
+			continue
+		}
+		t.Row = line
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rowErrors, nil
+}
+
+func parseRecord(record []string, columns map[string]int, profile ImportProfile) (Transaction, error) {
+	var t Transaction
+
+	dateStr, err := field(record, columns, profile.DateColumn)
+	if err != nil {
+		return t, err
+	}
+	layout := profile.DateFormat
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	t.Date, err = time.Parse(layout, dateStr)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q, expected format %s", dateStr, layout)
+	}
+
+	if t.Description, err = field(record, columns, profile.DescriptionColumn); err != nil {
+		return t, err
+	}
+	if profile.CategoryColumn != "" {
+		if t.CategoryName, err = field(record, columns, profile.CategoryColumn); err != nil {
+			return t, err
+		}
+	}
+
+	var signed money.Amount
+	if profile.AmountColumn != "" {
+		raw, err := field(record, columns, profile.AmountColumn)
+		if err != nil {
+			return t, err
+		}
+		if signed, err = parseAmount(raw, profile.DecimalComma); err != nil {
+			return t, err
+		}
+		if profile.AmountSign == SignPositiveIsExpense {
+			signed = -signed
+		}
+	} else {
+		debit, err := optionalAmount(record, columns, profile.DebitColumn, profile.DecimalComma)
+		if err != nil {
+			return t, err
+		}
+		credit, err := optionalAmount(record, columns, profile.CreditColumn, profile.DecimalComma)
+		if err != nil {
+			return t, err
+		}
+		// Some banks print debits as negative numbers, others as positive ones.
+		if debit < 0 {
+			debit = -debit
+		}
+		signed = credit - debit
+	}
+
+	if signed == 0 {
+		return t, errors.New("amount is zero")
+	}
+	t.IsIncome = signed > 0
+	if signed < 0 {
+		signed = -signed
+	}
+	t.Amount = signed
+	return t, nil
+}
+
+// field returns the trimmed value of a mapped column.
+func field(record []string, columns map[string]int, ref string) (string, error) {
+	if ref == "" {
+		return "", errors.New("column mapping is incomplete")
+	}
+	idx, ok := columns[strings.ToLower(strings.TrimSpace(ref))]
+	if !ok {
+		n, err := strconv.Atoi(ref)
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("unknown column %q", ref)
+		}
+		idx = n - 1
+	}
+	if idx >= len(record) {
+		return "", fmt.Errorf("missing column %q", ref)
+	}
+	return strings.TrimSpace(record[idx]), nil
+}
+
+func optionalAmount(record []string, columns map[string]int, ref string, decimalComma bool) (money.Amount, error) {
+	if ref == "" {
+		return 0, nil
+	}
+	raw, err := field(record, columns, ref)
+	if err != nil || raw == "" {
+		return 0, err
+	}
+	return parseAmount(raw, decimalComma)
+}
+
+// parseAmount understands thousands separators, currency symbols and codes,
+// trailing minus signs and accounting-style parentheses, e.g. "(1,234.50)",
+// "1.234,50-" or "EUR 12.50". Any other character makes the amount invalid
+// rather than being skipped, so "1e5" is not read as 15.
+func parseAmount(raw string, decimalComma bool) (money.Amount, error) {
+	s := strings.TrimSpace(raw)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative = true
+		s = strings.TrimSuffix(s, "-")
+	}
+	s = trimCurrencyCode(strings.TrimSpace(s))
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		case r == ',' && decimalComma, r == '.' && !decimalComma:
+			b.WriteRune('.')
+		case r == ',', r == '.', r == '\'', r == '+', unicode.IsSpace(r), unicode.Is(unicode.Sc, r):
+			// Thousands separators, an explicit plus sign and currency symbols.
+		default:
+			return 0, fmt.Errorf("invalid amount %q", raw)
+		}
+	}
+
+	value, err := money.Parse(b.String())
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// trimCurrencyCode removes an ISO 4217 code such as "EUR" written before or
+// after an amount.
+func trimCurrencyCode(s string) string {
+	isCode := func(code string) bool {
+		for _, r := range code {
+			if r < 'A' || r > 'Z' {
+				return false
+			}
+		}
+		return true
+	}
+	if len(s) > 3 && isCode(s[:3]) {
+		return strings.TrimSpace(s[3:])
+	}
+	if len(s) > 3 && isCode(s[len(s)-3:]) {
+		return strings.TrimSpace(s[:len(s)-3])
+	}
+	return s
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"trackonomy/internal/money"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		profile   ImportProfile
+		want      []Transaction
+		errorRows []int
+	}{
+		{
+			name: "header with a byte order mark",
+			input: "\ufeffDate,Description,Amount\n" +
+				"2024-01-05,Big Bazaar,-1234.50\n" +
+				"2024-01-31,ACME Corp,50000\n",
+			profile: ImportProfile{HasHeader: true, DateColumn: "date", DescriptionColumn: "Description", AmountColumn: "amount"},
+			want: []Transaction{
+				{Row: 2, Date: date(2024, 1, 5), Amount: money.MustParse("1234.50"), Description: "Big Bazaar"},
+				{Row: 3, Date: date(2024, 1, 31), Amount: money.MustParse("50000"), IsIncome: true, Description: "ACME Corp"},
+			},
+		},
+		{
+			name:  "positional columns without a header",
+			input: "05/01/2024;Netflix;99,00;Subscriptions\n",
+			profile: ImportProfile{Delimiter: ";", DateFormat: "02/01/2006", DateColumn: "1", DescriptionColumn: "2",
+				AmountColumn: "3", CategoryColumn: "4", AmountSign: SignPositiveIsExpense, DecimalComma: true},
+			want: []Transaction{
+				{Row: 1, Date: date(2024, 1, 5), Amount: money.MustParse("99"), Description: "Netflix", CategoryName: "Subscriptions"},
+			},
+		},
+		{
+			name: "debit and credit columns",
+			input: "date,description,debit,credit\n" +
+				"2024-02-01,Rent,1500.00,\n" +
+				"2024-02-02,Refund,,25.00\n" +
+				"2024-02-03,Fee,-2.50,\n",
+			profile: ImportProfile{HasHeader: true, DateColumn: "date", DescriptionColumn: "description",
+				DebitColumn: "debit", CreditColumn: "credit"},
+			want: []Transaction{
+				{Row: 2, Date: date(2024, 2, 1), Amount: money.MustParse("1500"), Description: "Rent"},
+				{Row: 3, Date: date(2024, 2, 2), Amount: money.MustParse("25"), IsIncome: true, Description: "Refund"},
+				{Row: 4, Date: date(2024, 2, 3), Amount: money.MustParse("2.50"), Description: "Fee"},
+			},
+		},
+		{
+			name: "bad rows are reported and skipped",
+			input: "date,description,amount\n" +
+				"2024-03-01,Coffee,-3.50\n" +
+				"\n" +
+				"01/03/2024,Wrong date,-1\n" +
+				"2024-03-02,Zero,0\n" +
+				"2024-03-03,Exponent,1e5\n" +
+				"2024-03-04,Short\n",
+			profile: ImportProfile{HasHeader: true, DateColumn: "date", DescriptionColumn: "description", AmountColumn: "amount"},
+			want: []Transaction{
+				{Row: 2, Date: date(2024, 3, 1), Amount: money.MustParse("3.50"), Description: "Coffee"},
+			},
+			errorRows: []int{4, 5, 6, 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rowErrors, err := ParseCSV(strings.NewReader(tt.input), tt.profile)
+			if err != nil {
+				t.Fatalf("ParseCSV() error = %v", err)
+			}
+			assertTransactions(t, got, tt.want)
+			assertErrorRows(t, rowErrors, tt.errorRows)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		raw          string
+		decimalComma bool
+		want         string // "" when the amount is refused
+	}{
+		{raw: "12.50", want: "12.50"},
+		{raw: "-12.50", want: "-12.50"},
+		{raw: "+12.50", want: "12.50"},
+		{raw: "1,234.50", want: "1234.50"},
+		{raw: "(1,234.50)", want: "-1234.50"},
+		{raw: "1234.50-", want: "-1234.50"},
+		{raw: "$ 1,234.50", want: "1234.50"},
+		{raw: "₹1,00,000", want: "100000"},
+		{raw: "EUR 12.50", want: "12.50"},
+		{raw: "12.50 USD", want: "12.50"},
+		{raw: "1'234.50", want: "1234.50"},
+		{raw: "1.234,50", decimalComma: true, want: "1234.50"},
+		{raw: "1 234,50-", decimalComma: true, want: "-1234.50"},
+		{raw: "1e5"},
+		{raw: "12.50abc"},
+		{raw: "twelve"},
+		{raw: ""},
+		{raw: "1.2.3"},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.raw, tt.decimalComma)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("parseAmount(%q) = %s, want an error", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != money.MustParse(tt.want) {
+			t.Errorf("parseAmount(%q) = %s, %v; want %s", tt.raw, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// fingerprint identifies a statement line so that importing it again can be detected.
+//
+// Lines carrying a bank FITID are identified by it; FITIDs are only unique
+// within one account, so the account is part of the key. Other lines are
+// identified by account, date, amount and description, so that the same
+// purchase on two accounts is not taken for a repetition. Two identical lines
+// in the same file (say, two coffees on the same day) are both real, so the
+// n-th repetition gets its own fingerprint. Importing the same file again
+// produces the same fingerprints, and every line is skipped.
+func fingerprint(t Transaction, accountID uint, seen map[string]int) string {
+	if t.FITID != "" {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("fitid|%d|%s", accountID, t.FITID)))
+		return hex.EncodeToString(sum[:])
+	}
+	base := fmt.Sprintf("account|%d|%s", accountID, lineKey(t))
+	n := seen[base]
+	seen[base] = n + 1
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", base, n)))
+	return hex.EncodeToString(sum[:])
+}
+
+// legacyFingerprint is the fingerprint lines without a FITID got before the
+// account was part of it, or "" for lines with one. Records imported back then
+// still carry it, so it is looked up too, on the same account only.
+func legacyFingerprint(t Transaction, seen map[string]int) string {
+	if t.FITID != "" {
+		return ""
+	}
+	base := lineKey(t)
+	n := seen[base]
+	seen[base] = n + 1
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", base, n)))
+	return hex.EncodeToString(sum[:])
+}
+
+// lineKey describes a line without a FITID by its date, direction, amount and
+// description.
+func lineKey(t Transaction) string {
+	direction := "out"
+	if t.IsIncome {
+		direction = "in"
+	}
+	// The amount is written to the cent, as it was when amounts were floats,
+	// so that files imported back then are still recognised.
+	return fmt.Sprintf("%s|%s|%s|%s",
+		t.Date.Format("2006-01-02"),
+		direction,
+		t.Amount.RoundTo(2),
+		strings.ToLower(strings.Join(strings.Fields(t.Description), " ")),
+	)
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"time"
+	"trackonomy/internal/money"
+)
+
+// Amount sign conventions for statements with a single amount column.
+const (
+	SignNegativeIsExpense = "negative_is_expense" // bank accounts: money out is negative
+	SignPositiveIsExpense = "positive_is_expense" // credit cards: charges are positive
+)
+
+// Row statuses reported back to the client.
+const (
+	StatusImported    = "imported"
+	StatusWouldImport = "would_import"
+	StatusDuplicate   = "duplicate"
+	StatusError       = "error"
+)
+
+// ImportProfile is a saved column mapping describing how to read one bank's CSV export.
+//
+// Column references are header names when HasHeader is set, otherwise 1-based
+// column numbers ("1", "2", ...). Use either AmountColumn, or DebitColumn and
+// CreditColumn for statements that split money out and money in.
+type ImportProfile struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `json:"user_id"`
+	Name      string `json:"name"`
+	Delimiter string `json:"delimiter"`
+	HasHeader bool   `json:"has_header"`
+
+	// DateFormat is a Go time layout, e.g. "02/01/2006" for dd/mm/yyyy.
+	DateFormat        string `json:"date_format"`
+	DateColumn        string `json:"date_column"`
+	DescriptionColumn string `json:"description_column"`
+	AmountColumn      string `json:"amount_column,omitempty"`
+	DebitColumn       string `json:"debit_column,omitempty"`
+	CreditColumn      string `json:"credit_column,omitempty"`
+	CategoryColumn    string `json:"category_column,omitempty"`
+
+	AmountSign   string `json:"amount_sign"`
+	DecimalComma bool   `json:"decimal_comma"`
+
+	// DefaultCategoryID is used when a row has no category or its name is unknown.
+	DefaultCategoryID *uint `json:"default_category_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Transaction is one statement line, normalized from whichever format it came from.
+// Amount is always positive; IsIncome tells money in from money out.
+type Transaction struct {
+	Row          int          `json:"row"`
+	Date         time.Time    `json:"date"`
+	Amount       money.Amount `json:"amount"`
+	IsIncome     bool         `json:"is_income"`
+	Description  string       `json:"description"`
+	CategoryName string       `json:"category_name,omitempty"`
+	// FITID is the bank's own transaction ID from OFX files, stable across downloads.
+	FITID string `json:"fitid,omitempty"`
+}
+
+// RowResult reports what happened to a single statement line.
+type RowResult struct {
+	Row         int          `json:"row"`
+	Status      string       `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	Date        *time.Time   `json:"date,omitempty"`
+	Amount      money.Amount `json:"amount,omitempty"`
+	Kind        string       `json:"kind,omitempty"`
+	Description string       `json:"description,omitempty"`
+	CategoryID  uint         `json:"category_id,omitempty"`
+	RecordID    uint         `json:"record_id,omitempty"`
+}
+
+// Result summarizes an import or a dry-run preview.
+type Result struct {
+	DryRun     bool        `json:"dry_run"`
+	Total      int         `json:"total"`
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Failed     int         `json:"failed"`
+	Rows       []RowResult `json:"rows"`
+}
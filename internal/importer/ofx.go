@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"trackonomy/internal/money"
+)
+
+// ofxEntities decodes the escapes allowed in OFX 2.x (XML) element values.
+var ofxEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&")
+
+// ParseOFX reads bank and credit card transactions from an OFX or QFX file.
+//
+// Both OFX 1.x (SGML, where leaf elements have no closing tags) and OFX 2.x
+// (XML) are supported: the file is read as a flat stream of tags, and every
+// tag directly followed by text is treated as a value of the current STMTTRN.
+// Transactions that cannot be read are returned as RowResults with StatusError,
+// numbered by their position in the file.
+func ParseOFX(r io.Reader) ([]Transaction, []RowResult, error) {
+	data, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	body := string(data)
+
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, nil, errors.New("not an OFX file: missing <OFX> element")
+	}
+	body = body[start:]
+
+	var (
+		transactions []Transaction
+		rowErrors    []RowResult
+		current      map[string]string
+		count        int
+	)
+
+	for len(body) > 0 {
+		open := strings.IndexByte(body, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(body[open:], '>')
+		if end < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[open+1 : open+end]))
+		body = body[open+end+1:]
+
+		switch {
+		case tag == "STMTTRN":
+			current = map[string]string{}
+		case tag == "/STMTTRN":
+			if current == nil {
+				continue
+			}
+			count++
+			t, err := ofxTransaction(current)
+			if err != nil {
+				rowErrors = append(rowErrors, RowResult{Row: count, Status: StatusError, Error: err.Error()})
+			} else {
+				t.Row = count
+				transactions = append(transactions, t)
+			}
+			current = nil
+		case current != nil && !strings.HasPrefix(tag, "/"):
+			next := strings.IndexByte(body, '<')
+			if next < 0 {
+				next = len(body)
+			}
+			if value := strings.TrimSpace(body[:next]); value != "" {
+				current[tag] = ofxEntities.Replace(value)
+			}
+		}
+	}
+
+	return transactions, rowErrors, nil
+}
+
+func ofxTransaction(fields map[string]string) (Transaction, error) {
+	var t Transaction
+
+	posted := fields["DTPOSTED"]
+	if len(posted) < 8 {
+		return t, fmt.Errorf("invalid DTPOSTED %q", posted)
+	}
+	date, err := time.Parse("20060102", posted[:8])
+	if err != nil {
+		return t, fmt.Errorf("invalid DTPOSTED %q", posted)
+	}
+	t.Date = date
+
+	raw := fields["TRNAMT"]
+	if !strings.Contains(raw, ".") {
+		raw = strings.Replace(raw, ",", ".", 1)
+	}
+	amount, err := money.Parse(raw)
+	if err != nil {
+		return t, fmt.Errorf("invalid TRNAMT %q", fields["TRNAMT"])
+	}
+	if amount == 0 {
+		return t, errors.New("amount is zero")
+	}
+	t.IsIncome = amount > 0
+	if amount < 0 {
+		amount = -amount
+	}
+	t.Amount = amount
+
+	t.Description = fields["NAME"]
+	if t.Description == "" {
+		t.Description = fields["PAYEE"]
+	}
+	if memo := fields["MEMO"]; memo != "" && memo != t.Description {
+		if t.Description == "" {
+			t.Description = memo
+		} else {
+			t.Description += " - " + memo
+		}
+	}
+
+	t.FITID = fields["FITID"]
+	return t, nil
+}
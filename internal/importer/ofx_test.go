@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"os"
+	"testing"
+	"time"
+	"trackonomy/internal/money"
+)
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Transaction
+		errRows []int
+	}{
+		{
+			fixture: "testdata/bank_v1.ofx",
+			want: []Transaction{
+				{Row: 1, Date: date(2024, 1, 3), Amount: money.MustParse("42.15"), Description: "SWIGGY BANGALORE - Food order", FITID: "20240103001"},
+				{Row: 2, Date: date(2024, 1, 31), Amount: money.MustParse("2500"), IsIncome: true, Description: "ACME CORP PAYROLL", FITID: "20240131002"},
+			},
+			errRows: []int{3},
+		},
+		{
+			fixture: "testdata/card_v2.qfx",
+			want: []Transaction{
+				{Row: 1, Date: date(2024, 2, 10), Amount: money.MustParse("450"), Description: "UBER *TRIP", FITID: "CC-9001"},
+				{Row: 2, Date: date(2024, 2, 15), Amount: money.MustParse("120.5"), IsIncome: true, Description: "Refund & Cashback", FITID: "CC-9002"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			f, err := os.Open(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, rowErrors, err := ParseOFX(f)
+			if err != nil {
+				t.Fatalf("ParseOFX() error = %v", err)
+			}
+			assertTransactions(t, got, tt.want)
+			assertErrorRows(t, rowErrors, tt.errRows)
+		})
+	}
+}
+
+func TestParseOFXRejectsOtherFiles(t *testing.T) {
+	f, err := os.Open("testdata/bank.qif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, _, err := ParseOFX(f); err == nil {
+		t.Fatal("ParseOFX() on a QIF file: expected an error")
+	}
+}
+
+func TestFingerprintUsesFITID(t *testing.T) {
+	a := Transaction{Date: date(2024, 1, 3), Amount: money.MustParse("10"), Description: "Coffee", FITID: "X1"}
+	b := Transaction{Date: date(2024, 1, 4), Amount: money.MustParse("12"), Description: "Coffee shop", FITID: "X1"}
+
+	if fingerprint(a, 1, map[string]int{}) != fingerprint(b, 1, map[string]int{}) {
+		t.Error("transactions with the same FITID in one account should share a fingerprint")
+	}
+	if fingerprint(a, 1, map[string]int{}) == fingerprint(a, 2, map[string]int{}) {
+		t.Error("the same FITID in different accounts should not collide")
+	}
+
+	a.FITID = ""
+	seen := map[string]int{}
+	if fingerprint(a, 1, seen) == fingerprint(a, 1, seen) {
+		t.Error("repeated identical lines in one file should get distinct fingerprints")
+	}
+	if fingerprint(a, 1, map[string]int{}) == fingerprint(a, 2, map[string]int{}) {
+		t.Error("identical lines on different accounts should not collide")
+	}
+	if legacyFingerprint(b, map[string]int{}) != "" {
+		t.Error("lines with a FITID have no legacy fingerprint")
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func assertTransactions(t *testing.T, got, want []Transaction) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) {
+			t.Errorf("transaction %d: date = %v, want %v", i, got[i].Date, want[i].Date)
+		}
+		g, w := got[i], want[i]
+		g.Date, w.Date = time.Time{}, time.Time{}
+		if g != w {
+			t.Errorf("transaction %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func assertErrorRows(t *testing.T, got []RowResult, wantRows []int) {
+	t.Helper()
+	if len(got) != len(wantRows) {
+		t.Fatalf("got %d row errors, want %d: %+v", len(got), len(wantRows), got)
+	}
+	for i, row := range wantRows {
+		if got[i].Row != row || got[i].Status != StatusError || got[i].Error == "" {
+			t.Errorf("row error %d = %+v, want an error on row %d", i, got[i], row)
+		}
+	}
+}
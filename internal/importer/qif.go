@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// qifDateLayouts are tried in order when no date format is given. QIF files
+// from US software commonly write the year after an apostrophe, e.g. 1/31'24.
+var qifDateLayouts = []string{
+	"01/02/2006",
+	"1/2/2006",
+	"01/02/06",
+	"1/2/06",
+	"2006-01-02",
+}
+
+// ParseQIF reads bank and credit card transactions from a QIF file. dateFormat
+// is an optional Go time layout for banks that write day-first dates. Records
+// that cannot be read are returned as RowResults with StatusError, numbered by
+// the line where the record starts.
+func ParseQIF(r io.Reader, dateFormat string) ([]Transaction, []RowResult, error) {
+	scanner := bufio.NewScanner(r)
+
+	var (
+		transactions []Transaction
+		rowErrors    []RowResult
+		fields       = map[byte]string{}
+		startLine    int
+		line         int
+		skip         bool
+	)
+
+	flush := func() {
+		if len(fields) > 0 && !skip {
+			t, err := qifTransaction(fields, dateFormat)
+			if err != nil {
+				rowErrors = append(rowErrors, RowResult{Row: startLine, Status: StatusError, Error: err.Error()})
+			} else {
+				t.Row = startLine
+				transactions = append(transactions, t)
+			}
+		}
+		fields = map[byte]string{}
+		startLine = 0
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			flush()
+			// Only bank-like sections hold transactions; skip account lists,
+			// category lists, investments and other option blocks.
+			header := strings.ToLower(strings.TrimSpace(text))
+			skip = !(strings.HasPrefix(header, "!type:bank") ||
+				strings.HasPrefix(header, "!type:ccard") ||
+				strings.HasPrefix(header, "!type:cash") ||
+				strings.HasPrefix(header, "!type:oth"))
+			continue
+		}
+		if text[0] == '^' {
+			flush()
+			continue
+		}
+
+		if startLine == 0 {
+			startLine = line
+		}
+		code, value := text[0], strings.TrimSpace(text[1:])
+		// Split lines (S, E, $) repeat; the first value is kept for the others.
+		if _, exists := fields[code]; !exists {
+			fields[code] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	flush()
+
+	return transactions, rowErrors, nil
+}
+
+func qifTransaction(fields map[byte]string, dateFormat string) (Transaction, error) {
+	var t Transaction
+
+	date, err := parseQIFDate(fields['D'], dateFormat)
+	if err != nil {
+		return t, err
+	}
+	t.Date = date
+
+	raw, ok := fields['T']
+	if !ok {
+		raw = fields['U']
+	}
+	amount, err := parseAmount(raw, false)
+	if err != nil {
+		return t, err
+	}
+	if amount == 0 {
+		return t, errors.New("amount is zero")
+	}
+	t.IsIncome = amount > 0
+	if amount < 0 {
+		amount = -amount
+	}
+	t.Amount = amount
+
+	t.Description = fields['P']
+	if memo := fields['M']; memo != "" && memo != t.Description {
+		if t.Description == "" {
+			t.Description = memo
+		} else {
+			t.Description += " - " + memo
+		}
+	}
+
+	// L holds "Category:Subcategory", or "[Account]" for a transfer.
+	if category := fields['L']; category != "" && !strings.HasPrefix(category, "[") {
+		parts := strings.Split(category, ":")
+		t.CategoryName = strings.TrimSpace(parts[len(parts)-1])
+	}
+	return t, nil
+}
+
+func parseQIFDate(value, dateFormat string) (time.Time, error) {
+	normalized := strings.ReplaceAll(strings.TrimSpace(value), "'", "/")
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	if dateFormat != "" {
+		d, err := time.Parse(dateFormat, normalized)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q, expected format %s", value, dateFormat)
+		}
+		return d, nil
+	}
+	for _, layout := range qifDateLayouts {
+		if d, err := time.Parse(layout, normalized); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,41 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"trackonomy/internal/money"
+)
+
+func TestParseQIF(t *testing.T) {
+	f, err := os.Open("testdata/bank.qif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, rowErrors, err := ParseQIF(f, "")
+	if err != nil {
+		t.Fatalf("ParseQIF() error = %v", err)
+	}
+
+	assertTransactions(t, got, []Transaction{
+		{Row: 6, Date: date(2024, 1, 5), Amount: money.MustParse("1234.5"), Description: "Big Bazaar - Weekly shopping", CategoryName: "Groceries"},
+		{Row: 12, Date: date(2024, 1, 31), Amount: money.MustParse("50000"), IsIncome: true, Description: "ACME Corp", CategoryName: "Salary"},
+		{Row: 17, Date: date(2024, 2, 1), Amount: money.MustParse("300"), Description: "Transfer to savings"},
+	})
+	assertErrorRows(t, rowErrors, []int{22})
+}
+
+func TestParseQIFDateFormat(t *testing.T) {
+	input := "!Type:CCard\nD31/01/2024\nT-99.00\nPNetflix\n^\n"
+
+	got, rowErrors, err := ParseQIF(strings.NewReader(input), "02/01/2006")
+	if err != nil {
+		t.Fatalf("ParseQIF() error = %v", err)
+	}
+	assertErrorRows(t, rowErrors, nil)
+	assertTransactions(t, got, []Transaction{
+		{Row: 2, Date: date(2024, 1, 31), Amount: money.MustParse("99"), Description: "Netflix"},
+	})
+}
@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository stores saved import profiles.
+type Repository interface {
+	CreateProfile(profile *ImportProfile) error
+	GetAllProfiles(userID uint) ([]ImportProfile, error)
+	GetProfileByID(id, userID uint) (*ImportProfile, error)
+	UpdateProfile(profile *ImportProfile) error
+	DeleteProfile(id, userID uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// CreateProfile adds a new import profile to the database.
+func (r *repository) CreateProfile(profile *ImportProfile) error {
+	if profile == nil {
+		return errors.New("import profile is nil")
+	}
+	return r.db.Create(profile).Error
+}
+
+// GetAllProfiles returns all import profiles of the user.
+func (r *repository) GetAllProfiles(userID uint) ([]ImportProfile, error) {
+	var profiles []ImportProfile
+	err := r.db.Where("user_id = ?", userID).Find(&profiles).Error
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// GetProfileByID fetches an import profile by ID, ensuring user ownership.
+func (r *repository) GetProfileByID(id, userID uint) (*ImportProfile, error) {
+	var profile ImportProfile
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateProfile modifies an existing import profile.
+func (r *repository) UpdateProfile(profile *ImportProfile) error {
+	if profile == nil || profile.ID == 0 {
+		return errors.New("invalid import profile")
+	}
+	return r.db.Save(profile).Error
+}
+
+// DeleteProfile removes an import profile by ID, ensuring user ownership.
+func (r *repository) DeleteProfile(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid import profile ID")
+	}
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&ImportProfile{}).Error
+}
@@ -0,0 +1,321 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/expense"
+	"trackonomy/internal/income"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/utils"
+)
+
+// Options control a single import run.
+type Options struct {
+	UserID    uint
+	AccountID uint
+	// DefaultCategoryID is used for rows without a known category.
+	DefaultCategoryID *uint
+	// DryRun previews the import without writing anything.
+	DryRun bool
+}
+
+type Service interface {
+	CreateProfile(profile *ImportProfile) error
+	GetAllProfiles(userID uint) ([]ImportProfile, error)
+	GetProfileByID(id, userID uint) (*ImportProfile, error)
+	UpdateProfile(profile *ImportProfile) error
+	DeleteProfile(id, userID uint) error
+	Import(ctx context.Context, opts Options, transactions []Transaction, rowErrors []RowResult) (*Result, error)
+}
+
+type service struct {
+	repo            Repository
+	accountRepo     account.Repository
+	categoryService category.Service
+	expenseRepo     expense.Repository
+	expenseService  expense.Service
+	incomeRepo      income.Repository
+	incomeService   income.Service
+	ruleRepo        rule.Repository
+}
+
+func NewService(
+	repo Repository,
+	accountRepo account.Repository,
+	categoryService category.Service,
+	expenseRepo expense.Repository,
+	expenseService expense.Service,
+	incomeRepo income.Repository,
+	incomeService income.Service,
+	ruleRepo rule.Repository,
+) Service {
+	return &service{
+		repo:            repo,
+		accountRepo:     accountRepo,
+		categoryService: categoryService,
+		expenseRepo:     expenseRepo,
+		expenseService:  expenseService,
+		incomeRepo:      incomeRepo,
+		incomeService:   incomeService,
+		ruleRepo:        ruleRepo,
+	}
+}
+
+func (s *service) CreateProfile(profile *ImportProfile) error {
+	if profile == nil {
+		return errors.New("import profile cannot be nil")
+	}
+	return s.repo.CreateProfile(profile)
+}
+
+func (s *service) GetAllProfiles(userID uint) ([]ImportProfile, error) {
+	return s.repo.GetAllProfiles(userID)
+}
+
+func (s *service) GetProfileByID(id, userID uint) (*ImportProfile, error) {
+	if id == 0 {
+		return nil, errors.New("invalid import profile ID")
+	}
+	return s.repo.GetProfileByID(id, userID)
+}
+
+func (s *service) UpdateProfile(profile *ImportProfile) error {
+	if profile == nil || profile.ID == 0 {
+		return errors.New("invalid import profile")
+	}
+	return s.repo.UpdateProfile(profile)
+}
+
+func (s *service) DeleteProfile(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid import profile ID")
+	}
+	return s.repo.DeleteProfile(id, userID)
+}
+
+// Import turns parsed statement lines into expenses (money out) and incomes
+// (money in) on the chosen account. Lines already imported to it are skipped,
+// and every other line is written independently so one bad row does not stop
+// the rest. rowErrors from parsing are merged into the result.
+func (s *service) Import(ctx context.Context, opts Options, transactions []Transaction, rowErrors []RowResult) (*Result, error) {
+	acc, err := s.accountRepo.GetByID(opts.AccountID, opts.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil || acc.UserID != opts.UserID {
+		return nil, account.ErrAccountNotFound
+	}
+
+	categories, err := s.categoryLookup(opts.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DefaultCategoryID != nil && categories.kinds[*opts.DefaultCategoryID] == "" {
+		return nil, fmt.Errorf("%w: %d", category.ErrCategoryNotFound, *opts.DefaultCategoryID)
+	}
+	rules, err := s.ruleRepo.GetEnabled(opts.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen, legacySeen := map[string]int{}, map[string]int{}
+	fingerprints := make([]string, len(transactions))
+	legacy := make([]string, len(transactions))
+	lookup := make([]string, 0, 2*len(transactions))
+	for i, t := range transactions {
+		fingerprints[i] = fingerprint(t, opts.AccountID, seen)
+		lookup = append(lookup, fingerprints[i])
+		if legacy[i] = legacyFingerprint(t, legacySeen); legacy[i] != "" {
+			lookup = append(lookup, legacy[i])
+		}
+	}
+	existingExpenses, err := s.expenseRepo.ExistingFingerprints(opts.UserID, opts.AccountID, lookup)
+	if err != nil {
+		return nil, err
+	}
+	existingIncomes, err := s.incomeRepo.ExistingFingerprints(opts.UserID, opts.AccountID, lookup)
+	if err != nil {
+		return nil, err
+	}
+	imported := func(fp string) bool {
+		return fp != "" && (existingExpenses[fp] || existingIncomes[fp])
+	}
+
+	result := &Result{DryRun: opts.DryRun, Total: len(transactions) + len(rowErrors)}
+	for i, t := range transactions {
+		date := t.Date
+		row := RowResult{
+			Row:         t.Row,
+			Date:        &date,
+			Amount:      t.Amount,
+			Kind:        category.KindExpense,
+			Description: t.Description,
+		}
+		if t.IsIncome {
+			row.Kind = category.KindIncome
+		}
+
+		switch {
+		case imported(fingerprints[i]) || imported(legacy[i]):
+			row.Status = StatusDuplicate
+		default:
+			row.CategoryID, err = categories.resolve(t, fallbackCategory(rules, t, opts.DefaultCategoryID))
+			if err != nil {
+				row.Status, row.Error = StatusError, err.Error()
+			} else if opts.DryRun {
+				row.Status = StatusWouldImport
+			} else if row.RecordID, err = s.write(ctx, opts, t, row.CategoryID, fingerprints[i]); err != nil {
+				row.Status, row.Error = StatusError, err.Error()
+			} else {
+				row.Status = StatusImported
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	result.Rows = append(result.Rows, rowErrors...)
+	sort.SliceStable(result.Rows, func(i, j int) bool { return result.Rows[i].Row < result.Rows[j].Row })
+
+	for _, row := range result.Rows {
+		switch row.Status {
+		case StatusImported, StatusWouldImport:
+			result.Imported++
+		case StatusDuplicate:
+			result.Duplicates++
+		case StatusError:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// write stores one line through the expense or income service, so the account
+// balance is updated exactly as for a manually entered transaction.
+func (s *service) write(ctx context.Context, opts Options, t Transaction, categoryID uint, fp string) (uint, error) {
+	title, description := titleFor(t.Description)
+	if t.IsIncome {
+		in := &income.Income{
+			Title:       title,
+			Description: description,
+			Amount:      t.Amount,
+			Date:        t.Date,
+			UserID:      opts.UserID,
+			CategoryID:  categoryID,
+			AccountID:   opts.AccountID,
+			Fingerprint: fp,
+		}
+		if err := s.incomeService.CreateIncome(in); err != nil {
+			return 0, err
+		}
+		return in.ID, nil
+	}
+
+	e := &expense.Expense{
+		Title:       title,
+		Description: description,
+		Amount:      t.Amount,
+		Date:        t.Date,
+		UserID:      opts.UserID,
+		CategoryID:  categoryID,
+		AccountID:   opts.AccountID,
+		Fingerprint: fp,
+	}
+	if err := s.expenseService.CreateExpense(ctx, e); err != nil {
+		return 0, err
+	}
+	return e.ID, nil
+}
+
+// fallbackCategory is the category for an expense line whose statement
+// category is missing or unknown: the one the user's rules pick, or else the
+// default. Rules also run when the expense is created, where they add tags.
+func fallbackCategory(rules []rule.Rule, t Transaction, defaultCategoryID *uint) *uint {
+	if t.IsIncome {
+		return defaultCategoryID
+	}
+	title, description := titleFor(t.Description)
+	out := rule.Evaluate(rules, rule.Candidate{Title: title, Description: description, Amount: t.Amount})
+	if out.CategoryID != nil {
+		return out.CategoryID
+	}
+	return defaultCategoryID
+}
+
+// titleFor derives an expense title from a statement description, keeping the
+// full text as the description when it is too long for a title.
+func titleFor(text string) (string, string) {
+	text = strings.Join(strings.Fields(text), " ")
+	if len([]rune(text)) < 3 {
+		return "Imported transaction", text
+	}
+	runes := []rune(text)
+	if len(runes) > 100 {
+		return string(runes[:100]), string(runes[:min(len(runes), 255)])
+	}
+	return text, ""
+}
+
+// categoryLookup maps lower-cased category names to IDs, separately for
+// expense and income categories, and the IDs of the categories visible to the
+// user to their kind.
+type categoryLookup struct {
+	expense map[string]uint
+	income  map[string]uint
+	kinds   map[uint]string
+}
+
+func (s *service) categoryLookup(userID uint) (*categoryLookup, error) {
+	cats, _, err := s.categoryService.GetAllCategories(userID, utils.Pagination{})
+	if err != nil {
+		return nil, err
+	}
+	lookup := &categoryLookup{expense: map[string]uint{}, income: map[string]uint{}, kinds: map[uint]string{}}
+	for _, c := range cats {
+		lookup.kinds[c.ID] = c.Kind
+		name := strings.ToLower(strings.TrimSpace(c.Name))
+		// User categories win over global ones with the same name.
+		if c.Kind != category.KindIncome {
+			if _, ok := lookup.expense[name]; !ok || !c.IsGlobal {
+				lookup.expense[name] = c.ID
+			}
+		}
+		if c.Kind == category.KindIncome || c.Kind == category.KindBoth {
+			if _, ok := lookup.income[name]; !ok || !c.IsGlobal {
+				lookup.income[name] = c.ID
+			}
+		}
+	}
+	return lookup, nil
+}
+
+// resolve returns the category of a line: the one named by the statement, or
+// else defaultCategoryID when it can hold that kind of line.
+func (l *categoryLookup) resolve(t Transaction, defaultCategoryID *uint) (uint, error) {
+	names, kind := l.expense, category.KindExpense
+	if t.IsIncome {
+		names, kind = l.income, category.KindIncome
+	}
+	if t.CategoryName != "" {
+		if id, ok := names[strings.ToLower(strings.TrimSpace(t.CategoryName))]; ok {
+			return id, nil
+		}
+	}
+	if defaultCategoryID != nil {
+		found := l.kinds[*defaultCategoryID]
+		if found == "" {
+			return 0, fmt.Errorf("%w: %d", category.ErrCategoryNotFound, *defaultCategoryID)
+		}
+		if found != kind && found != category.KindBoth {
+			return 0, fmt.Errorf("%w: %d is an %s category", category.ErrWrongKind, *defaultCategoryID, found)
+		}
+		return *defaultCategoryID, nil
+	}
+	if t.CategoryName != "" {
+		return 0, fmt.Errorf("unknown category %q", t.CategoryName)
+	}
+	return 0, errors.New("no category given and no default category configured")
+}
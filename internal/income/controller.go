@@ -0,0 +1,224 @@
+package income
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/money"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IncomeController struct {
+	service Service
+}
+
+func NewIncomeController(service Service) *IncomeController {
+	return &IncomeController{service: service}
+}
+
+func (ctrl *IncomeController) CreateIncome(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.IncomeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	date, err := utils.ParseDateOrNow(request.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	income := &Income{
+		Title:       request.Title,
+		Description: request.Description,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Date:        date,
+		UserID:      userID,
+		CategoryID:  request.CategoryID,
+		AccountID:   request.AccountID,
+	}
+
+	if err := ctrl.service.CreateIncome(income); err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			response.BadRequest(c, "Invalid account", err.Error())
+			return
+		}
+		if errors.Is(err, category.ErrCategoryNotFound) || errors.Is(err, category.ErrWrongKind) {
+			response.BadRequest(c, "Validation error", gin.H{"category_id": err.Error()})
+			return
+		}
+		if errors.Is(err, currency.ErrNoRate) {
+			response.BadRequest(c, "Could not convert currency", err.Error())
+			return
+		}
+		if errors.Is(err, money.ErrInvalid) {
+			response.BadRequest(c, "Validation error", gin.H{"amount": err.Error()})
+			return
+		}
+		logger.Error("Failed to create income", zap.Error(err))
+		response.InternalServerError(c, "Could not create income", err.Error())
+		return
+	}
+	response.Created(c, "Income created successfully", income)
+}
+
+func (ctrl *IncomeController) GetAllIncomes(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	pagination := utils.NewPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	incomes, page, err := ctrl.service.GetIncomesByUserPaginated(userID, pagination)
+	if err != nil {
+		logger.Error("Failed to retrieve incomes", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve incomes", err.Error())
+		return
+	}
+
+	responseData := pagination.Meta(page)
+	responseData["incomes"] = incomes
+	response.Success(c, http.StatusOK, "Incomes retrieved successfully", responseData)
+}
+
+func (ctrl *IncomeController) GetIncomeByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid income ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid income ID", err.Error())
+		return
+	}
+
+	income, err := ctrl.service.GetIncomeByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve income", zap.Error(err), zap.Int("incomeID", id))
+		response.InternalServerError(c, "Could not retrieve income", err.Error())
+		return
+	}
+	if income == nil {
+		response.NotFound(c, "Income not found", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "Income retrieved successfully", income)
+}
+
+func (ctrl *IncomeController) UpdateIncome(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid income ID", err.Error())
+		return
+	}
+
+	var request dto.IncomeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	existingIncome, err := ctrl.service.GetIncomeByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve income for update", zap.Error(err))
+		response.InternalServerError(c, "Could not retrieve income", err.Error())
+		return
+	}
+	if existingIncome == nil {
+		response.NotFound(c, "Income not found", nil)
+		return
+	}
+
+	if request.Date != "" {
+		date, err := utils.ParseDateOrNow(request.Date)
+		if err != nil {
+			response.BadRequest(c, "Invalid date", err.Error())
+			return
+		}
+		existingIncome.Date = date
+	}
+	existingIncome.Title = request.Title
+	existingIncome.Description = request.Description
+	existingIncome.Amount = request.Amount
+	if request.Currency != "" {
+		existingIncome.Currency = request.Currency
+	}
+	existingIncome.CategoryID = request.CategoryID
+	existingIncome.AccountID = request.AccountID
+
+	if err := ctrl.service.UpdateIncome(existingIncome); err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			response.BadRequest(c, "Invalid account", err.Error())
+			return
+		}
+		if errors.Is(err, category.ErrCategoryNotFound) || errors.Is(err, category.ErrWrongKind) {
+			response.BadRequest(c, "Validation error", gin.H{"category_id": err.Error()})
+			return
+		}
+		if errors.Is(err, currency.ErrNoRate) {
+			response.BadRequest(c, "Could not convert currency", err.Error())
+			return
+		}
+		if errors.Is(err, money.ErrInvalid) {
+			response.BadRequest(c, "Validation error", gin.H{"amount": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrIncomeNotFound) {
+			response.NotFound(c, "Income not found", nil)
+			return
+		}
+		logger.Error("Failed to update income", zap.Error(err), zap.Int("incomeID", id))
+		response.InternalServerError(c, "Could not update income", err.Error())
+		return
+	}
+	response.Updated(c, "Income updated successfully", existingIncome)
+}
+
+func (ctrl *IncomeController) DeleteIncome(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid income ID", err.Error())
+		return
+	}
+
+	if err := ctrl.service.DeleteIncome(uint(id), userID); err != nil {
+		if errors.Is(err, ErrIncomeNotFound) {
+			response.NotFound(c, "Income not found", nil)
+			return
+		}
+		logger.Error("Failed to delete income", zap.Error(err), zap.Int("incomeID", id))
+		response.InternalServerError(c, "Could not delete income", err.Error())
+		return
+	}
+	response.Deleted(c, "Income deleted successfully")
+}
@@ -0,0 +1,49 @@
+package income
+
+import (
+	"trackonomy/internal/account"
+	"trackonomy/internal/currency"
+)
+
+// convert fills in the currency of the income, defaulting to the currency of
+// its account, and works out the amount to credit to the account and the
+// amount in the owner's base currency at the rates of the income date. The
+// amount is first rounded to the minor unit of its currency, and one that
+// rounds to nothing returns an error wrapping money.ErrInvalid. It returns an
+// error wrapping currency.ErrNoRate when a rate is missing.
+func (s *service) convert(accounts account.Repository, income *Income) error {
+	acc, err := accounts.GetByID(income.AccountID, income.UserID)
+	if err != nil {
+		return err
+	}
+	if acc == nil {
+		return account.ErrAccountNotFound
+	}
+	accountCurrency := currency.Normalize(acc.Currency)
+	if accountCurrency == "" {
+		accountCurrency = currency.Default
+	}
+
+	income.Currency = currency.Normalize(income.Currency)
+	if income.Currency == "" {
+		income.Currency = accountCurrency
+	}
+	if income.Amount, err = income.Amount.RoundPositive(income.Currency); err != nil {
+		return err
+	}
+	income.AccountAmount, err = s.currency.Convert(income.Amount, income.Currency, accountCurrency, income.Date)
+	if err != nil {
+		return err
+	}
+
+	base, err := s.currency.BaseCurrency(income.UserID)
+	if err != nil {
+		return err
+	}
+	income.BaseAmount, err = s.currency.Convert(income.Amount, income.Currency, base, income.Date)
+	if err != nil {
+		return err
+	}
+	income.BaseCurrency = base
+	return nil
+}
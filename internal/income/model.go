@@ -0,0 +1,58 @@
+package income
+
+import (
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/money"
+	"trackonomy/internal/user"
+)
+
+// Income represents money coming into one of the user's accounts,
+// such as salary, refunds or interest.
+type Income struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+	Date        time.Time    `json:"date" gorm:"index:idx_income_user_date,priority:2"`
+
+	// Currency is the ISO 4217 code Amount was received in. AccountAmount is
+	// what was credited to the account in the account's currency, and
+	// BaseAmount is Amount in the owner's base currency, which reports add up.
+	// Both are converted at the rates of Date.
+	Currency      string       `json:"currency" gorm:"size:3"`
+	AccountAmount money.Amount `json:"account_amount"`
+	BaseAmount    money.Amount `json:"base_amount"`
+	BaseCurrency  string       `json:"base_currency" gorm:"size:3"`
+
+	UserID uint      `json:"user_id" gorm:"index:idx_income_user_date,priority:1"`
+	User   user.User `json:"-" gorm:"foreignKey:UserID"`
+
+	CategoryID uint               `json:"category_id"`
+	Category   *category.Category `json:"-" gorm:"foreignKey:CategoryID"`
+
+	AccountID uint             `json:"account_id"`
+	Account   *account.Account `json:"-" gorm:"foreignKey:AccountID"`
+
+	// Fingerprint identifies imported statement rows so re-imports skip them.
+	Fingerprint string `json:"-" gorm:"index"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SortFields maps the sort names accepted by the list endpoint to columns.
+var SortFields = map[string]string{
+	"id":          "incomes.id",
+	"title":       "incomes.title",
+	"amount":      "incomes.amount",
+	"date":        "incomes.date",
+	"category_id": "incomes.category_id",
+	"account_id":  "incomes.account_id",
+	"created_at":  "incomes.created_at",
+	"updated_at":  "incomes.updated_at",
+}
+
+// DefaultSort is used when the request does not send ?sort=.
+const DefaultSort = "created_at:desc"
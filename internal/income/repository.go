@@ -0,0 +1,127 @@
+package income
+
+import (
+	"errors"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository defines the methods needed to get and store incomes.
+type Repository interface {
+	Create(income *Income) error
+	GetByID(id, userID uint) (*Income, error)
+	GetByIDForUpdate(id, userID uint) (*Income, error)
+	Update(income *Income) error
+	Delete(id, userID uint) error
+	GetAllByUserPaginated(userID uint, p utils.Pagination) ([]Income, utils.Page, error)
+	ExistingFingerprints(userID, accountID uint, fingerprints []string) (map[string]bool, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new income repository with the given database connection.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// Create adds a new income to the database.
+func (r *repository) Create(income *Income) error {
+	if income == nil {
+		return errors.New("income is nil")
+	}
+	return r.db.Create(income).Error
+}
+
+// GetByID retrieves an income by its ID, ensuring it belongs to the user.
+func (r *repository) GetByID(id, userID uint) (*Income, error) {
+	var income Income
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&income).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &income, nil
+}
+
+// GetByIDForUpdate is like GetByID but locks the row until the surrounding
+// transaction finishes.
+func (r *repository) GetByIDForUpdate(id, userID uint) (*Income, error) {
+	var income Income
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&income).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &income, nil
+}
+
+// Update modifies an existing income in the database.
+func (r *repository) Update(income *Income) error {
+	if income == nil {
+		return errors.New("income is nil")
+	}
+	return r.db.Save(income).Error
+}
+
+// Delete removes an income by its ID, ensuring it belongs to the user.
+func (r *repository) Delete(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid ID")
+	}
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Income{}).Error
+}
+
+func (r *repository) GetAllByUserPaginated(userID uint, p utils.Pagination) ([]Income, utils.Page, error) {
+	query := r.db.Model(&Income{}).
+		Where("user_id = ?", userID)
+
+	if p.Search != "" {
+		searchTerm := "%" + p.Search + "%"
+		query = query.Where("title ILIKE ? OR description ILIKE ?", searchTerm, searchTerm)
+	}
+
+	return utils.FindPage[Income](query, p, "incomes.id")
+}
+
+// ExistingFingerprints returns which of the given import fingerprints the user
+// already has incomes for on the account.
+func (r *repository) ExistingFingerprints(userID, accountID uint, fingerprints []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(fingerprints) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := r.db.Model(&Income{}).
+		Where("user_id = ? AND account_id = ? AND fingerprint IN ?", userID, accountID, fingerprints).
+		Pluck("fingerprint", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range found {
+		existing[f] = true
+	}
+	return existing, nil
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{tx}
+}
@@ -0,0 +1,140 @@
+package income
+
+import (
+	"errors"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrIncomeNotFound is returned when the income to modify does not exist.
+var ErrIncomeNotFound = errors.New("income not found")
+
+type Service interface {
+	CreateIncome(income *Income) error
+	GetIncomeByID(id, userID uint) (*Income, error)
+	UpdateIncome(income *Income) error
+	DeleteIncome(id, userID uint) error
+	GetIncomesByUserPaginated(userID uint, pagination utils.Pagination) ([]Income, utils.Page, error)
+}
+
+type service struct {
+	repo         Repository
+	accountRepo  account.Repository
+	categoryRepo category.Repository
+	currency     currency.Service
+}
+
+func NewService(repo Repository, accountRepo account.Repository, categoryRepo category.Repository,
+	currencies currency.Service) Service {
+	return &service{repo: repo, accountRepo: accountRepo, categoryRepo: categoryRepo, currency: currencies}
+}
+
+// CreateIncome stores the income and credits its account in the same
+// transaction. The category must be visible to the user and hold incomes.
+// Incomes in another currency than their account are converted as in convert.
+func (s *service) CreateIncome(income *Income) error {
+	if income == nil {
+		return errors.New("income cannot be nil")
+	}
+	if err := category.CheckUsable(s.categoryRepo, income.CategoryID, income.UserID, category.KindIncome); err != nil {
+		return err
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		accounts := s.accountRepo.WithTx(tx)
+		if err := s.convert(accounts, income); err != nil {
+			return err
+		}
+		if err := accounts.AdjustBalance(income.AccountID, income.UserID, income.AccountAmount); err != nil {
+			return err
+		}
+		return s.repo.WithTx(tx).Create(income)
+	})
+}
+
+func (s *service) GetIncomeByID(id, userID uint) (*Income, error) {
+	if id == 0 {
+		return nil, errors.New("invalid ID")
+	}
+	return s.repo.GetByID(id, userID)
+}
+
+// UpdateIncome saves the income, debiting the previously stored account amount
+// from the old account and crediting the new one to the (possibly different)
+// new account.
+// A new category is checked as in CreateIncome.
+func (s *service) UpdateIncome(income *Income) error {
+	if income == nil || income.ID == 0 {
+		return errors.New("invalid income")
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		accounts := s.accountRepo.WithTx(tx)
+
+		previous, err := repo.GetByIDForUpdate(income.ID, income.UserID)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			return ErrIncomeNotFound
+		}
+		if income.CategoryID != previous.CategoryID {
+			err := category.CheckUsable(s.categoryRepo, income.CategoryID, income.UserID, category.KindIncome)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := reverse(accounts, previous); err != nil {
+			return err
+		}
+		if err := s.convert(accounts, income); err != nil {
+			return err
+		}
+		if err := accounts.AdjustBalance(income.AccountID, income.UserID, income.AccountAmount); err != nil {
+			return err
+		}
+		return repo.Update(income)
+	})
+}
+
+// DeleteIncome removes the income and debits its amount from the account again.
+func (s *service) DeleteIncome(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid ID")
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		existing, err := repo.GetByIDForUpdate(id, userID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrIncomeNotFound
+		}
+
+		if err := reverse(s.accountRepo.WithTx(tx), existing); err != nil {
+			return err
+		}
+		return repo.Delete(id, userID)
+	})
+}
+
+func (s *service) GetIncomesByUserPaginated(userID uint, pagination utils.Pagination) ([]Income, utils.Page, error) {
+	return s.repo.GetAllByUserPaginated(userID, pagination)
+}
+
+// reverse debits the stored account amount of an income from its account. An account
+// that no longer exists has no balance to restore. Trashed accounts are debited
+// too, so that restoring one brings back the right balance.
+func reverse(accounts account.Repository, income *Income) error {
+	err := accounts.Unscoped().AdjustBalance(income.AccountID, income.UserID, -income.AccountAmount)
+	if errors.Is(err, account.ErrAccountNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,274 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Places is the number of decimal places an Amount keeps, and Unit the
+// Amount of one whole unit of a currency.
+const (
+	Places = 4
+	Unit   = Amount(10000)
+)
+
+// ErrInvalid is wrapped by every error returned from Parse.
+var ErrInvalid = errors.New("invalid amount")
+
+// Amount is an exact sum of money in fixed point, counted in ten-thousandths
+// of the unit of its currency. That leaves room for the currencies with three
+// decimals and for intermediate results, and amounts add up without the drift
+// of floating point. It is stored as NUMERIC and encoded in JSON as a string
+// such as "12.34".
+type Amount int64
+
+// minorUnits lists the ISO 4217 currencies that do not have two decimals.
+var minorUnits = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// Decimals returns the number of decimals amounts in a currency are rounded
+// to. Unknown and empty codes get two.
+func Decimals(code string) int {
+	if d, ok := minorUnits[strings.ToUpper(code)]; ok {
+		return d
+	}
+	return 2
+}
+
+// Parse reads a decimal such as "12.34", "-0.5" or "+1000". It refuses
+// exponents, thousands separators and more than Places decimals rather than
+// guessing.
+func Parse(s string) (Amount, error) {
+	text := strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(text, "-"):
+		negative = true
+		text = text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+
+	whole, frac, _ := strings.Cut(text, ".")
+	if whole == "" && frac == "" || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w %q", ErrInvalid, s)
+	}
+	if len(frac) > Places {
+		return 0, fmt.Errorf("%w %q: more than %d decimals", ErrInvalid, s, Places)
+	}
+
+	var units int64
+	if whole != "" {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || n > math.MaxInt64/int64(Unit) {
+			return 0, fmt.Errorf("%w %q: out of range", ErrInvalid, s)
+		}
+		units = n * int64(Unit)
+	}
+	if frac != "" {
+		n, _ := strconv.ParseInt(frac+strings.Repeat("0", Places-len(frac)), 10, 64)
+		units += n
+	}
+	if negative {
+		units = -units
+	}
+	return Amount(units), nil
+}
+
+// MustParse is like Parse but panics on invalid input. It is meant for
+// constants.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromFloat returns the Amount nearest to f. Use it only where floating point
+// cannot be avoided, such as applying an exchange rate.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * float64(Unit)))
+}
+
+// Float64 returns the amount as a float, for ratios and percentages.
+func (a Amount) Float64() float64 {
+	return float64(a) / float64(Unit)
+}
+
+// Mul multiplies the amount by a factor such as an exchange rate. The result
+// is not rounded to any currency.
+func (a Amount) Mul(factor float64) Amount {
+	return FromFloat(a.Float64() * factor)
+}
+
+// RoundTo rounds the amount to the given number of decimals, halves away from
+// zero.
+func (a Amount) RoundTo(decimals int) Amount {
+	if decimals >= Places {
+		return a
+	}
+	step := int64(math.Pow10(Places - decimals))
+	n := int64(a)
+	half := step / 2
+	if n < 0 {
+		return Amount(-((-n + half) / step * step))
+	}
+	return Amount((n + half) / step * step)
+}
+
+// Round rounds the amount to the minor unit of a currency, e.g. cents for EUR
+// and whole yen for JPY.
+func (a Amount) Round(code string) Amount {
+	return a.RoundTo(Decimals(code))
+}
+
+// RoundPositive rounds an amount entered in a currency to its minor unit. It
+// returns an error wrapping ErrInvalid when nothing is left, such as for
+// 0.001 EUR or 0.4 JPY.
+func (a Amount) RoundPositive(code string) (Amount, error) {
+	rounded := a.Round(code)
+	if rounded <= 0 {
+		return 0, fmt.Errorf("%w %s: less than the smallest unit of %s", ErrInvalid, a, code)
+	}
+	return rounded, nil
+}
+
+// String formats the amount with at least two decimals and no trailing
+// zeros beyond them, e.g. "12.00", "-0.50" or "1.234".
+func (a Amount) String() string {
+	s := a.fixed()
+	for strings.HasSuffix(s, "0") && len(s)-strings.IndexByte(s, '.') > 3 {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// fixed formats the amount with all Places decimals.
+func (a Amount) fixed() string {
+	n := int64(a)
+	sign := ""
+	if n < 0 {
+		sign = "-"
+	}
+	u := uint64(n)
+	if n < 0 {
+		u = uint64(-n)
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, u/uint64(Unit), Places, u%uint64(Unit))
+}
+
+// MarshalJSON encodes the amount as a string so clients do not read it into
+// a float.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a string such as "12.34" and, for older clients, a
+// plain JSON number. null leaves the amount unchanged.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// UnmarshalText parses the amount from text such as "12.34", as Parse does.
+func (a *Amount) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// UnmarshalParam lets gin bind form and query values such as "12.50" as
+// amounts; without it they would be read as a count of ten-thousandths.
+func (a *Amount) UnmarshalParam(param string) error {
+	return a.UnmarshalText([]byte(param))
+}
+
+// Scan reads a NUMERIC column. Columns that are still floating point are
+// rounded to the nearest Amount.
+func (a *Amount) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	case float64:
+		*a = FromFloat(v)
+		return nil
+	case int64:
+		*a = Amount(v) * Unit
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", value)
+	}
+}
+
+// scanText parses a NUMERIC as the database prints it. Averages and other
+// results can have more decimals than an Amount keeps; they are rounded half
+// away from zero.
+func (a *Amount) scanText(s string) error {
+	whole, frac, _ := strings.Cut(s, ".")
+	var carry Amount
+	if len(frac) > Places {
+		if frac[Places] >= '5' {
+			carry = 1
+			if strings.HasPrefix(whole, "-") {
+				carry = -1
+			}
+		}
+		frac = frac[:Places]
+	}
+	text := whole
+	if frac != "" {
+		text += "." + frac
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed + carry
+	return nil
+}
+
+// Value stores the amount as an exact decimal.
+func (a Amount) Value() (driver.Value, error) {
+	return a.fixed(), nil
+}
+
+// GormDataType is the column type of amounts.
+func (Amount) GormDataType() string {
+	return "numeric(19,4)"
+}
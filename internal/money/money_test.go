@@ -0,0 +1,226 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+	}{
+		{"12.34", 123400},
+		{"-0.5", -5000},
+		{"+1000", 10000000},
+		{".25", 2500},
+		{"7.", 70000},
+		{" 0.0001 ", 1},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, in := range []string{"", ".", "-", "1,000", "1e3", "12.34567", "abc", "99999999999999999999"} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalid", in, err)
+		}
+	}
+}
+
+func TestSumIsExact(t *testing.T) {
+	if got := MustParse("0.1") + MustParse("0.2"); got != MustParse("0.3") {
+		t.Errorf("0.1 + 0.2 = %s, want 0.30", got)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{123400, "12.34"},
+		{120000, "12.00"},
+		{-5000, "-0.50"},
+		{12345, "1.2345"},
+		{12340, "1.234"},
+		{0, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in   string
+		code string
+		want string
+	}{
+		{"12.345", "EUR", "12.35"},
+		{"-12.345", "EUR", "-12.35"},
+		{"12.344", "EUR", "12.34"},
+		{"1234.5", "JPY", "1235.00"},
+		{"1.2345", "KWD", "1.235"},
+		{"1.2345", "", "1.23"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Round(tt.code).String(); got != tt.want {
+			t.Errorf("Round(%s, %q) = %s, want %s", tt.in, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRoundPositive(t *testing.T) {
+	tests := []struct {
+		in, code string
+		want     string // "" when the amount is refused
+	}{
+		{"12.345", "EUR", "12.35"},
+		{"12.50", "JPY", "13"},
+		{"1.2345", "KWD", "1.235"},
+		{"0.005", "EUR", "0.01"},
+		{"0.004", "EUR", ""},
+		{"0.4", "JPY", ""},
+		{"-1", "EUR", ""},
+	}
+	for _, tt := range tests {
+		got, err := MustParse(tt.in).RoundPositive(tt.code)
+		if tt.want == "" {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("RoundPositive(%s %s) = %s, %v; want ErrInvalid", tt.in, tt.code, got, err)
+			}
+			continue
+		}
+		if err != nil || got != MustParse(tt.want) {
+			t.Errorf("RoundPositive(%s %s) = %s, %v; want %s", tt.in, tt.code, got, err, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		A Amount  `json:"a"`
+		B Amount  `json:"b"`
+		C *Amount `json:"c"`
+	}
+	if err := json.Unmarshal([]byte(`{"a":"12.34","b":0.1,"c":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 123400 || v.B != 1000 || v.C != nil {
+		t.Errorf("decoded %+v", v)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"a":"12.34","b":"0.10","c":null}` {
+		t.Errorf("encoded %s", out)
+	}
+	if err := json.Unmarshal([]byte(`{"a":"1e3"}`), &v); err == nil {
+		t.Error("exponent was accepted")
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want Amount
+	}{
+		{[]byte("12.3400"), 123400},
+		{"-7", -70000},
+		{"0.33333333", 3333},
+		{"-0.66666666", -6667},
+		{0.1, 1000},
+		{int64(3), 30000},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		var a Amount
+		if err := a.Scan(tt.in); err != nil {
+			t.Errorf("Scan(%v): %v", tt.in, err)
+			continue
+		}
+		if a != tt.want {
+			t.Errorf("Scan(%v) = %d, want %d", tt.in, a, tt.want)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	v, err := MustParse("-12.5").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "-12.5000" {
+		t.Errorf("Value() = %v, want -12.5000", v)
+	}
+}
+
+func TestFormBinding(t *testing.T) {
+	type form struct {
+		Amount  Amount
+		Minimum *Amount `form:"min_amount"`
+	}
+	values := url.Values{"Amount": {"12.50"}, "min_amount": {"12"}}
+	wantAmount, wantMinimum := MustParse("12.50"), MustParse("12")
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for key, v := range values {
+		if err := w.WriteField(key, v[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	multipartReq := httptest.NewRequest(http.MethodPost, "/", &body)
+	multipartReq.Header.Set("Content-Type", w.FormDataContentType())
+
+	formReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(values.Encode()))
+	formReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	queryReq := httptest.NewRequest(http.MethodGet, "/?"+values.Encode(), nil)
+
+	for name, req := range map[string]*http.Request{"multipart": multipartReq, "urlencoded": formReq, "query": queryReq} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+			var got form
+			if err := c.ShouldBind(&got); err != nil {
+				t.Fatalf("ShouldBind() error = %v", err)
+			}
+			if got.Amount != wantAmount || got.Minimum == nil || *got.Minimum != wantMinimum {
+				t.Errorf("bound %s and %v, want %s and %s", got.Amount, got.Minimum, wantAmount, wantMinimum)
+			}
+		})
+	}
+
+	bad := httptest.NewRequest(http.MethodGet, "/?Amount=12,50", nil)
+	var got form
+	if err := binding.Query.Bind(bad, &got); !errors.Is(err, ErrInvalid) {
+		t.Errorf("binding 12,50: error = %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,193 @@
+package recurring
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RecurringController struct {
+	service Service
+}
+
+func NewRecurringController(service Service) *RecurringController {
+	return &RecurringController{service: service}
+}
+
+// CreateRecurring creates a recurring expense template for the caller.
+func (rc *RecurringController) CreateRecurring(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req dto.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid recurring expense data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	rec := &RecurringExpense{UserID: userID, Active: true}
+	if err := applyRequest(rec, req); err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	if err := rc.service.CreateRecurring(rec); err != nil {
+		logger.Error("Failed to create recurring expense", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not create recurring expense", err.Error())
+		return
+	}
+	response.Created(c, "Recurring expense created successfully", rec)
+}
+
+// GetAllRecurring lists the caller's recurring expense templates.
+func (rc *RecurringController) GetAllRecurring(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	recs, err := rc.service.GetAllRecurring(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve recurring expenses", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve recurring expenses", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Recurring expenses retrieved successfully", recs)
+}
+
+// GetRecurringByID retrieves a single recurring expense template.
+func (rc *RecurringController) GetRecurringByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid recurring expense ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid recurring expense ID", nil)
+		return
+	}
+
+	rec, err := rc.service.GetRecurringByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve recurring expense", zap.Error(err), zap.Int("recurringID", id))
+		response.InternalServerError(c, "Failed to retrieve recurring expense", err.Error())
+		return
+	}
+	if rec == nil {
+		response.NotFound(c, "Recurring expense not found", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "Recurring expense retrieved successfully", rec)
+}
+
+// UpdateRecurring changes a recurring expense template and its schedule.
+func (rc *RecurringController) UpdateRecurring(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid recurring expense ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid recurring expense ID", nil)
+		return
+	}
+
+	var req dto.RecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid recurring expense data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	rec, err := rc.service.GetRecurringByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve recurring expense for update", zap.Error(err))
+		response.InternalServerError(c, "Could not retrieve recurring expense", err.Error())
+		return
+	}
+	if rec == nil {
+		response.NotFound(c, "Recurring expense not found", nil)
+		return
+	}
+
+	if err := applyRequest(rec, req); err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	if err := rc.service.UpdateRecurring(rec); err != nil {
+		logger.Error("Failed to update recurring expense", zap.Error(err), zap.Uint("recurringID", rec.ID))
+		response.InternalServerError(c, "Could not update recurring expense", err.Error())
+		return
+	}
+	response.Updated(c, "Recurring expense updated successfully", rec)
+}
+
+// DeleteRecurring removes a recurring expense template. Expenses already created are kept.
+func (rc *RecurringController) DeleteRecurring(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid recurring expense ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid recurring expense ID", nil)
+		return
+	}
+
+	if err := rc.service.DeleteRecurring(uint(id), userID); err != nil {
+		logger.Error("Failed to delete recurring expense", zap.Error(err), zap.Int("recurringID", id))
+		response.InternalServerError(c, "Could not delete recurring expense", err.Error())
+		return
+	}
+	response.Deleted(c, "Recurring expense deleted successfully")
+}
+
+// applyRequest copies the request fields onto the template.
+func applyRequest(rec *RecurringExpense, req dto.RecurringExpenseRequest) error {
+	start, err := time.Parse(utils.DateLayout, req.StartDate)
+	if err != nil {
+		return err
+	}
+	var end *time.Time
+	if req.EndDate != "" {
+		t, err := time.Parse(utils.DateLayout, req.EndDate)
+		if err != nil {
+			return err
+		}
+		end = &t
+	}
+
+	rec.Title = req.Title
+	rec.Description = req.Description
+	rec.Amount = req.Amount
+	rec.CategoryID = req.CategoryID
+	rec.AccountID = req.AccountID
+	rec.Frequency = req.Frequency
+	rec.Interval = req.Interval
+	rec.DayOfMonth = req.DayOfMonth
+	rec.BusinessDay = req.BusinessDay
+	rec.StartDate = start
+	rec.EndDate = end
+	if rec.Interval == 0 {
+		rec.Interval = 1
+	}
+	if rec.BusinessDay == "" {
+		rec.BusinessDay = AdjustNone
+	}
+	if req.Active != nil {
+		rec.Active = *req.Active
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package recurring
+
+import (
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/money"
+)
+
+// Supported schedule frequencies.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// Business day adjustments applied when an occurrence falls on a weekend.
+const (
+	AdjustNone      = "none"
+	AdjustFollowing = "following" // move to the next Monday
+	AdjustPreceding = "preceding" // move to the previous Friday
+)
+
+// LastDayOfMonth can be used as DayOfMonth to always pick the last day of the month.
+const LastDayOfMonth = -1
+
+// RecurringExpense is a template from which real expenses are created when they fall due.
+//
+// The schedule works like a small subset of RRULE: every Interval days, weeks,
+// months or years starting at StartDate. Monthly and yearly schedules can pin the
+// day of the month (or LastDayOfMonth), and BusinessDay shifts occurrences off
+// weekends, so "last business day of the month" is DayOfMonth=-1 with
+// BusinessDay=preceding.
+type RecurringExpense struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	UserID      uint         `json:"user_id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+
+	CategoryID uint               `json:"category_id"`
+	Category   *category.Category `json:"-" gorm:"foreignKey:CategoryID"`
+
+	AccountID uint             `json:"account_id"`
+	Account   *account.Account `json:"-" gorm:"foreignKey:AccountID"`
+
+	Frequency   string     `json:"frequency"`
+	Interval    int        `json:"interval"`
+	DayOfMonth  int        `json:"day_of_month"`
+	BusinessDay string     `json:"business_day"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Active      bool       `json:"active"`
+
+	// OccurrenceCount is the index of the next occurrence to materialize.
+	OccurrenceCount int        `json:"occurrence_count"`
+	NextOccurrence  time.Time  `json:"next_occurrence" gorm:"index"`
+	LastOccurrence  *time.Time `json:"last_occurrence,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
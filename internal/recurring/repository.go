@@ -0,0 +1,86 @@
+package recurring
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository defines the methods needed to get and store recurring templates.
+type Repository interface {
+	Create(r *RecurringExpense) error
+	GetAll(userID uint) ([]RecurringExpense, error)
+	GetByID(id, userID uint) (*RecurringExpense, error)
+	Update(r *RecurringExpense) error
+	Delete(id, userID uint) error
+	GetDue(now time.Time) ([]RecurringExpense, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new recurring expense repository with the given database connection.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create adds a new recurring template to the database.
+func (r *repository) Create(rec *RecurringExpense) error {
+	if rec == nil {
+		return errors.New("recurring expense is nil")
+	}
+	return r.db.Create(rec).Error
+}
+
+// GetAll returns every recurring template owned by the user.
+func (r *repository) GetAll(userID uint) ([]RecurringExpense, error) {
+	var recs []RecurringExpense
+	err := r.db.Where("user_id = ?", userID).Order("next_occurrence asc").Find(&recs).Error
+	if err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+// GetByID fetches a recurring template by ID, ensuring user ownership.
+func (r *repository) GetByID(id, userID uint) (*RecurringExpense, error) {
+	var rec RecurringExpense
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&rec).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Update modifies an existing recurring template.
+func (r *repository) Update(rec *RecurringExpense) error {
+	if rec == nil || rec.ID == 0 {
+		return errors.New("invalid recurring expense")
+	}
+	return r.db.Save(rec).Error
+}
+
+// Delete removes a recurring template. Expenses already created from it are kept.
+func (r *repository) Delete(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid recurring expense ID")
+	}
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&RecurringExpense{}).Error
+}
+
+// GetDue returns all active templates whose next occurrence is on or before now.
+func (r *repository) GetDue(now time.Time) ([]RecurringExpense, error) {
+	var recs []RecurringExpense
+	err := r.db.Where("active = ? AND next_occurrence <= ?", true, now).
+		Order("next_occurrence asc").
+		Find(&recs).Error
+	if err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
@@ -0,0 +1,104 @@
+package recurring
+
+import "time"
+
+// maxScheduleScan bounds the search for an occurrence index so that a bad
+// schedule can never spin forever.
+const maxScheduleScan = 100000
+
+// Occurrence returns the date of the n-th (zero-based) occurrence of the schedule.
+// Dates are always computed from StartDate rather than from the previous
+// occurrence, so clamping "the 31st" to February does not drift later months.
+func (r *RecurringExpense) Occurrence(n int) time.Time {
+	start := dateOnly(r.StartDate)
+	step := n * r.interval()
+
+	var d time.Time
+	switch r.Frequency {
+	case FrequencyDaily:
+		d = start.AddDate(0, 0, step)
+	case FrequencyWeekly:
+		d = start.AddDate(0, 0, 7*step)
+	case FrequencyMonthly:
+		d = monthDay(start.Year(), start.Month()+time.Month(step), r.dayOfMonth())
+	case FrequencyYearly:
+		d = monthDay(start.Year()+step, start.Month(), r.dayOfMonth())
+	default:
+		d = start
+	}
+	return adjustBusinessDay(d, r.BusinessDay)
+}
+
+// IndexOnOrAfter returns the index of the first occurrence that falls on or after t.
+func (r *RecurringExpense) IndexOnOrAfter(t time.Time) int {
+	t = dateOnly(t)
+	for n := 0; n < maxScheduleScan; n++ {
+		if !r.Occurrence(n).Before(t) {
+			return n
+		}
+	}
+	return maxScheduleScan
+}
+
+// Reschedule points the template at its next occurrence: the first one after the
+// last materialized occurrence, or the first one on or after StartDate.
+func (r *RecurringExpense) Reschedule() {
+	from := r.StartDate
+	if r.LastOccurrence != nil && r.LastOccurrence.AddDate(0, 0, 1).After(from) {
+		from = r.LastOccurrence.AddDate(0, 0, 1)
+	}
+	r.OccurrenceCount = r.IndexOnOrAfter(from)
+	r.NextOccurrence = r.Occurrence(r.OccurrenceCount)
+}
+
+// Finished reports whether the given occurrence lies past the end of the schedule.
+func (r *RecurringExpense) Finished(occurrence time.Time) bool {
+	return r.EndDate != nil && occurrence.After(dateOnly(*r.EndDate))
+}
+
+func (r *RecurringExpense) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+func (r *RecurringExpense) dayOfMonth() int {
+	if r.DayOfMonth == 0 {
+		return r.StartDate.Day()
+	}
+	return r.DayOfMonth
+}
+
+// monthDay builds a date for the given day of the month, clamping days that do
+// not exist (e.g. the 31st of April) to the last day of that month.
+func monthDay(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	if day == LastDayOfMonth || day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func adjustBusinessDay(d time.Time, adjust string) time.Time {
+	switch adjust {
+	case AdjustFollowing:
+		for isWeekend(d) {
+			d = d.AddDate(0, 0, 1)
+		}
+	case AdjustPreceding:
+		for isWeekend(d) {
+			d = d.AddDate(0, 0, -1)
+		}
+	}
+	return d
+}
+
+func isWeekend(d time.Time) bool {
+	return d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,132 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrence(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule RecurringExpense
+		want     []time.Time // occurrences 0, 1, 2, ...
+	}{
+		{
+			name:     "daily across a leap day",
+			schedule: RecurringExpense{Frequency: FrequencyDaily, StartDate: date(2024, 2, 28)},
+			want:     []time.Time{date(2024, 2, 28), date(2024, 2, 29), date(2024, 3, 1)},
+		},
+		{
+			name:     "every two weeks",
+			schedule: RecurringExpense{Frequency: FrequencyWeekly, Interval: 2, StartDate: date(2024, 2, 19)},
+			want:     []time.Time{date(2024, 2, 19), date(2024, 3, 4), date(2024, 3, 18)},
+		},
+		{
+			name:     "31st clamps to short months without drifting",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, StartDate: date(2024, 1, 31)},
+			want:     []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31), date(2024, 4, 30), date(2024, 5, 31)},
+		},
+		{
+			name:     "31st in a common year",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, StartDate: date(2023, 1, 31)},
+			want:     []time.Time{date(2023, 1, 31), date(2023, 2, 28), date(2023, 3, 31)},
+		},
+		{
+			name:     "pinned day of month",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, DayOfMonth: 30, StartDate: date(2024, 1, 5)},
+			want:     []time.Time{date(2024, 1, 30), date(2024, 2, 29), date(2024, 3, 30)},
+		},
+		{
+			name:     "last day of month",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, DayOfMonth: LastDayOfMonth, StartDate: date(2024, 1, 15)},
+			want:     []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31), date(2024, 4, 30)},
+		},
+		{
+			name:     "every other month from the 31st",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, Interval: 2, StartDate: date(2024, 12, 31)},
+			want:     []time.Time{date(2024, 12, 31), date(2025, 2, 28), date(2025, 4, 30)},
+		},
+		{
+			name:     "leap day every year",
+			schedule: RecurringExpense{Frequency: FrequencyYearly, StartDate: date(2024, 2, 29)},
+			want:     []time.Time{date(2024, 2, 29), date(2025, 2, 28), date(2026, 2, 28), date(2027, 2, 28), date(2028, 2, 29)},
+		},
+		{
+			name: "last business day, preceding",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, DayOfMonth: LastDayOfMonth,
+				BusinessDay: AdjustPreceding, StartDate: date(2024, 3, 1)},
+			// 31 March 2024 is a Sunday, 30 April a Tuesday.
+			want: []time.Time{date(2024, 3, 29), date(2024, 4, 30)},
+		},
+		{
+			name: "weekend end of month, following",
+			schedule: RecurringExpense{Frequency: FrequencyMonthly, DayOfMonth: LastDayOfMonth,
+				BusinessDay: AdjustFollowing, StartDate: date(2024, 8, 1)},
+			// 31 August 2024 is a Saturday.
+			want: []time.Time{date(2024, 9, 2), date(2024, 9, 30)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for n, want := range tt.want {
+				if got := tt.schedule.Occurrence(n); !got.Equal(want) {
+					t.Errorf("Occurrence(%d) = %s, want %s", n, got.Format(time.DateOnly), want.Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestIndexOnOrAfter(t *testing.T) {
+	r := RecurringExpense{Frequency: FrequencyMonthly, StartDate: date(2024, 1, 31)}
+	tests := []struct {
+		t    time.Time
+		want int
+	}{
+		{date(2023, 12, 1), 0},
+		{date(2024, 1, 31), 0},
+		{date(2024, 2, 1), 1},
+		{date(2024, 2, 29), 1},
+		{date(2024, 3, 1), 2},
+		{time.Date(2024, 3, 31, 18, 30, 0, 0, time.UTC), 2},
+	}
+	for _, tt := range tests {
+		if got := r.IndexOnOrAfter(tt.t); got != tt.want {
+			t.Errorf("IndexOnOrAfter(%s) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestReschedule(t *testing.T) {
+	last := date(2024, 2, 29)
+	r := RecurringExpense{Frequency: FrequencyMonthly, StartDate: date(2024, 1, 31), LastOccurrence: &last}
+	r.Reschedule()
+	if r.OccurrenceCount != 2 || !r.NextOccurrence.Equal(date(2024, 3, 31)) {
+		t.Errorf("after Reschedule: count %d, next %s; want 2, 2024-03-31", r.OccurrenceCount, r.NextOccurrence)
+	}
+
+	// Moving the start date past the last occurrence starts over from it.
+	r.StartDate = date(2024, 6, 30)
+	r.Reschedule()
+	if r.OccurrenceCount != 0 || !r.NextOccurrence.Equal(date(2024, 6, 30)) {
+		t.Errorf("after moving the start: count %d, next %s; want 0, 2024-06-30", r.OccurrenceCount, r.NextOccurrence)
+	}
+}
+
+func TestFinished(t *testing.T) {
+	end := time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC)
+	r := RecurringExpense{EndDate: &end}
+	if r.Finished(date(2024, 2, 29)) {
+		t.Error("an occurrence on the end date is not past it")
+	}
+	if !r.Finished(date(2024, 3, 1)) {
+		t.Error("an occurrence after the end date is past it")
+	}
+	if (&RecurringExpense{}).Finished(date(2100, 1, 1)) {
+		t.Error("a schedule without an end date never finishes")
+	}
+}
@@ -0,0 +1,161 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"time"
+	"trackonomy/internal/expense"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// maxCatchUp limits how many missed occurrences of a single template are created
+// in one run. Anything left over is picked up on the next run.
+const maxCatchUp = 500
+
+type Service interface {
+	CreateRecurring(rec *RecurringExpense) error
+	GetAllRecurring(userID uint) ([]RecurringExpense, error)
+	GetRecurringByID(id, userID uint) (*RecurringExpense, error)
+	UpdateRecurring(rec *RecurringExpense) error
+	DeleteRecurring(id, userID uint) error
+	MaterializeDue(now time.Time) (int, error)
+}
+
+type service struct {
+	repo           Repository
+	expenseRepo    expense.Repository
+	expenseService expense.Service
+}
+
+func NewService(repo Repository, expenseRepo expense.Repository, expenseService expense.Service) Service {
+	return &service{repo: repo, expenseRepo: expenseRepo, expenseService: expenseService}
+}
+
+func (s *service) CreateRecurring(rec *RecurringExpense) error {
+	if rec == nil {
+		return errors.New("recurring expense cannot be nil")
+	}
+	rec.Reschedule()
+	return s.repo.Create(rec)
+}
+
+func (s *service) GetAllRecurring(userID uint) ([]RecurringExpense, error) {
+	return s.repo.GetAll(userID)
+}
+
+func (s *service) GetRecurringByID(id, userID uint) (*RecurringExpense, error) {
+	if id == 0 {
+		return nil, errors.New("invalid recurring expense ID")
+	}
+	return s.repo.GetByID(id, userID)
+}
+
+// UpdateRecurring saves the template and recomputes its next occurrence, so a
+// changed schedule continues after the last expense that was already created.
+func (s *service) UpdateRecurring(rec *RecurringExpense) error {
+	if rec == nil || rec.ID == 0 {
+		return errors.New("invalid recurring expense")
+	}
+	rec.Reschedule()
+	return s.repo.Update(rec)
+}
+
+func (s *service) DeleteRecurring(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid recurring expense ID")
+	}
+	return s.repo.Delete(id, userID)
+}
+
+// MaterializeDue creates expenses for every occurrence that is due at now,
+// including occurrences missed while the server was down. It returns the number
+// of expenses created. A failing template is logged and skipped so it cannot
+// block the others.
+func (s *service) MaterializeDue(now time.Time) (int, error) {
+	due, err := s.repo.GetDue(now)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for i := range due {
+		n, err := s.catchUp(&due[i], now)
+		created += n
+		if err != nil {
+			logger.Error("Failed to materialize recurring expense",
+				zap.Error(err), zap.Uint("recurringID", due[i].ID))
+		}
+	}
+	return created, nil
+}
+
+func (s *service) catchUp(rec *RecurringExpense, now time.Time) (int, error) {
+	created := 0
+	for i := 0; i < maxCatchUp; i++ {
+		occurrence := rec.NextOccurrence
+		if occurrence.After(now) {
+			break
+		}
+		if rec.Finished(occurrence) {
+			rec.Active = false
+			break
+		}
+
+		ok, err := s.materialize(rec, occurrence)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+
+		rec.LastOccurrence = &occurrence
+		rec.OccurrenceCount++
+		rec.NextOccurrence = rec.Occurrence(rec.OccurrenceCount)
+		if err := s.repo.Update(rec); err != nil {
+			return created, err
+		}
+	}
+	if rec.Finished(rec.NextOccurrence) {
+		rec.Active = false
+	}
+	return created, s.repo.Update(rec)
+}
+
+// materialize creates the expense for a single occurrence. It is idempotent:
+// an occurrence that already exists is skipped, and the unique index on
+// (recurring_id, occurrence_date) catches a concurrent run creating it first.
+func (s *service) materialize(rec *RecurringExpense, occurrence time.Time) (bool, error) {
+	exists, err := s.expenseRepo.ExistsOccurrence(rec.ID, occurrence)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	recurringID := rec.ID
+	occurrenceDate := occurrence
+	e := &expense.Expense{
+		Title:          rec.Title,
+		Description:    rec.Description,
+		Amount:         rec.Amount,
+		Date:           occurrence,
+		UserID:         rec.UserID,
+		CategoryID:     rec.CategoryID,
+		AccountID:      rec.AccountID,
+		RecurringID:    &recurringID,
+		OccurrenceDate: &occurrenceDate,
+	}
+	// Occurrences are created by the worker, so the audit log has no user for them.
+	if err := s.expenseService.CreateExpense(context.Background(), e); err != nil {
+		if utils.IsUniqueViolation(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
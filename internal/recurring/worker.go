@@ -0,0 +1,50 @@
+package recurring
+
+import (
+	"context"
+	"time"
+	"trackonomy/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// Worker periodically turns due recurring templates into real expenses.
+type Worker struct {
+	service  Service
+	interval time.Duration
+}
+
+func NewWorker(service Service, interval time.Duration) *Worker {
+	return &Worker{service: service, interval: interval}
+}
+
+// Run materializes due occurrences right away, catching up on anything missed
+// while the server was down, and then again on every tick until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	logger.Info("Recurring expense worker started", zap.Duration("interval", w.interval))
+
+	w.runOnce()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Recurring expense worker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce()
+		}
+	}
+}
+
+func (w *Worker) runOnce() {
+	created, err := w.service.MaterializeDue(time.Now().UTC())
+	if err != nil {
+		logger.Error("Recurring expense run failed", zap.Error(err))
+		return
+	}
+	if created > 0 {
+		logger.Info("Created recurring expenses", zap.Int("count", created))
+	}
+}
@@ -0,0 +1,208 @@
+package report
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ReportController struct {
+	service  Service
+	currency currency.Service
+}
+
+func NewReportController(service Service, currencies currency.Service) *ReportController {
+	return &ReportController{service: service, currency: currencies}
+}
+
+// ByCategory returns spending per category for ?from=&to=.
+func (rc *ReportController) ByCategory(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	rng, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	code, ok := rc.baseCurrency(c, userID)
+	if !ok {
+		return
+	}
+
+	totals, err := rc.service.ByCategory(userID, rng)
+	if err != nil {
+		logger.Error("Failed to build category report", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not build category report", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Category report retrieved successfully", gin.H{
+		"range":    rng,
+		"currency": code,
+		"totals":   totals,
+	})
+}
+
+// ByAccount returns spending per account for ?from=&to=.
+func (rc *ReportController) ByAccount(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	rng, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	code, ok := rc.baseCurrency(c, userID)
+	if !ok {
+		return
+	}
+
+	totals, err := rc.service.ByAccount(userID, rng)
+	if err != nil {
+		logger.Error("Failed to build account report", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not build account report", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Account report retrieved successfully", gin.H{
+		"range":    rng,
+		"currency": code,
+		"totals":   totals,
+	})
+}
+
+// ByTag returns spending per tag for ?from=&to=.
+func (rc *ReportController) ByTag(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	rng, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	code, ok := rc.baseCurrency(c, userID)
+	if !ok {
+		return
+	}
+
+	totals, err := rc.service.ByTag(userID, rng)
+	if err != nil {
+		logger.Error("Failed to build tag report", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not build tag report", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Tag report retrieved successfully", gin.H{
+		"range":    rng,
+		"currency": code,
+		"totals":   totals,
+	})
+}
+
+// ByPeriod returns expenses, incomes and net cash flow per ?interval=day|week|month|year.
+func (rc *ReportController) ByPeriod(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	rng, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	code, ok := rc.baseCurrency(c, userID)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "month")
+
+	totals, err := rc.service.ByPeriod(userID, interval, rng)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInterval) {
+			response.BadRequest(c, "Invalid report parameters", gin.H{"interval": err.Error()})
+			return
+		}
+		logger.Error("Failed to build period report", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not build period report", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Period report retrieved successfully", gin.H{
+		"range":    rng,
+		"currency": code,
+		"interval": interval,
+		"totals":   totals,
+	})
+}
+
+// MonthOverMonth returns each month's spending and its change from the previous month.
+func (rc *ReportController) MonthOverMonth(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	rng, ok := parseRange(c)
+	if !ok {
+		return
+	}
+	code, ok := rc.baseCurrency(c, userID)
+	if !ok {
+		return
+	}
+
+	deltas, err := rc.service.MonthOverMonth(userID, rng)
+	if err != nil {
+		logger.Error("Failed to build month-over-month report", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not build month-over-month report", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Month-over-month report retrieved successfully", gin.H{
+		"range":    rng,
+		"currency": code,
+		"months":   deltas,
+	})
+}
+
+// baseCurrency returns the currency the user's report totals are in. On
+// failure it writes a 500 response and returns false.
+func (rc *ReportController) baseCurrency(c *gin.Context, userID uint) (string, bool) {
+	code, err := rc.currency.BaseCurrency(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve base currency", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve base currency", err.Error())
+		return "", false
+	}
+	return code, true
+}
+
+// parseRange reads ?from= and ?to= (inclusive, YYYY-MM-DD). Without them the
+// report covers the last twelve months up to today. On invalid input it writes
+// a 400 response and returns false.
+func parseRange(c *gin.Context) (Range, bool) {
+	errs := map[string]string{}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today
+	if toStr := c.Query("to"); toStr != "" {
+		t, err := time.Parse(utils.DateLayout, toStr)
+		if err != nil {
+			errs["to"] = "must be a date in YYYY-MM-DD format"
+		}
+		to = t
+	}
+
+	from := monthStart(to).AddDate(0, -11, 0)
+	if fromStr := c.Query("from"); fromStr != "" {
+		t, err := time.Parse(utils.DateLayout, fromStr)
+		if err != nil {
+			errs["from"] = "must be a date in YYYY-MM-DD format"
+		}
+		from = t
+	}
+
+	if len(errs) == 0 && from.After(to) {
+		errs["from"] = "must not be after to"
+	}
+	if len(errs) > 0 {
+		response.BadRequest(c, "Invalid report parameters", errs)
+		return Range{}, false
+	}
+
+	// The range is stored half-open so the whole "to" day is included.
+	return Range{From: from, To: to.AddDate(0, 0, 1)}, true
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"time"
+	"trackonomy/internal/money"
+)
+
+// Range is a half-open date range [From, To) used by every report.
+type Range struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// CategoryTotal is the spending of one category within a range. Total and
+// Count include the spending of all its sub-categories; OwnTotal is what was
+// spent on the category itself.
+type CategoryTotal struct {
+	CategoryID   uint         `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	ParentID     *uint        `json:"parent_id,omitempty"`
+	Total        money.Amount `json:"total"`
+	OwnTotal     money.Amount `json:"own_total"`
+	Count        int64        `json:"count"`
+}
+
+// AccountTotal is the spending from one account within a range.
+type AccountTotal struct {
+	AccountID   uint         `json:"account_id"`
+	AccountName string       `json:"account_name"`
+	Total       money.Amount `json:"total"`
+	Count       int64        `json:"count"`
+}
+
+// TagTotal is the spending on expenses carrying one tag within a range. An
+// expense with several tags counts towards each of them.
+type TagTotal struct {
+	TagID   uint         `json:"tag_id"`
+	TagName string       `json:"tag_name"`
+	Total   money.Amount `json:"total"`
+	Count   int64        `json:"count"`
+}
+
+// PeriodTotal is the money that went out and came in during one period.
+type PeriodTotal struct {
+	Period  time.Time    `json:"period"`
+	Expense money.Amount `json:"expense"`
+	Income  money.Amount `json:"income"`
+	Net     money.Amount `json:"net"`
+}
+
+// MonthDelta compares one month's spending with the month before it.
+type MonthDelta struct {
+	Month         time.Time    `json:"month"`
+	Total         money.Amount `json:"total"`
+	PreviousTotal money.Amount `json:"previous_total"`
+	Delta         money.Amount `json:"delta"`
+	// DeltaPercent is nil when the previous month had no spending.
+	DeltaPercent *float64 `json:"delta_percent"`
+}
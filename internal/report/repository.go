@@ -0,0 +1,109 @@
+package report
+
+import (
+	"trackonomy/internal/category"
+	"trackonomy/internal/expense"
+
+	"gorm.io/gorm"
+)
+
+// Repository runs aggregate queries over expenses and incomes. Everything is
+// grouped in SQL so that large histories never have to be loaded into memory.
+type Repository interface {
+	TotalsByCategory(userID uint, r Range) ([]CategoryTotal, error)
+	TotalsByAccount(userID uint, r Range) ([]AccountTotal, error)
+	TotalsByTag(userID uint, r Range) ([]TagTotal, error)
+	TotalsByPeriod(userID uint, unit string, r Range) ([]PeriodTotal, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// TotalsByCategory sums expenses per category, largest first. Split expenses
+// count each item towards its own category, and the spending of sub-categories
+// is rolled up into every category above them.
+func (r *repository) TotalsByCategory(userID uint, rng Range) ([]CategoryTotal, error) {
+	var totals []CategoryTotal
+	// Expenses whose category no longer exists have no ancestry and stay on their own
+	err := r.db.Table("(?) AS e", expense.CategorySplits(r.db)).
+		Select("COALESCE(t.root, e.category_id) AS category_id, COALESCE(c.name, '') AS category_name, "+
+			"c.parent_id, SUM(e.amount) AS total, "+
+			"SUM(CASE WHEN e.category_id = COALESCE(t.root, e.category_id) THEN e.amount ELSE 0 END) AS own_total, "+
+			"COUNT(DISTINCT e.expense_id) AS count").
+		Joins("LEFT JOIN (?) AS t ON t.id = e.category_id", category.Ancestry(r.db)).
+		Joins("LEFT JOIN categories c ON c.id = COALESCE(t.root, e.category_id)").
+		Where("e.user_id = ? AND e.date >= ? AND e.date < ?", userID, rng.From, rng.To).
+		Group("COALESCE(t.root, e.category_id), c.name, c.parent_id").
+		Order("total DESC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// TotalsByAccount sums expenses per account, largest first. Like every report
+// total, it is in the user's base currency.
+func (r *repository) TotalsByAccount(userID uint, rng Range) ([]AccountTotal, error) {
+	var totals []AccountTotal
+	err := r.db.Table("expenses AS e").
+		Select("e.account_id, COALESCE(a.name, '') AS account_name, SUM(e.base_amount) AS total, COUNT(*) AS count").
+		Joins("LEFT JOIN accounts a ON a.id = e.account_id").
+		Where("e.user_id = ? AND e.deleted_at IS NULL AND e.date >= ? AND e.date < ?", userID, rng.From, rng.To).
+		Group("e.account_id, a.name").
+		Order("total DESC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// TotalsByTag sums expenses per tag, largest first. Untagged expenses are left out.
+func (r *repository) TotalsByTag(userID uint, rng Range) ([]TagTotal, error) {
+	var totals []TagTotal
+	err := r.db.Table("expense_tags AS et").
+		Select("t.id AS tag_id, t.name AS tag_name, SUM(e.base_amount) AS total, COUNT(*) AS count").
+		Joins("JOIN expenses e ON e.id = et.expense_id").
+		Joins("JOIN tags t ON t.id = et.tag_id").
+		Where("e.user_id = ? AND e.deleted_at IS NULL AND e.date >= ? AND e.date < ?", userID, rng.From, rng.To).
+		Group("t.id, t.name").
+		Order("total DESC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// TotalsByPeriod sums expenses and incomes per period. unit is a PostgreSQL
+// date_trunc unit: "day", "week", "month" or "year". Periods without any
+// transactions are not returned. Expenses and incomes are converted to the
+// base currency.
+func (r *repository) TotalsByPeriod(userID uint, unit string, rng Range) ([]PeriodTotal, error) {
+	var totals []PeriodTotal
+	err := r.db.Raw(`
+		SELECT period, SUM(expense) AS expense, SUM(income) AS income, SUM(income) - SUM(expense) AS net
+		FROM (
+			SELECT date_trunc(@unit, date AT TIME ZONE 'UTC') AS period, base_amount AS expense, 0 AS income
+			FROM expenses
+			WHERE user_id = @user AND deleted_at IS NULL AND date >= @from AND date < @to
+			UNION ALL
+			SELECT date_trunc(@unit, date AT TIME ZONE 'UTC') AS period, 0 AS expense, base_amount AS income
+			FROM incomes
+			WHERE user_id = @user AND date >= @from AND date < @to
+		) t
+		GROUP BY period
+		ORDER BY period`,
+		map[string]interface{}{"unit": unit, "user": userID, "from": rng.From, "to": rng.To},
+	).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
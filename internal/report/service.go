@@ -0,0 +1,91 @@
+package report
+
+import (
+	"errors"
+	"time"
+	"trackonomy/internal/money"
+)
+
+// Intervals supported by the time series report.
+var intervals = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+	"year":  true,
+}
+
+// ErrInvalidInterval is returned for an interval other than day, week, month or year.
+var ErrInvalidInterval = errors.New("interval must be one of day, week, month, year")
+
+type Service interface {
+	ByCategory(userID uint, r Range) ([]CategoryTotal, error)
+	ByAccount(userID uint, r Range) ([]AccountTotal, error)
+	ByTag(userID uint, r Range) ([]TagTotal, error)
+	ByPeriod(userID uint, interval string, r Range) ([]PeriodTotal, error)
+	MonthOverMonth(userID uint, r Range) ([]MonthDelta, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) ByCategory(userID uint, r Range) ([]CategoryTotal, error) {
+	return s.repo.TotalsByCategory(userID, r)
+}
+
+func (s *service) ByAccount(userID uint, r Range) ([]AccountTotal, error) {
+	return s.repo.TotalsByAccount(userID, r)
+}
+
+func (s *service) ByTag(userID uint, r Range) ([]TagTotal, error) {
+	return s.repo.TotalsByTag(userID, r)
+}
+
+func (s *service) ByPeriod(userID uint, interval string, r Range) ([]PeriodTotal, error) {
+	if !intervals[interval] {
+		return nil, ErrInvalidInterval
+	}
+	return s.repo.TotalsByPeriod(userID, interval, r)
+}
+
+// MonthOverMonth returns the spending of every month in the range together with
+// its change from the month before. Months without expenses count as zero.
+func (s *service) MonthOverMonth(userID uint, r Range) ([]MonthDelta, error) {
+	first := monthStart(r.From)
+	// Include the month before the range so the first month has something to compare with.
+	totals, err := s.repo.TotalsByPeriod(userID, "month", Range{From: first.AddDate(0, -1, 0), To: r.To})
+	if err != nil {
+		return nil, err
+	}
+	byMonth := make(map[time.Time]money.Amount, len(totals))
+	for _, t := range totals {
+		byMonth[t.Period.UTC()] = t.Expense
+	}
+
+	var deltas []MonthDelta
+	previous := byMonth[first.AddDate(0, -1, 0)]
+	for m := first; m.Before(r.To); m = m.AddDate(0, 1, 0) {
+		d := MonthDelta{
+			Month:         m,
+			Total:         byMonth[m],
+			PreviousTotal: previous,
+		}
+		d.Delta = d.Total - d.PreviousTotal
+		if d.PreviousTotal != 0 {
+			pct := d.Delta.Float64() / d.PreviousTotal.Float64() * 100
+			d.DeltaPercent = &pct
+		}
+		deltas = append(deltas, d)
+		previous = d.Total
+	}
+	return deltas, nil
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
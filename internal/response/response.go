@@ -93,3 +93,8 @@ func Deleted(c *gin.Context, message string) {
 func NotFound(c *gin.Context, message string, err interface{}) {
 	Error(c, http.StatusNotFound, message, err)
 }
+
+// Forbidden is a convenience function for returning a 403 response
+func Forbidden(c *gin.Context, message string, err interface{}) {
+	Error(c, http.StatusForbidden, message, err)
+}
@@ -3,9 +3,22 @@ package internal
 import (
 	"trackonomy/config"
 	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
 	"trackonomy/internal/auth"
+	"trackonomy/internal/budget"
 	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
 	"trackonomy/internal/expense"
+	"trackonomy/internal/group"
+	"trackonomy/internal/importer"
+	"trackonomy/internal/income"
+	"trackonomy/internal/recurring"
+	"trackonomy/internal/report"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/sharing"
+	"trackonomy/internal/tag"
+	"trackonomy/internal/transfer"
+	"trackonomy/internal/trash"
 	"trackonomy/internal/upload"
 	"trackonomy/internal/user"
 
@@ -21,25 +34,91 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		panic("Failed to create Cloudinary service: " + err.Error())
 	}
 
+	router.Use(audit.RequestIDMiddleware())
+
+	// ====== Audit Setup ======
+	auditRepo := audit.NewRepository(db)
+
 	// ====== User Setup ======
 	userRepo := user.NewRepository(db)
 	userService := user.NewService(userRepo)
 	userController := user.NewUserController(userService)
 
+	// ====== Group Setup ======
+	groupRepo := group.NewRepository(db)
+	groupService := group.NewService(groupRepo, userRepo)
+	groupController := group.NewGroupController(groupService)
+
+	// ====== Currency Setup ======
+	currencyRepo := currency.NewRepository(db)
+	currencyService := currency.NewService(currencyRepo)
+	currencyController := currency.NewCurrencyController(currencyService)
+
 	// ====== Category Setup ======
 	categoryRepo := category.NewRepository(db)
-	categoryService := category.NewService(categoryRepo)
+	categoryService := category.NewService(categoryRepo, groupService, auditRepo)
 	categoryController := category.NewCategoryController(categoryService)
 
+	// ====== Account Setup ====== (NEW)
+	accountRepo := account.NewRepository(db)
+	accountService := account.NewService(accountRepo, groupService, auditRepo, currencyService)
+	accountController := account.NewAccountController(accountService)
+
+	// ====== Tag Setup ======
+	tagRepo := tag.NewRepository(db)
+	tagService := tag.NewService(tagRepo)
+	tagController := tag.NewTagController(tagService)
+
+	// ====== Rule Setup ======
+	ruleRepo := rule.NewRepository(db)
+	ruleService := rule.NewService(ruleRepo, categoryRepo, accountRepo)
+	ruleController := rule.NewRuleController(ruleService)
+
 	// ====== Expense Setup ======
 	expenseRepo := expense.NewRepository(db)
-	expenseService := expense.NewService(expenseRepo)
+	expenseService := expense.NewService(expenseRepo, accountRepo, categoryRepo, tagRepo, ruleRepo, groupService,
+		auditRepo, currencyService)
 	expenseController := expense.NewExpenseController(expenseService, uploadService)
 
-	// ====== Account Setup ====== (NEW)
-	accountRepo := account.NewRepository(db)
-	accountService := account.NewService(accountRepo)
-	accountController := account.NewAccountController(accountService)
+	// ====== Income Setup ======
+	incomeRepo := income.NewRepository(db)
+	incomeService := income.NewService(incomeRepo, accountRepo, categoryRepo, currencyService)
+	incomeController := income.NewIncomeController(incomeService)
+
+	// ====== Transfer Setup ======
+	transferRepo := transfer.NewRepository(db)
+	transferService := transfer.NewService(transferRepo, accountRepo, currencyService)
+	transferController := transfer.NewTransferController(transferService)
+
+	// ====== Recurring Expense Setup ======
+	recurringRepo := recurring.NewRepository(db)
+	recurringService := recurring.NewService(recurringRepo, expenseRepo, expenseService)
+	recurringController := recurring.NewRecurringController(recurringService)
+
+	// ====== Budget Setup ======
+	budgetRepo := budget.NewRepository(db)
+	budgetService := budget.NewService(budgetRepo, expenseRepo)
+	budgetController := budget.NewBudgetController(budgetService)
+
+	// ====== Report Setup ======
+	reportRepo := report.NewRepository(db)
+	reportService := report.NewService(reportRepo)
+	reportController := report.NewReportController(reportService, currencyService)
+
+	// ====== Import Setup ======
+	importRepo := importer.NewRepository(db)
+	importService := importer.NewService(importRepo, accountRepo, categoryService,
+		expenseRepo, expenseService, incomeRepo, incomeService, ruleRepo)
+	importController := importer.NewImportController(importService)
+
+	// ====== Sharing Setup ======
+	sharingRepo := sharing.NewRepository(db)
+	sharingService := sharing.NewService(sharingRepo, expenseRepo, userRepo, groupService, currencyService)
+	sharingController := sharing.NewSharingController(sharingService)
+
+	// ====== Trash Setup ======
+	trashService := trash.NewService(expenseService, accountService, categoryService)
+	trashController := trash.NewTrashController(trashService)
 
 	// ====== API Routes ======
 	api := router.Group("/api")
@@ -56,17 +135,56 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		// These routes do not require authentication.
 		categoryRoutes := api.Group("/categories")
 		{
-			categoryRoutes.POST("/global", categoryController.CreateGlobalCategory)
 			categoryRoutes.GET("/global", categoryController.GetAllGlobalCategories)
 		}
 
 		// ====== Public Account Endpoints (NEW) ======
 		accountRoutes := api.Group("/accounts")
 		{
-			accountRoutes.POST("/global", accountController.CreateGlobalAccount)
 			accountRoutes.GET("/global", accountController.GetAllGlobalAccounts)
 		}
 
+		// ====== Admin Endpoints ======
+		// These routes require a valid JWT token of an administrator.
+		admin := api.Group("/admin")
+		admin.Use(auth.AuthMiddleware(), auth.RequireRole(userService, user.RoleAdmin))
+		{
+			// ----- Global Category Endpoints -----
+			adminCategoryRoutes := admin.Group("/categories")
+			{
+				adminCategoryRoutes.POST("/", categoryController.CreateGlobalCategory)
+				adminCategoryRoutes.GET("/", categoryController.GetAllGlobalCategories)
+				adminCategoryRoutes.PUT("/:id", categoryController.UpdateGlobalCategory)
+				adminCategoryRoutes.DELETE("/:id", categoryController.DeleteGlobalCategory)
+			}
+
+			// ----- Global Account Endpoints -----
+			adminAccountRoutes := admin.Group("/accounts")
+			{
+				adminAccountRoutes.POST("/", accountController.CreateGlobalAccount)
+				adminAccountRoutes.GET("/", accountController.GetAllGlobalAccounts)
+				adminAccountRoutes.PUT("/:id", accountController.UpdateGlobalAccount)
+				adminAccountRoutes.DELETE("/:id", accountController.DeleteGlobalAccount)
+				adminAccountRoutes.GET("/trash", accountController.GetDeletedGlobalAccounts)
+				adminAccountRoutes.POST("/:id/restore", accountController.RestoreGlobalAccount)
+				adminAccountRoutes.GET("/:id/history", accountController.GetGlobalAccountHistory)
+			}
+
+			// ----- User Management Endpoints -----
+			adminUserRoutes := admin.Group("/users")
+			{
+				adminUserRoutes.GET("/", userController.GetAllUsers)
+				adminUserRoutes.GET("/:id", userController.GetUserByID)
+				adminUserRoutes.PUT("/:id/role", userController.SetUserRole)
+			}
+
+			// ----- Exchange Rate Endpoints -----
+			adminRateRoutes := admin.Group("/exchange-rates")
+			{
+				adminRateRoutes.POST("/import", currencyController.ImportRates)
+			}
+		}
+
 		// ====== Protected Endpoints (JWT middleware) ======
 		// These routes require a valid JWT token.
 		protected := api.Group("/")
@@ -76,6 +194,30 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			userRoutes := protected.Group("/user")
 			{
 				userRoutes.GET("/profile", userController.GetProfile)
+				userRoutes.GET("/base-currency", currencyController.GetBaseCurrency)
+				userRoutes.PUT("/base-currency", currencyController.SetBaseCurrency)
+			}
+
+			// ----- Group Endpoints -----
+			groupRoutes := protected.Group("/groups")
+			{
+				groupRoutes.POST("/", groupController.CreateGroup)
+				groupRoutes.GET("/", groupController.GetAllGroups)
+				groupRoutes.GET("/:id", groupController.GetGroupByID)
+				groupRoutes.PUT("/:id", groupController.UpdateGroup)
+				groupRoutes.DELETE("/:id", groupController.DeleteGroup)
+				groupRoutes.GET("/:id/members", groupController.GetMembers)
+				groupRoutes.PUT("/:id/members/:userId", groupController.UpdateMemberRole)
+				groupRoutes.DELETE("/:id/members/:userId", groupController.RemoveMember)
+				groupRoutes.POST("/:id/invitations", groupController.InviteMember)
+				groupRoutes.GET("/:id/invitations", groupController.GetGroupInvitations)
+				groupRoutes.DELETE("/:id/invitations/:invitationId", groupController.RevokeInvitation)
+			}
+			invitationRoutes := protected.Group("/invitations")
+			{
+				invitationRoutes.GET("/", groupController.GetMyInvitations)
+				invitationRoutes.POST("/:id/accept", groupController.AcceptInvitation)
+				invitationRoutes.POST("/:id/decline", groupController.DeclineInvitation)
 			}
 
 			// ----- Protected Category Endpoints -----
@@ -83,9 +225,13 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			{
 				protectedCategoryRoutes.POST("/", categoryController.CreateCategory)
 				protectedCategoryRoutes.GET("/", categoryController.GetAllCategories)
+				protectedCategoryRoutes.POST("/merge", categoryController.MergeCategories)
 				protectedCategoryRoutes.GET("/:id", categoryController.GetCategoryByID)
 				protectedCategoryRoutes.PUT("/:id", categoryController.UpdateCategory)
+				protectedCategoryRoutes.PUT("/:id/move", categoryController.MoveCategory)
 				protectedCategoryRoutes.DELETE("/:id", categoryController.DeleteCategory)
+				protectedCategoryRoutes.GET("/:id/history", categoryController.GetCategoryHistory)
+				protectedCategoryRoutes.POST("/:id/history/:entryId/revert", categoryController.RevertCategory)
 			}
 
 			// ----- Expense Endpoints -----
@@ -93,9 +239,120 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			{
 				expenseRoutes.POST("/", expenseController.CreateExpense)
 				expenseRoutes.GET("/", expenseController.GetAllExpenses)
+				expenseRoutes.GET("/export", expenseController.ExportExpenses)
 				expenseRoutes.GET("/:id", expenseController.GetExpenseByID)
 				expenseRoutes.PUT("/:id", expenseController.UpdateExpense)
 				expenseRoutes.DELETE("/:id", expenseController.DeleteExpense)
+				expenseRoutes.GET("/:id/history", expenseController.GetExpenseHistory)
+				expenseRoutes.POST("/:id/history/:entryId/revert", expenseController.RevertExpense)
+				expenseRoutes.GET("/:id/shares", sharingController.GetShares)
+				expenseRoutes.PUT("/:id/shares", sharingController.SplitExpense)
+				expenseRoutes.DELETE("/:id/shares", sharingController.RemoveShares)
+			}
+
+			// ----- Tag Endpoints -----
+			tagRoutes := protected.Group("/tags")
+			{
+				tagRoutes.POST("/", tagController.CreateTag)
+				tagRoutes.GET("/", tagController.GetAllTags)
+				tagRoutes.POST("/merge", tagController.MergeTags)
+				tagRoutes.GET("/:id", tagController.GetTagByID)
+				tagRoutes.PUT("/:id", tagController.RenameTag)
+				tagRoutes.DELETE("/:id", tagController.DeleteTag)
+			}
+
+			// ----- Auto-categorization Rule Endpoints -----
+			ruleRoutes := protected.Group("/rules")
+			{
+				ruleRoutes.POST("/", ruleController.CreateRule)
+				ruleRoutes.GET("/", ruleController.GetAllRules)
+				ruleRoutes.GET("/:id", ruleController.GetRuleByID)
+				ruleRoutes.PUT("/:id", ruleController.UpdateRule)
+				ruleRoutes.DELETE("/:id", ruleController.DeleteRule)
+				ruleRoutes.POST("/:id/dry-run", expenseController.DryRunRule)
+				ruleRoutes.POST("/:id/apply", expenseController.ApplyRule)
+			}
+
+			// ----- Shared Expense Balance & Settlement Endpoints -----
+			balanceRoutes := protected.Group("/balances")
+			{
+				balanceRoutes.GET("/", sharingController.GetBalances)
+				balanceRoutes.GET("/simplify", sharingController.SimplifyDebts)
+			}
+			settlementRoutes := protected.Group("/settlements")
+			{
+				settlementRoutes.POST("/", sharingController.CreateSettlement)
+				settlementRoutes.GET("/", sharingController.GetSettlements)
+				settlementRoutes.DELETE("/:id", sharingController.DeleteSettlement)
+			}
+
+			// ----- Recurring Expense Endpoints -----
+			recurringRoutes := protected.Group("/recurring-expenses")
+			{
+				recurringRoutes.POST("/", recurringController.CreateRecurring)
+				recurringRoutes.GET("/", recurringController.GetAllRecurring)
+				recurringRoutes.GET("/:id", recurringController.GetRecurringByID)
+				recurringRoutes.PUT("/:id", recurringController.UpdateRecurring)
+				recurringRoutes.DELETE("/:id", recurringController.DeleteRecurring)
+			}
+
+			// ----- Budget Endpoints -----
+			budgetRoutes := protected.Group("/budgets")
+			{
+				budgetRoutes.POST("/", budgetController.CreateBudget)
+				budgetRoutes.GET("/", budgetController.GetAllBudgets)
+				budgetRoutes.GET("/:id", budgetController.GetBudgetByID)
+				budgetRoutes.GET("/:id/utilization", budgetController.GetBudgetUtilization)
+				budgetRoutes.PUT("/:id", budgetController.UpdateBudget)
+				budgetRoutes.DELETE("/:id", budgetController.DeleteBudget)
+			}
+
+			// ----- Exchange Rate Endpoints -----
+			rateRoutes := protected.Group("/exchange-rates")
+			{
+				rateRoutes.GET("/", currencyController.GetRates)
+			}
+
+			// ----- Report Endpoints -----
+			reportRoutes := protected.Group("/reports")
+			{
+				reportRoutes.GET("/by-category", reportController.ByCategory)
+				reportRoutes.GET("/by-account", reportController.ByAccount)
+				reportRoutes.GET("/by-tag", reportController.ByTag)
+				reportRoutes.GET("/by-period", reportController.ByPeriod)
+				reportRoutes.GET("/month-over-month", reportController.MonthOverMonth)
+			}
+
+			// ----- Income Endpoints -----
+			incomeRoutes := protected.Group("/incomes")
+			{
+				incomeRoutes.POST("/", incomeController.CreateIncome)
+				incomeRoutes.GET("/", incomeController.GetAllIncomes)
+				incomeRoutes.GET("/:id", incomeController.GetIncomeByID)
+				incomeRoutes.PUT("/:id", incomeController.UpdateIncome)
+				incomeRoutes.DELETE("/:id", incomeController.DeleteIncome)
+			}
+
+			// ----- Transfer Endpoints -----
+			transferRoutes := protected.Group("/transfers")
+			{
+				transferRoutes.POST("/", transferController.CreateTransfer)
+				transferRoutes.GET("/", transferController.GetAllTransfers)
+				transferRoutes.GET("/:id", transferController.GetTransferByID)
+				transferRoutes.DELETE("/:id", transferController.DeleteTransfer)
+			}
+
+			// ----- Import Endpoints -----
+			importRoutes := protected.Group("/imports")
+			{
+				importRoutes.POST("/csv", importController.ImportCSV)
+				importRoutes.POST("/ofx", importController.ImportOFX)
+				importRoutes.POST("/qif", importController.ImportQIF)
+				importRoutes.POST("/profiles", importController.CreateProfile)
+				importRoutes.GET("/profiles", importController.GetAllProfiles)
+				importRoutes.GET("/profiles/:id", importController.GetProfileByID)
+				importRoutes.PUT("/profiles/:id", importController.UpdateProfile)
+				importRoutes.DELETE("/profiles/:id", importController.DeleteProfile)
 			}
 
 			// ----- Protected Account Endpoints (NEW) -----
@@ -106,6 +363,15 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 				protectedAccountRoutes.GET("/:id", accountController.GetAccountByID)
 				protectedAccountRoutes.PUT("/:id", accountController.UpdateAccount)
 				protectedAccountRoutes.DELETE("/:id", accountController.DeleteAccount)
+				protectedAccountRoutes.GET("/:id/history", accountController.GetAccountHistory)
+				protectedAccountRoutes.POST("/:id/history/:entryId/revert", accountController.RevertAccount)
+			}
+
+			// ----- Trash Endpoints -----
+			trashRoutes := protected.Group("/trash")
+			{
+				trashRoutes.GET("/", trashController.GetTrash)
+				trashRoutes.POST("/:type/:id/restore", trashController.Restore)
 			}
 		}
 	}
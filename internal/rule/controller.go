@@ -0,0 +1,172 @@
+package rule
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RuleController struct {
+	service Service
+}
+
+func NewRuleController(service Service) *RuleController {
+	return &RuleController{service: service}
+}
+
+// CreateRule creates an auto-categorization rule for the caller.
+func (ctrl *RuleController) CreateRule(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	request, ok := bindRequest(c)
+	if !ok {
+		return
+	}
+
+	rule := ruleFromRequest(request)
+	rule.UserID = userID
+	if err := ctrl.service.CreateRule(rule); err != nil {
+		respondError(c, err, "Could not create rule")
+		return
+	}
+	response.Created(c, "Rule created successfully", rule)
+}
+
+// GetAllRules lists the caller's rules in the order they run.
+func (ctrl *RuleController) GetAllRules(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	rules, err := ctrl.service.GetRules(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve rules", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve rules", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Rules retrieved successfully", rules)
+}
+
+// GetRuleByID retrieves one of the caller's rules.
+func (ctrl *RuleController) GetRuleByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := ctrl.service.GetRule(id, userID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve rule")
+		return
+	}
+	response.Success(c, http.StatusOK, "Rule retrieved successfully", rule)
+}
+
+// UpdateRule replaces one of the caller's rules.
+func (ctrl *RuleController) UpdateRule(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest(c)
+	if !ok {
+		return
+	}
+
+	rule := ruleFromRequest(request)
+	rule.ID = id
+	rule.UserID = userID
+	if err := ctrl.service.UpdateRule(rule); err != nil {
+		respondError(c, err, "Could not update rule")
+		return
+	}
+	response.Updated(c, "Rule updated successfully", rule)
+}
+
+// DeleteRule removes one of the caller's rules.
+func (ctrl *RuleController) DeleteRule(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteRule(id, userID); err != nil {
+		respondError(c, err, "Could not delete rule")
+		return
+	}
+	response.Deleted(c, "Rule deleted successfully")
+}
+
+func bindRequest(c *gin.Context) (dto.RuleRequest, bool) {
+	var request dto.RuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return request, false
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return request, false
+	}
+	return request, true
+}
+
+func ruleFromRequest(request dto.RuleRequest) *Rule {
+	enabled := true
+	if request.Enabled != nil {
+		enabled = *request.Enabled
+	}
+	return &Rule{
+		Name:       request.Name,
+		Priority:   request.Priority,
+		Enabled:    enabled,
+		Field:      request.Field,
+		MatchType:  request.MatchType,
+		Pattern:    request.Pattern,
+		MinAmount:  request.MinAmount,
+		MaxAmount:  request.MaxAmount,
+		CategoryID: request.CategoryID,
+		AccountID:  request.AccountID,
+		Tags:       request.Tags,
+	}
+}
+
+// paramID reads the rule ID from the path, answering 400 when it is invalid.
+func paramID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondError maps the errors of the rule service to responses.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound):
+		response.NotFound(c, "Rule not found", nil)
+	case errors.Is(err, ErrInvalidRule):
+		response.BadRequest(c, "Invalid rule", err.Error())
+	case errors.Is(err, category.ErrCategoryNotFound), errors.Is(err, category.ErrWrongKind):
+		response.BadRequest(c, "Validation error", gin.H{"category_id": err.Error()})
+	case errors.Is(err, account.ErrAccountNotFound):
+		response.BadRequest(c, "Validation error", gin.H{"account_id": err.Error()})
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,126 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"trackonomy/internal/money"
+)
+
+// ErrInvalidRule is wrapped by every validation error of a rule.
+var ErrInvalidRule = errors.New("invalid rule")
+
+// Candidate is the part of a transaction that rules look at.
+type Candidate struct {
+	Title       string
+	Description string
+	Amount      money.Amount
+}
+
+// Outcome is what the matching rules decided for a candidate. For the category
+// and the account the first matching rule that sets them wins; the tags of all
+// matching rules are combined.
+type Outcome struct {
+	CategoryID *uint    `json:"category_id,omitempty"`
+	AccountID  *uint    `json:"account_id,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	RuleIDs    []uint   `json:"rule_ids,omitempty"`
+}
+
+// Evaluate runs the rules, which must be sorted by priority, against c.
+func Evaluate(rules []Rule, c Candidate) Outcome {
+	var out Outcome
+	for i := range rules {
+		r := &rules[i]
+		if !r.Enabled || !r.Matches(c) {
+			continue
+		}
+		out.RuleIDs = append(out.RuleIDs, r.ID)
+		if out.CategoryID == nil && r.CategoryID != nil {
+			out.CategoryID = r.CategoryID
+		}
+		if out.AccountID == nil && r.AccountID != nil {
+			out.AccountID = r.AccountID
+		}
+		out.Tags = append(out.Tags, r.Tags...)
+	}
+	return out
+}
+
+// Matches reports whether every condition of the rule holds for c. Text
+// conditions ignore case.
+func (r *Rule) Matches(c Candidate) bool {
+	if r.MinAmount != nil && c.Amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && c.Amount > *r.MaxAmount {
+		return false
+	}
+	if r.MatchType == "" {
+		return true
+	}
+
+	var texts []string
+	switch r.Field {
+	case FieldTitle:
+		texts = []string{c.Title}
+	case FieldDescription:
+		texts = []string{c.Description}
+	default:
+		texts = []string{c.Title, c.Description}
+	}
+	for _, text := range texts {
+		if r.matchText(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchText(text string) bool {
+	switch r.MatchType {
+	case MatchContains:
+		return strings.Contains(strings.ToLower(text), strings.ToLower(r.Pattern))
+	case MatchRegex:
+		if r.re == nil {
+			re, err := compile(r.Pattern)
+			if err != nil {
+				return false
+			}
+			r.re = re
+		}
+		return r.re.MatchString(text)
+	}
+	return false
+}
+
+// Validate checks that the rule has at least one condition and one action, and
+// that its pattern and amount range make sense.
+func (r *Rule) Validate() error {
+	hasText := r.MatchType != ""
+	if !hasText && r.MinAmount == nil && r.MaxAmount == nil {
+		return fmt.Errorf("%w: needs a text pattern or an amount range", ErrInvalidRule)
+	}
+	if r.CategoryID == nil && r.AccountID == nil && len(r.Tags) == 0 {
+		return fmt.Errorf("%w: needs a category, an account or tags to set", ErrInvalidRule)
+	}
+	if hasText && strings.TrimSpace(r.Pattern) == "" {
+		return fmt.Errorf("%w: pattern must not be empty", ErrInvalidRule)
+	}
+	if r.MatchType == MatchRegex {
+		re, err := compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: pattern is not a valid regular expression: %v", ErrInvalidRule, err)
+		}
+		r.re = re
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && *r.MinAmount > *r.MaxAmount {
+		return fmt.Errorf("%w: min_amount must not be greater than max_amount", ErrInvalidRule)
+	}
+	return nil
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
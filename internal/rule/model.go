@@ -0,0 +1,47 @@
+package rule
+
+import (
+	"regexp"
+	"time"
+	"trackonomy/internal/money"
+)
+
+// Fields a text condition can look at.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldAny         = "any"
+)
+
+// Ways a text condition can match.
+const (
+	MatchContains = "contains"
+	MatchRegex    = "regex"
+)
+
+// Rule categorizes transactions automatically. It matches when every condition
+// it has holds: the text condition (Field, MatchType and Pattern) and the
+// amount range (MinAmount and MaxAmount). A matching rule sets the category and
+// account and adds the tags it names.
+type Rule struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserID   uint   `json:"user_id" gorm:"index"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"` // lower runs first
+	Enabled  bool   `json:"enabled"`
+
+	Field     string        `json:"field,omitempty"`
+	MatchType string        `json:"match_type,omitempty"`
+	Pattern   string        `json:"pattern,omitempty"`
+	MinAmount *money.Amount `json:"min_amount,omitempty"`
+	MaxAmount *money.Amount `json:"max_amount,omitempty"`
+
+	CategoryID *uint    `json:"category_id,omitempty"`
+	AccountID  *uint    `json:"account_id,omitempty"`
+	Tags       []string `json:"tags,omitempty" gorm:"serializer:json"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	re *regexp.Regexp // compiled Pattern for regex rules
+}
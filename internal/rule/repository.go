@@ -0,0 +1,104 @@
+package rule
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrRuleNotFound is returned when a rule does not exist or belongs to someone else.
+var ErrRuleNotFound = errors.New("rule not found")
+
+type Repository interface {
+	Create(rule *Rule) error
+	GetAll(userID uint) ([]Rule, error)
+	GetEnabled(userID uint) ([]Rule, error)
+	GetByID(id, userID uint) (*Rule, error)
+	Update(rule *Rule) error
+	Delete(id, userID uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create adds a new rule to the database.
+func (r *repository) Create(rule *Rule) error {
+	if rule == nil {
+		return errors.New("rule is nil")
+	}
+	return r.db.Create(rule).Error
+}
+
+// GetAll returns the user's rules in the order they run.
+func (r *repository) GetAll(userID uint) ([]Rule, error) {
+	var rules []Rule
+	if err := r.db.Where("user_id = ?", userID).Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetEnabled returns the user's enabled rules in the order they run.
+func (r *repository) GetEnabled(userID uint) ([]Rule, error) {
+	var rules []Rule
+	err := r.db.Where("user_id = ? AND enabled = ?", userID, true).
+		Order("priority, id").
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetByID fetches one of the user's rules, or nil if there is none.
+func (r *repository) GetByID(id, userID uint) (*Rule, error) {
+	var rule Rule
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Update overwrites one of rule.UserID's rules and reloads it. It returns
+// ErrRuleNotFound when the user has no such rule.
+func (r *repository) Update(rule *Rule) error {
+	if rule == nil || rule.ID == 0 {
+		return errors.New("invalid rule")
+	}
+	result := r.db.Model(&Rule{}).
+		Where("id = ? AND user_id = ?", rule.ID, rule.UserID).
+		Select("name", "priority", "enabled", "field", "match_type", "pattern",
+			"min_amount", "max_amount", "category_id", "account_id", "tags").
+		Updates(rule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return r.db.First(rule, rule.ID).Error
+}
+
+// Delete removes one of the user's rules.
+func (r *repository) Delete(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid rule ID")
+	}
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Rule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
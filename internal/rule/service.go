@@ -0,0 +1,104 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/tag"
+)
+
+type Service interface {
+	CreateRule(rule *Rule) error
+	GetRules(userID uint) ([]Rule, error)
+	GetRule(id, userID uint) (*Rule, error)
+	UpdateRule(rule *Rule) error
+	DeleteRule(id, userID uint) error
+}
+
+type service struct {
+	repo         Repository
+	categoryRepo category.Repository
+	accountRepo  account.Repository
+}
+
+func NewService(repo Repository, categoryRepo category.Repository, accountRepo account.Repository) Service {
+	return &service{repo: repo, categoryRepo: categoryRepo, accountRepo: accountRepo}
+}
+
+// CreateRule validates and stores a rule.
+func (s *service) CreateRule(rule *Rule) error {
+	if rule == nil {
+		return errors.New("rule cannot be nil")
+	}
+	if err := prepare(rule); err != nil {
+		return err
+	}
+	if err := s.checkTargets(rule); err != nil {
+		return err
+	}
+	return s.repo.Create(rule)
+}
+
+func (s *service) GetRules(userID uint) ([]Rule, error) {
+	return s.repo.GetAll(userID)
+}
+
+func (s *service) GetRule(id, userID uint) (*Rule, error) {
+	rule, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+// UpdateRule validates and overwrites one of the user's rules.
+func (s *service) UpdateRule(rule *Rule) error {
+	if rule == nil || rule.ID == 0 {
+		return errors.New("invalid rule")
+	}
+	if err := prepare(rule); err != nil {
+		return err
+	}
+	if err := s.checkTargets(rule); err != nil {
+		return err
+	}
+	return s.repo.Update(rule)
+}
+
+func (s *service) DeleteRule(id, userID uint) error {
+	return s.repo.Delete(id, userID)
+}
+
+// prepare normalizes the tags of a rule the same way expense tags are stored
+// and validates it.
+func prepare(rule *Rule) error {
+	rule.Tags = tag.Normalized(rule.Tags)
+	if rule.MatchType != "" && rule.Field == "" {
+		rule.Field = FieldAny
+	}
+	return rule.Validate()
+}
+
+// checkTargets makes sure the category a rule sets is an expense category the
+// owner can see, and the account one they can use.
+func (s *service) checkTargets(rule *Rule) error {
+	if rule.CategoryID != nil {
+		if err := category.CheckUsable(s.categoryRepo, *rule.CategoryID, rule.UserID, category.KindExpense); err != nil {
+			return err
+		}
+	}
+	if rule.AccountID != nil {
+		acc, err := s.accountRepo.GetByID(*rule.AccountID, rule.UserID)
+		if err != nil {
+			return err
+		}
+		if acc == nil {
+			return fmt.Errorf("%w: %d", account.ErrAccountNotFound, *rule.AccountID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package sharing
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/group"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/money"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SharingController struct {
+	service Service
+}
+
+func NewSharingController(service Service) *SharingController {
+	return &SharingController{service: service}
+}
+
+// SplitExpense shares one of the caller's expenses with other users.
+func (ctrl *SharingController) SplitExpense(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid expense ID", err.Error())
+		return
+	}
+
+	var request dto.SplitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	participants := make([]Participant, len(request.Participants))
+	for i, p := range request.Participants {
+		participants[i] = Participant{UserID: p.UserID, Amount: p.Amount, Percent: p.Percent}
+	}
+
+	shares, err := ctrl.service.SplitExpense(uint(id), userID, request.Method, participants)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrExpenseNotFound):
+			response.NotFound(c, "Expense not found", nil)
+		case errors.Is(err, ErrInvalidSplit), errors.Is(err, ErrNotGroupMate):
+			response.BadRequest(c, "Invalid split", err.Error())
+		default:
+			logger.Error("Failed to split expense", zap.Error(err), zap.Int("expenseID", id))
+			response.InternalServerError(c, "Could not split expense", err.Error())
+		}
+		return
+	}
+	response.Updated(c, "Expense split successfully", shares)
+}
+
+// GetShares returns how an expense is split.
+func (ctrl *SharingController) GetShares(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid expense ID", err.Error())
+		return
+	}
+
+	shares, err := ctrl.service.GetShares(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, ErrExpenseNotFound) {
+			response.NotFound(c, "Expense not found", nil)
+			return
+		}
+		logger.Error("Failed to retrieve shares", zap.Error(err), zap.Int("expenseID", id))
+		response.InternalServerError(c, "Could not retrieve shares", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Shares retrieved successfully", shares)
+}
+
+// RemoveShares stops sharing an expense.
+func (ctrl *SharingController) RemoveShares(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid expense ID", err.Error())
+		return
+	}
+
+	if err := ctrl.service.RemoveShares(uint(id), userID); err != nil {
+		if errors.Is(err, ErrExpenseNotFound) {
+			response.NotFound(c, "Expense not found", nil)
+			return
+		}
+		logger.Error("Failed to remove shares", zap.Error(err), zap.Int("expenseID", id))
+		response.InternalServerError(c, "Could not remove shares", err.Error())
+		return
+	}
+	response.Deleted(c, "Expense is no longer shared")
+}
+
+// GetBalances lists what each counterparty owes the caller, one entry per
+// currency they have debts in.
+func (ctrl *SharingController) GetBalances(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	balances, err := ctrl.service.GetBalances(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve balances", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve balances", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Balances retrieved successfully", balances)
+}
+
+// SimplifyDebts proposes the fewest payments that settle the caller and
+// ?user_ids=2,3,4 with each other, or every member of ?group_id=. Listed users
+// must share a group, an expense or a settlement with the caller. Debts in
+// different currencies are settled separately.
+func (ctrl *SharingController) SimplifyDebts(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	if raw := c.Query("group_id"); raw != "" {
+		groupID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || groupID == 0 {
+			response.BadRequest(c, "Invalid filters", gin.H{"group_id": "must be a positive integer"})
+			return
+		}
+		payments, err := ctrl.service.SimplifyGroup(uint(groupID), userID)
+		if err != nil {
+			if group.RespondAccessError(c, err) {
+				return
+			}
+			logger.Error("Failed to simplify group debts", zap.Error(err), zap.Uint("userID", userID))
+			response.InternalServerError(c, "Could not simplify debts", err.Error())
+			return
+		}
+		response.Success(c, http.StatusOK, "Debts simplified successfully", payments)
+		return
+	}
+
+	var others []uint
+	for _, part := range strings.Split(c.Query("user_ids"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil || id == 0 {
+			response.BadRequest(c, "Invalid filters", gin.H{"user_ids": "must be a comma-separated list of IDs"})
+			return
+		}
+		others = append(others, uint(id))
+	}
+	if len(others) == 0 {
+		response.BadRequest(c, "Invalid filters", gin.H{"user_ids": "is required"})
+		return
+	}
+
+	payments, err := ctrl.service.Simplify(userID, others)
+	if err != nil {
+		if errors.Is(err, ErrNotRelated) {
+			response.BadRequest(c, "Invalid filters", gin.H{"user_ids": err.Error()})
+			return
+		}
+		logger.Error("Failed to simplify debts", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not simplify debts", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Debts simplified successfully", payments)
+}
+
+// CreateSettlement records a payment between the caller and another user.
+func (ctrl *SharingController) CreateSettlement(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.SettlementRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	date, err := utils.ParseDateOrNow(request.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	settlement := &Settlement{
+		FromUserID: request.FromUserID,
+		ToUserID:   request.ToUserID,
+		Amount:     request.Amount,
+		Currency:   request.Currency,
+		Note:       request.Note,
+		Date:       date,
+		RecordedBy: userID,
+	}
+	if settlement.FromUserID == 0 {
+		settlement.FromUserID = userID
+	}
+
+	if err := ctrl.service.CreateSettlement(settlement); err != nil {
+		if errors.Is(err, ErrInvalidParties) || errors.Is(err, ErrUserNotFound) {
+			response.BadRequest(c, "Invalid settlement", err.Error())
+			return
+		}
+		if errors.Is(err, money.ErrInvalid) {
+			response.BadRequest(c, "Validation error", gin.H{"amount": err.Error()})
+			return
+		}
+		logger.Error("Failed to create settlement", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not create settlement", err.Error())
+		return
+	}
+	response.Created(c, "Settlement recorded successfully", settlement)
+}
+
+// GetSettlements lists the settlements the caller paid or received.
+func (ctrl *SharingController) GetSettlements(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	settlements, err := ctrl.service.GetSettlements(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve settlements", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve settlements", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Settlements retrieved successfully", settlements)
+}
+
+// DeleteSettlement removes a settlement recorded by mistake.
+func (ctrl *SharingController) DeleteSettlement(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid settlement ID", err.Error())
+		return
+	}
+
+	if err := ctrl.service.DeleteSettlement(uint(id), userID); err != nil {
+		if errors.Is(err, ErrSettlementNotFound) {
+			response.NotFound(c, "Settlement not found", nil)
+			return
+		}
+		logger.Error("Failed to delete settlement", zap.Error(err), zap.Int("settlementID", id))
+		response.InternalServerError(c, "Could not delete settlement", err.Error())
+		return
+	}
+	response.Deleted(c, "Settlement deleted successfully")
+}
@@ -0,0 +1,70 @@
+package sharing
+
+import (
+	"time"
+	"trackonomy/internal/money"
+)
+
+// Methods for dividing an expense between users.
+const (
+	SplitEqual   = "equal"
+	SplitExact   = "exact"
+	SplitPercent = "percent"
+)
+
+// Share is the part of an expense that one user owes to the user who paid it.
+// The payer may have a share too, so that the shares add up to the expense
+// amount; it does not count towards any balance.
+type Share struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	ExpenseID uint         `json:"expense_id" gorm:"uniqueIndex:idx_share_expense_user,priority:1"`
+	PayerID   uint         `json:"payer_id" gorm:"index"`
+	UserID    uint         `json:"user_id" gorm:"uniqueIndex:idx_share_expense_user,priority:2;index"`
+	Method    string       `json:"method"`
+	Percent   float64      `json:"percent,omitempty"`
+	Amount    money.Amount `json:"amount"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Settlement is a payment between two users that pays off what one owes the
+// other in Currency. It only offsets debts in the same currency.
+type Settlement struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	FromUserID uint         `json:"from_user_id" gorm:"index"`
+	ToUserID   uint         `json:"to_user_id" gorm:"index"`
+	Amount     money.Amount `json:"amount"`
+	Currency   string       `json:"currency" gorm:"size:3"`
+	Note       string       `json:"note"`
+	Date       time.Time    `json:"date"`
+	RecordedBy uint         `json:"recorded_by"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// Participant is one user in a split request. Amount is used by exact splits
+// and Percent by percent splits.
+type Participant struct {
+	UserID  uint
+	Amount  money.Amount
+	Percent float64
+}
+
+// Balance is what another user owes the caller in one currency, net of
+// everything shared and settled between them in it. A negative amount means
+// the caller owes that user. Shares are in the currency of their expense.
+type Balance struct {
+	UserID   uint         `json:"user_id"`
+	Username string       `json:"username"`
+	Amount   money.Amount `json:"amount"`
+	Currency string       `json:"currency"`
+}
+
+// Payment is one transfer proposed by debt simplification.
+type Payment struct {
+	FromUserID uint         `json:"from_user_id"`
+	ToUserID   uint         `json:"to_user_id"`
+	Amount     money.Amount `json:"amount"`
+	Currency   string       `json:"currency"`
+}
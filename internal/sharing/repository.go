@@ -0,0 +1,218 @@
+package sharing
+
+import (
+	"errors"
+	"trackonomy/internal/group"
+	"trackonomy/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// Repository stores expense shares and settlements and computes balances from them.
+type Repository interface {
+	GetShares(expenseID uint) ([]Share, error)
+	ReplaceShares(expenseID uint, shares []Share) error
+	CreateSettlement(settlement *Settlement) error
+	GetSettlements(userID uint) ([]Settlement, error)
+	GetSettlementByID(id, userID uint) (*Settlement, error)
+	DeleteSettlement(id, userID uint) error
+	Balances(userID uint) ([]Balance, error)
+	NetPositions(userIDs []uint) (map[string]map[uint]money.Amount, error)
+	GroupMates(userID uint, others []uint) ([]uint, error)
+	Related(userID uint, others []uint) ([]uint, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new sharing repository with the given database connection.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// GetShares returns the shares of an expense.
+func (r *repository) GetShares(expenseID uint) ([]Share, error) {
+	var shares []Share
+	err := r.db.Where("expense_id = ?", expenseID).Order("id").Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// ReplaceShares deletes the shares of an expense and stores shares instead.
+func (r *repository) ReplaceShares(expenseID uint, shares []Share) error {
+	if err := r.db.Where("expense_id = ?", expenseID).Delete(&Share{}).Error; err != nil {
+		return err
+	}
+	if len(shares) == 0 {
+		return nil
+	}
+	for i := range shares {
+		shares[i].ID = 0
+		shares[i].ExpenseID = expenseID
+	}
+	return r.db.Create(&shares).Error
+}
+
+// CreateSettlement adds a new settlement to the database.
+func (r *repository) CreateSettlement(settlement *Settlement) error {
+	if settlement == nil {
+		return errors.New("settlement is nil")
+	}
+	return r.db.Create(settlement).Error
+}
+
+// GetSettlements returns the settlements the user paid or received, newest first.
+func (r *repository) GetSettlements(userID uint) ([]Settlement, error) {
+	var settlements []Settlement
+	err := r.db.Where("from_user_id = ? OR to_user_id = ?", userID, userID).
+		Order("date DESC, id DESC").
+		Find(&settlements).Error
+	if err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+// GetSettlementByID retrieves a settlement the user is a party to.
+func (r *repository) GetSettlementByID(id, userID uint) (*Settlement, error) {
+	var settlement Settlement
+	err := r.db.Where("id = ? AND (from_user_id = ? OR to_user_id = ?)", id, userID, userID).
+		First(&settlement).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// DeleteSettlement removes a settlement the user is a party to.
+func (r *repository) DeleteSettlement(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid ID")
+	}
+	return r.db.Where("id = ? AND (from_user_id = ? OR to_user_id = ?)", id, userID, userID).
+		Delete(&Settlement{}).Error
+}
+
+// Balances returns, for every user the given user has shared expenses or
+// settlements with, what that user owes them in each currency. Pairs that are
+// settled up in a currency are left out.
+func (r *repository) Balances(userID uint) ([]Balance, error) {
+	var balances []Balance
+	err := r.db.Raw(`
+		SELECT t.other AS user_id, COALESCE(u.username, '') AS username, SUM(t.amount) AS amount, t.currency
+		FROM (
+			SELECT s.user_id AS other, s.amount, e.currency
+			FROM shares s JOIN expenses e ON e.id = s.expense_id AND e.deleted_at IS NULL
+			WHERE s.payer_id = @user AND s.user_id <> @user
+			UNION ALL
+			SELECT s.payer_id AS other, -s.amount, e.currency
+			FROM shares s JOIN expenses e ON e.id = s.expense_id AND e.deleted_at IS NULL
+			WHERE s.user_id = @user AND s.payer_id <> @user
+			UNION ALL
+			SELECT to_user_id AS other, amount, currency FROM settlements WHERE from_user_id = @user
+			UNION ALL
+			SELECT from_user_id AS other, -amount, currency FROM settlements WHERE to_user_id = @user
+		) t
+		LEFT JOIN users u ON u.id = t.other
+		GROUP BY t.other, u.username, t.currency
+		HAVING ROUND(SUM(t.amount)::numeric, 2) <> 0
+		ORDER BY t.currency, amount DESC`,
+		map[string]interface{}{"user": userID},
+	).Scan(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// NetPositions returns the net position of each of the given users in each
+// currency, counting only shares and settlements between members of the set.
+// A positive amount means the user is owed money.
+func (r *repository) NetPositions(userIDs []uint) (map[string]map[uint]money.Amount, error) {
+	var rows []struct {
+		UserID   uint
+		Currency string
+		Amount   money.Amount
+	}
+	err := r.db.Raw(`
+		SELECT user_id, currency, SUM(amount) AS amount
+		FROM (
+			SELECT s.payer_id AS user_id, e.currency, s.amount
+			FROM shares s JOIN expenses e ON e.id = s.expense_id AND e.deleted_at IS NULL
+			WHERE s.payer_id IN @users AND s.user_id IN @users AND s.payer_id <> s.user_id
+			UNION ALL
+			SELECT s.user_id, e.currency, -s.amount
+			FROM shares s JOIN expenses e ON e.id = s.expense_id AND e.deleted_at IS NULL
+			WHERE s.payer_id IN @users AND s.user_id IN @users AND s.payer_id <> s.user_id
+			UNION ALL
+			SELECT from_user_id AS user_id, currency, amount FROM settlements
+			WHERE from_user_id IN @users AND to_user_id IN @users
+			UNION ALL
+			SELECT to_user_id AS user_id, currency, -amount FROM settlements
+			WHERE from_user_id IN @users AND to_user_id IN @users
+		) t
+		GROUP BY user_id, currency`,
+		map[string]interface{}{"users": userIDs},
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	net := map[string]map[uint]money.Amount{}
+	for _, row := range rows {
+		if net[row.Currency] == nil {
+			net[row.Currency] = map[uint]money.Amount{}
+		}
+		net[row.Currency][row.UserID] = row.Amount
+	}
+	return net, nil
+}
+
+// GroupMates returns those of others who share at least one group with the user.
+func (r *repository) GroupMates(userID uint, others []uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&group.Member{}).Distinct("user_id").
+		Where("user_id IN ? AND group_id IN (?)", others, group.IDsOf(r.db, userID)).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Related returns those of others the user shares a group with, or has
+// shared expenses or settlements with.
+func (r *repository) Related(userID uint, others []uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Table("users").
+		Where("id IN ?", others).
+		Where(`id IN (?)
+			OR EXISTS (SELECT 1 FROM shares s
+				WHERE (s.payer_id = ? AND s.user_id = users.id) OR (s.user_id = ? AND s.payer_id = users.id))
+			OR EXISTS (SELECT 1 FROM settlements t
+				WHERE (t.from_user_id = ? AND t.to_user_id = users.id) OR (t.to_user_id = ? AND t.from_user_id = users.id))`,
+			group.MatesOf(r.db, userID), userID, userID, userID, userID).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
@@ -0,0 +1,290 @@
+package sharing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/expense"
+	"trackonomy/internal/group"
+	"trackonomy/internal/money"
+	"trackonomy/internal/user"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrExpenseNotFound is returned when the expense does not exist or is not visible to the user.
+	ErrExpenseNotFound = errors.New("expense not found")
+	// ErrUserNotFound is returned when a participant or counterparty is not a registered user.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrSettlementNotFound is returned when the settlement to delete does not exist.
+	ErrSettlementNotFound = errors.New("settlement not found")
+	// ErrInvalidParties is returned when a settlement is not between the caller and someone else.
+	ErrInvalidParties = errors.New("a settlement must be between the caller and another user")
+	// ErrNotGroupMate is returned when an expense is split with a user who shares no group with the payer.
+	ErrNotGroupMate = errors.New("expenses can only be split with members of your groups")
+	// ErrNotRelated is returned when debts are simplified with a user the caller has nothing shared with.
+	ErrNotRelated = errors.New("you share no expenses or groups with this user")
+)
+
+type Service interface {
+	SplitExpense(expenseID, userID uint, method string, participants []Participant) ([]Share, error)
+	GetShares(expenseID, userID uint) ([]Share, error)
+	RemoveShares(expenseID, userID uint) error
+	CreateSettlement(settlement *Settlement) error
+	GetSettlements(userID uint) ([]Settlement, error)
+	DeleteSettlement(id, userID uint) error
+	GetBalances(userID uint) ([]Balance, error)
+	Simplify(userID uint, others []uint) ([]Payment, error)
+	SimplifyGroup(groupID, userID uint) ([]Payment, error)
+}
+
+type service struct {
+	repo        Repository
+	expenseRepo expense.Repository
+	userRepo    user.Repository
+	groups      group.Service
+	currency    currency.Service
+}
+
+func NewService(repo Repository, expenseRepo expense.Repository, userRepo user.Repository, groups group.Service,
+	currencies currency.Service) Service {
+	return &service{repo: repo, expenseRepo: expenseRepo, userRepo: userRepo, groups: groups, currency: currencies}
+}
+
+// SplitExpense divides one of the user's expenses between the participants,
+// replacing any earlier split. The user is recorded as the payer. Other
+// participants must share a group with the user, so nobody can be put in debt
+// by a stranger.
+func (s *service) SplitExpense(expenseID, userID uint, method string, participants []Participant) ([]Share, error) {
+	exp, err := s.ownExpense(expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var others []uint
+	for _, p := range participants {
+		if p.UserID != userID {
+			others = append(others, p.UserID)
+		}
+	}
+	if len(others) > 0 {
+		mates, err := s.repo.GroupMates(userID, others)
+		if err != nil {
+			return nil, err
+		}
+		if id, ok := missing(others, mates); ok {
+			return nil, fmt.Errorf("%w: user %d", ErrNotGroupMate, id)
+		}
+	}
+
+	shares, err := Split(method, exp.Amount, exp.Currency, participants)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		shares[i].PayerID = userID
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		return s.repo.WithTx(tx).ReplaceShares(exp.ID, shares)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// GetShares returns the split of an expense to its owner or to any participant.
+func (s *service) GetShares(expenseID, userID uint) ([]Share, error) {
+	// Participants need not be able to see the expense itself, so it is looked
+	// up without scoping and checked below.
+	exp, err := s.expenseRepo.GetWithTrashed(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil || exp.DeletedAt.Valid {
+		return nil, ErrExpenseNotFound
+	}
+
+	shares, err := s.repo.GetShares(expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if exp.UserID == userID {
+		return shares, nil
+	}
+	for _, share := range shares {
+		if share.UserID == userID {
+			return shares, nil
+		}
+	}
+	return nil, ErrExpenseNotFound
+}
+
+// RemoveShares turns a shared expense back into a personal one.
+func (s *service) RemoveShares(expenseID, userID uint) error {
+	exp, err := s.ownExpense(expenseID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.ReplaceShares(exp.ID, nil)
+}
+
+// CreateSettlement records a payment between the caller (RecordedBy) and
+// another user. It is in the caller's base currency unless it names one, and
+// its amount is rounded to the minor unit of that currency.
+func (s *service) CreateSettlement(settlement *Settlement) error {
+	if settlement == nil {
+		return errors.New("settlement cannot be nil")
+	}
+	caller := settlement.RecordedBy
+	if settlement.FromUserID == settlement.ToUserID ||
+		(settlement.FromUserID != caller && settlement.ToUserID != caller) {
+		return ErrInvalidParties
+	}
+
+	other := settlement.ToUserID
+	if other == caller {
+		other = settlement.FromUserID
+	}
+	if err := s.requireUser(other); err != nil {
+		return err
+	}
+	settlement.Currency = currency.Normalize(settlement.Currency)
+	if settlement.Currency == "" {
+		code, err := s.currency.BaseCurrency(caller)
+		if err != nil {
+			return err
+		}
+		settlement.Currency = code
+	}
+	amount, err := settlement.Amount.RoundPositive(settlement.Currency)
+	if err != nil {
+		return err
+	}
+	settlement.Amount = amount
+	return s.repo.CreateSettlement(settlement)
+}
+
+func (s *service) GetSettlements(userID uint) ([]Settlement, error) {
+	return s.repo.GetSettlements(userID)
+}
+
+// DeleteSettlement removes a settlement; either party may do so.
+func (s *service) DeleteSettlement(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid ID")
+	}
+	existing, err := s.repo.GetSettlementByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrSettlementNotFound
+	}
+	return s.repo.DeleteSettlement(id, userID)
+}
+
+func (s *service) GetBalances(userID uint) ([]Balance, error) {
+	return s.repo.Balances(userID)
+}
+
+// Simplify proposes the payments that settle all debts between the user and
+// the given other users, who must each share a group, an expense or a
+// settlement with the user.
+func (s *service) Simplify(userID uint, others []uint) ([]Payment, error) {
+	userIDs := []uint{userID}
+	seen := map[uint]bool{userID: true}
+	for _, id := range others {
+		if !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) > 1 {
+		related, err := s.repo.Related(userID, userIDs[1:])
+		if err != nil {
+			return nil, err
+		}
+		if id, ok := missing(userIDs[1:], related); ok {
+			return nil, fmt.Errorf("%w: user %d", ErrNotRelated, id)
+		}
+	}
+
+	net, err := s.repo.NetPositions(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return simplifyEach(net), nil
+}
+
+// SimplifyGroup proposes the payments that settle all debts between the
+// members of a group the user belongs to.
+func (s *service) SimplifyGroup(groupID, userID uint) ([]Payment, error) {
+	members, err := s.groups.MemberIDs(groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	net, err := s.repo.NetPositions(members)
+	if err != nil {
+		return nil, err
+	}
+	return simplifyEach(net), nil
+}
+
+// simplifyEach simplifies the debts in each currency on their own, as debts in
+// one currency do not pay off those in another. Currencies come in code order.
+func simplifyEach(net map[string]map[uint]money.Amount) []Payment {
+	codes := make([]string, 0, len(net))
+	for code := range net {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var payments []Payment
+	for _, code := range codes {
+		for _, p := range Simplify(net[code], code) {
+			p.Currency = code
+			payments = append(payments, p)
+		}
+	}
+	return payments
+}
+
+// ownExpense returns the expense if it belongs to the user.
+func (s *service) ownExpense(expenseID, userID uint) (*expense.Expense, error) {
+	exp, err := s.expenseRepo.GetByID(expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil || exp.UserID != userID {
+		return nil, ErrExpenseNotFound
+	}
+	return exp, nil
+}
+
+// missing returns the first of want that is not in got.
+func missing(want, got []uint) (uint, bool) {
+	found := make(map[uint]bool, len(got))
+	for _, id := range got {
+		found[id] = true
+	}
+	for _, id := range want {
+		if !found[id] {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (s *service) requireUser(id uint) error {
+	u, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
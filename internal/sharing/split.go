@@ -0,0 +1,171 @@
+package sharing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"trackonomy/internal/money"
+)
+
+// ErrInvalidSplit is wrapped by every error returned from Split.
+var ErrInvalidSplit = errors.New("invalid split")
+
+// Split divides total, an amount in the currency code, between the participants
+// and returns one share per participant. Amounts are worked out in the minor
+// unit of the currency (cents, whole yen, fils); with equal and percent splits
+// the units that cannot be divided evenly go to the first participants, so the
+// shares always add up to total exactly. Exact amounts must not be finer than
+// the minor unit.
+func Split(method string, total money.Amount, code string, participants []Participant) ([]Share, error) {
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("%w: at least one participant is required", ErrInvalidSplit)
+	}
+	seen := map[uint]bool{}
+	for _, p := range participants {
+		if seen[p.UserID] {
+			return nil, fmt.Errorf("%w: user %d is listed twice", ErrInvalidSplit, p.UserID)
+		}
+		seen[p.UserID] = true
+	}
+
+	step := minorUnit(code)
+	totalUnits := int64(total.Round(code) / step)
+	units := make([]int64, len(participants))
+
+	switch method {
+	case SplitEqual:
+		n := int64(len(participants))
+		for i := range units {
+			units[i] = totalUnits / n
+		}
+		distribute(units, totalUnits%n, nil)
+
+	case SplitExact:
+		var sum int64
+		for i, p := range participants {
+			if p.Amount%step != 0 {
+				return nil, fmt.Errorf("%w: %s has more decimals than %s allows", ErrInvalidSplit, p.Amount, code)
+			}
+			units[i] = int64(p.Amount / step)
+			sum += units[i]
+		}
+		if sum != totalUnits {
+			return nil, fmt.Errorf("%w: amounts add up to %s, expected %s", ErrInvalidSplit,
+				money.Amount(sum)*step, total.Round(code))
+		}
+
+	case SplitPercent:
+		var percent float64
+		remainders := make([]float64, len(participants))
+		var sum int64
+		for i, p := range participants {
+			percent += p.Percent
+			exact := float64(totalUnits) * p.Percent / 100
+			units[i] = int64(math.Floor(exact))
+			remainders[i] = exact - float64(units[i])
+			sum += units[i]
+		}
+		if math.Abs(percent-100) > 0.001 {
+			return nil, fmt.Errorf("%w: percentages add up to %g, expected 100", ErrInvalidSplit, percent)
+		}
+		distribute(units, totalUnits-sum, remainders)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown method %q", ErrInvalidSplit, method)
+	}
+
+	shares := make([]Share, len(participants))
+	for i, p := range participants {
+		shares[i] = Share{
+			UserID: p.UserID,
+			Method: method,
+			Amount: money.Amount(units[i]) * step,
+		}
+		if method == SplitPercent {
+			shares[i].Percent = p.Percent
+		}
+	}
+	return shares, nil
+}
+
+// distribute hands out extra minor units one at a time, to the largest
+// remainders first when they are given and in participant order otherwise.
+func distribute(units []int64, extra int64, remainders []float64) {
+	order := make([]int, len(units))
+	for i := range order {
+		order[i] = i
+	}
+	if remainders != nil {
+		sort.SliceStable(order, func(a, b int) bool {
+			return remainders[order[a]] > remainders[order[b]]
+		})
+	}
+	for i := int64(0); i < extra; i++ {
+		units[order[int(i)%len(order)]]++
+	}
+}
+
+// Simplify turns the net position of each user (positive: is owed money,
+// negative: owes money) into a short list of payments that settles everyone.
+// It repeatedly pays the largest creditor from the largest debtor, which needs
+// at most n-1 payments for n users and leaves nobody paying and receiving at
+// the same time. The positions are in the currency code and rounded to its
+// minor unit first.
+func Simplify(net map[uint]money.Amount, code string) []Payment {
+	type position struct {
+		userID uint
+		units  int64
+	}
+	step := minorUnit(code)
+	var creditors, debtors []position
+	for userID, amount := range net {
+		switch c := int64(amount.Round(code) / step); {
+		case c > 0:
+			creditors = append(creditors, position{userID, c})
+		case c < 0:
+			debtors = append(debtors, position{userID, -c})
+		}
+	}
+	largestFirst := func(p []position) func(a, b int) bool {
+		return func(a, b int) bool {
+			if p[a].units != p[b].units {
+				return p[a].units > p[b].units
+			}
+			return p[a].userID < p[b].userID
+		}
+	}
+
+	var payments []Payment
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sort.Slice(creditors, largestFirst(creditors))
+		sort.Slice(debtors, largestFirst(debtors))
+
+		amount := min(creditors[0].units, debtors[0].units)
+		payments = append(payments, Payment{
+			FromUserID: debtors[0].userID,
+			ToUserID:   creditors[0].userID,
+			Amount:     money.Amount(amount) * step,
+		})
+
+		creditors[0].units -= amount
+		debtors[0].units -= amount
+		if creditors[0].units == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].units == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return payments
+}
+
+// minorUnit returns the Amount of the smallest unit of a currency, such as
+// one cent or one yen.
+func minorUnit(code string) money.Amount {
+	step := money.Unit
+	for i := 0; i < money.Decimals(code); i++ {
+		step /= 10
+	}
+	return step
+}
@@ -0,0 +1,278 @@
+package sharing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"trackonomy/internal/money"
+)
+
+func amounts(values ...string) []money.Amount {
+	out := make([]money.Amount, len(values))
+	for i, v := range values {
+		out[i] = money.MustParse(v)
+	}
+	return out
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		total        string
+		code         string // EUR when empty
+		participants []Participant
+		want         []money.Amount
+	}{
+		{
+			name:         "equal, even",
+			method:       SplitEqual,
+			total:        "10.00",
+			participants: []Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}, {UserID: 4}},
+			want:         amounts("2.50", "2.50", "2.50", "2.50"),
+		},
+		{
+			name:         "equal, remainder goes to the first participants",
+			method:       SplitEqual,
+			total:        "100.00",
+			participants: []Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+			want:         amounts("33.34", "33.33", "33.33"),
+		},
+		{
+			name:         "equal, fewer cents than participants",
+			method:       SplitEqual,
+			total:        "0.05",
+			participants: []Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+			want:         amounts("0.02", "0.02", "0.01"),
+		},
+		{
+			name:   "exact",
+			method: SplitExact,
+			total:  "45.50",
+			participants: []Participant{
+				{UserID: 1, Amount: money.MustParse("20")},
+				{UserID: 2, Amount: money.MustParse("25.50")},
+			},
+			want: amounts("20.00", "25.50"),
+		},
+		{
+			name:   "percent, round",
+			method: SplitPercent,
+			total:  "10.00",
+			participants: []Participant{
+				{UserID: 1, Percent: 50},
+				{UserID: 2, Percent: 25},
+				{UserID: 3, Percent: 25},
+			},
+			want: amounts("5.00", "2.50", "2.50"),
+		},
+		{
+			name:   "percent, extra cent to the largest remainder",
+			method: SplitPercent,
+			total:  "0.10",
+			participants: []Participant{
+				{UserID: 1, Percent: 33.3333},
+				{UserID: 2, Percent: 33.3333},
+				{UserID: 3, Percent: 33.3334},
+			},
+			want: amounts("0.03", "0.03", "0.04"),
+		},
+		{
+			name:   "percent, equal remainders in participant order",
+			method: SplitPercent,
+			total:  "1.00",
+			participants: []Participant{
+				{UserID: 1, Percent: 12.5},
+				{UserID: 2, Percent: 87.5},
+			},
+			want: amounts("0.13", "0.87"),
+		},
+		{
+			name:         "equal, in whole yen",
+			method:       SplitEqual,
+			total:        "1000",
+			code:         "JPY",
+			participants: []Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+			want:         amounts("334", "333", "333"),
+		},
+		{
+			name:   "percent, in fils",
+			method: SplitPercent,
+			total:  "1.000",
+			code:   "KWD",
+			participants: []Participant{
+				{UserID: 1, Percent: 33.3333},
+				{UserID: 2, Percent: 33.3333},
+				{UserID: 3, Percent: 33.3334},
+			},
+			want: amounts("0.333", "0.333", "0.334"),
+		},
+		{
+			name:   "exact, in fils",
+			method: SplitExact,
+			total:  "10.005",
+			code:   "KWD",
+			participants: []Participant{
+				{UserID: 1, Amount: money.MustParse("5.002")},
+				{UserID: 2, Amount: money.MustParse("5.003")},
+			},
+			want: amounts("5.002", "5.003"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := tt.code
+			if code == "" {
+				code = "EUR"
+			}
+			total := money.MustParse(tt.total)
+			shares, err := Split(tt.method, total, code, tt.participants)
+			if err != nil {
+				t.Fatalf("Split() error = %v", err)
+			}
+			if len(shares) != len(tt.want) {
+				t.Fatalf("Split() returned %d shares, want %d", len(shares), len(tt.want))
+			}
+			var sum money.Amount
+			for i, share := range shares {
+				if share.UserID != tt.participants[i].UserID || share.Method != tt.method {
+					t.Errorf("share %d = %+v, want user %d, method %s", i, share, tt.participants[i].UserID, tt.method)
+				}
+				if share.Amount != tt.want[i] {
+					t.Errorf("share %d amount = %s, want %s", i, share.Amount, tt.want[i])
+				}
+				sum += share.Amount
+			}
+			if sum != total {
+				t.Errorf("shares add up to %s, want %s", sum, total)
+			}
+		})
+	}
+}
+
+func TestSplitRejects(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		participants []Participant
+	}{
+		{name: "no participants", method: SplitEqual},
+		{name: "duplicate participant", method: SplitEqual, participants: []Participant{{UserID: 1}, {UserID: 1}}},
+		{name: "unknown method", method: "shares", participants: []Participant{{UserID: 1}}},
+		{name: "exact amounts short", method: SplitExact, participants: []Participant{
+			{UserID: 1, Amount: money.MustParse("4")},
+			{UserID: 2, Amount: money.MustParse("5.99")},
+		}},
+		{name: "percentages over 100", method: SplitPercent, participants: []Participant{
+			{UserID: 1, Percent: 60},
+			{UserID: 2, Percent: 50},
+		}},
+		{name: "percentages under 100", method: SplitPercent, participants: []Participant{
+			{UserID: 1, Percent: 90},
+		}},
+		{name: "exact amounts finer than a cent", method: SplitExact, participants: []Participant{
+			{UserID: 1, Amount: money.MustParse("4.995")},
+			{UserID: 2, Amount: money.MustParse("5.005")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Split(tt.method, money.MustParse("10"), "EUR", tt.participants)
+			if !errors.Is(err, ErrInvalidSplit) {
+				t.Errorf("Split() error = %v, want ErrInvalidSplit", err)
+			}
+		})
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		name string
+		net  map[uint]string
+		code string // EUR when empty
+		want []Payment
+	}{
+		{name: "nothing owed", net: map[uint]string{1: "0", 2: "0"}},
+		{
+			name: "chain collapses to one payment",
+			// 1 owes 2 ten and 2 owes 3 ten.
+			net:  map[uint]string{1: "-10", 2: "0", 3: "10"},
+			want: []Payment{{FromUserID: 1, ToUserID: 3, Amount: money.MustParse("10")}},
+		},
+		{
+			name: "largest debtor pays first",
+			net:  map[uint]string{1: "30", 2: "-10", 3: "-20"},
+			want: []Payment{
+				{FromUserID: 3, ToUserID: 1, Amount: money.MustParse("20")},
+				{FromUserID: 2, ToUserID: 1, Amount: money.MustParse("10")},
+			},
+		},
+		{
+			name: "at most n-1 payments",
+			net:  map[uint]string{1: "15", 2: "5", 3: "-12", 4: "-8"},
+			want: []Payment{
+				{FromUserID: 3, ToUserID: 1, Amount: money.MustParse("12")},
+				{FromUserID: 4, ToUserID: 2, Amount: money.MustParse("5")},
+				{FromUserID: 4, ToUserID: 1, Amount: money.MustParse("3")},
+			},
+		},
+		{
+			name: "ties broken by user ID",
+			net:  map[uint]string{1: "-5", 2: "-5", 3: "5", 4: "5"},
+			want: []Payment{
+				{FromUserID: 1, ToUserID: 3, Amount: money.MustParse("5")},
+				{FromUserID: 2, ToUserID: 4, Amount: money.MustParse("5")},
+			},
+		},
+		{name: "less than a cent is settled", net: map[uint]string{1: "0.004", 2: "-0.004"}},
+		{name: "less than a yen is settled", code: "JPY", net: map[uint]string{1: "0.4", 2: "-0.4"}},
+		{
+			name: "fils are kept",
+			code: "KWD",
+			net:  map[uint]string{1: "0.004", 2: "-0.004"},
+			want: []Payment{{FromUserID: 2, ToUserID: 1, Amount: money.MustParse("0.004")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net := map[uint]money.Amount{}
+			for id, v := range tt.net {
+				net[id] = money.MustParse(v)
+			}
+			code := tt.code
+			if code == "" {
+				code = "EUR"
+			}
+			got := Simplify(net, code)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Simplify() = %+v, want %+v", got, tt.want)
+			}
+
+			// Applying the payments must settle everyone.
+			for _, p := range got {
+				net[p.FromUserID] += p.Amount
+				net[p.ToUserID] -= p.Amount
+			}
+			for id, left := range net {
+				if left.Round(code) != 0 {
+					t.Errorf("user %d still has %s after the payments", id, left)
+				}
+			}
+		})
+	}
+}
+
+func TestSimplifyEachCurrency(t *testing.T) {
+	net := map[string]map[uint]money.Amount{
+		"USD": {1: money.MustParse("-5"), 2: money.MustParse("5")},
+		"INR": {1: money.MustParse("100"), 2: money.MustParse("-100")},
+		"EUR": {1: money.MustParse("0"), 2: money.MustParse("0")},
+	}
+	want := []Payment{
+		{FromUserID: 2, ToUserID: 1, Amount: money.MustParse("100"), Currency: "INR"},
+		{FromUserID: 1, ToUserID: 2, Amount: money.MustParse("5"), Currency: "USD"},
+	}
+	if got := simplifyEach(net); !reflect.DeepEqual(got, want) {
+		t.Errorf("simplifyEach() = %+v, want %+v", got, want)
+	}
+}
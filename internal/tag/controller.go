@@ -0,0 +1,164 @@
+package tag
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TagController struct {
+	service Service
+}
+
+func NewTagController(service Service) *TagController {
+	return &TagController{service: service}
+}
+
+// CreateTag creates a tag for the caller.
+func (ctrl *TagController) CreateTag(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.TagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	tag := &Tag{Name: request.Name, UserID: userID}
+	if err := ctrl.service.CreateTag(tag); err != nil {
+		respondError(c, err, "Could not create tag")
+		return
+	}
+	response.Created(c, "Tag created successfully", tag)
+}
+
+// GetAllTags lists the caller's tags alphabetically.
+func (ctrl *TagController) GetAllTags(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	tags, err := ctrl.service.GetTags(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve tags", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve tags", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Tags retrieved successfully", tags)
+}
+
+// GetTagByID retrieves one of the caller's tags.
+func (ctrl *TagController) GetTagByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	tag, err := ctrl.service.GetTag(id, userID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve tag")
+		return
+	}
+	response.Success(c, http.StatusOK, "Tag retrieved successfully", tag)
+}
+
+// RenameTag changes the name of a tag on every expense that carries it.
+func (ctrl *TagController) RenameTag(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var request dto.TagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	tag, err := ctrl.service.RenameTag(id, userID, request.Name)
+	if err != nil {
+		respondError(c, err, "Could not rename tag")
+		return
+	}
+	response.Updated(c, "Tag renamed successfully", tag)
+}
+
+// DeleteTag removes a tag from all expenses and deletes it.
+func (ctrl *TagController) DeleteTag(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.service.DeleteTag(id, userID); err != nil {
+		respondError(c, err, "Could not delete tag")
+		return
+	}
+	response.Deleted(c, "Tag deleted successfully")
+}
+
+// MergeTags folds the source tags into the target tag.
+func (ctrl *TagController) MergeTags(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.TagMergeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	target, err := ctrl.service.MergeTags(userID, request.SourceIDs, request.TargetID)
+	if err != nil {
+		respondError(c, err, "Could not merge tags")
+		return
+	}
+	response.Success(c, http.StatusOK, "Tags merged successfully", target)
+}
+
+func paramID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid tag ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondError maps the errors of the tag service to responses.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrTagNotFound):
+		response.NotFound(c, "Tag not found", nil)
+	case errors.Is(err, ErrTagExists), errors.Is(err, ErrInvalidName):
+		response.BadRequest(c, "Invalid tag", gin.H{"name": err.Error()})
+	case errors.Is(err, ErrInvalidMerge):
+		response.BadRequest(c, "Invalid merge", gin.H{"source_ids": err.Error()})
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,36 @@
+package tag
+
+import (
+	"strings"
+	"time"
+)
+
+// Tag is a free-form label such as "trip-goa" or "reimbursable" that a user
+// attaches to any number of expenses. Names are unique per user.
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_tag_user_name,priority:2"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_tag_user_name,priority:1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Normalize turns user input such as " #Trip-Goa" into the stored form "trip-goa".
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(name), "#")))
+}
+
+// Normalized returns the distinct non-empty normalized names, in input order.
+func Normalized(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var out []string
+	for _, name := range names {
+		name = Normalize(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out
+}
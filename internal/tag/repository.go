@@ -0,0 +1,156 @@
+package tag
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository stores tags and their links to expenses. The links live in the
+// expense_tags join table of expense.Expense.
+type Repository interface {
+	Create(tag *Tag) error
+	GetAll(userID uint) ([]Tag, error)
+	GetByID(id, userID uint) (*Tag, error)
+	GetByName(userID uint, name string) (*Tag, error)
+	GetByIDs(userID uint, ids []uint) ([]Tag, error)
+	Rename(id, userID uint, name string) error
+	Delete(id, userID uint) error
+	FindOrCreate(userID uint, names []string) ([]Tag, error)
+	Merge(userID uint, sourceIDs []uint, targetID uint) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create adds a new tag to the database.
+func (r *repository) Create(tag *Tag) error {
+	if tag == nil {
+		return errors.New("tag is nil")
+	}
+	return r.db.Create(tag).Error
+}
+
+// GetAll returns the user's tags in alphabetical order.
+func (r *repository) GetAll(userID uint) ([]Tag, error) {
+	var tags []Tag
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetByID fetches one of the user's tags, or nil if there is none.
+func (r *repository) GetByID(id, userID uint) (*Tag, error) {
+	var tag Tag
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByName fetches the user's tag with the given normalized name, or nil.
+func (r *repository) GetByName(userID uint, name string) (*Tag, error) {
+	var tag Tag
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByIDs returns those of the given tags that belong to the user.
+func (r *repository) GetByIDs(userID uint, ids []uint) ([]Tag, error) {
+	var tags []Tag
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	if err := r.db.Where("user_id = ? AND id IN ?", userID, ids).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Rename changes the name of one of the user's tags.
+func (r *repository) Rename(id, userID uint, name string) error {
+	return r.db.Model(&Tag{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("name", name).Error
+}
+
+// Delete removes one of the user's tags together with its expense links.
+func (r *repository) Delete(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid tag ID")
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("DELETE FROM expense_tags WHERE tag_id IN (SELECT id FROM tags WHERE id = ? AND user_id = ?)",
+			id, userID).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&Tag{}).Error
+	})
+}
+
+// FindOrCreate returns the user's tags with the given normalized names,
+// creating the ones that do not exist yet.
+func (r *repository) FindOrCreate(userID uint, names []string) ([]Tag, error) {
+	var tags []Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	missing := make([]Tag, len(names))
+	for i, name := range names {
+		missing[i] = Tag{Name: name, UserID: userID}
+	}
+	// Tags created concurrently by another request are simply picked up below
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Where("user_id = ? AND name IN ?", userID, names).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Merge moves the expense links of the source tags to the target tag and
+// deletes the source tags. Expenses that already carry the target keep a
+// single link.
+func (r *repository) Merge(userID uint, sourceIDs []uint, targetID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO expense_tags (expense_id, tag_id)
+			SELECT DISTINCT expense_id, ? FROM expense_tags WHERE tag_id IN ?
+			ON CONFLICT DO NOTHING`, targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM expense_tags WHERE tag_id IN ?", sourceIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND id IN ?", userID, sourceIDs).Delete(&Tag{}).Error
+	})
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
@@ -0,0 +1,134 @@
+package tag
+
+import (
+	"errors"
+)
+
+var (
+	// ErrTagNotFound is returned when the tag does not exist or belongs to someone else.
+	ErrTagNotFound = errors.New("tag not found")
+	// ErrTagExists is returned when the user already has a tag with that name.
+	ErrTagExists = errors.New("a tag with this name already exists")
+	// ErrInvalidName is returned for a name that is empty once normalized.
+	ErrInvalidName = errors.New("tag name must not be empty")
+	// ErrInvalidMerge is returned when the merge target is also one of the sources.
+	ErrInvalidMerge = errors.New("the target tag cannot also be a source")
+)
+
+type Service interface {
+	CreateTag(tag *Tag) error
+	GetTags(userID uint) ([]Tag, error)
+	GetTag(id, userID uint) (*Tag, error)
+	RenameTag(id, userID uint, name string) (*Tag, error)
+	DeleteTag(id, userID uint) error
+	MergeTags(userID uint, sourceIDs []uint, targetID uint) (*Tag, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// CreateTag stores a new tag under its normalized name.
+func (s *service) CreateTag(tag *Tag) error {
+	if tag == nil {
+		return errors.New("tag cannot be nil")
+	}
+	tag.Name = Normalize(tag.Name)
+	if err := s.checkName(tag.UserID, tag.Name, 0); err != nil {
+		return err
+	}
+	return s.repo.Create(tag)
+}
+
+func (s *service) GetTags(userID uint) ([]Tag, error) {
+	return s.repo.GetAll(userID)
+}
+
+func (s *service) GetTag(id, userID uint) (*Tag, error) {
+	tag, err := s.repo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+	return tag, nil
+}
+
+// RenameTag changes the name of a tag. Renaming onto another existing tag is
+// refused; MergeTags combines two tags instead.
+func (s *service) RenameTag(id, userID uint, name string) (*Tag, error) {
+	tag, err := s.GetTag(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	name = Normalize(name)
+	if err := s.checkName(userID, name, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rename(id, userID, name); err != nil {
+		return nil, err
+	}
+	tag.Name = name
+	return tag, nil
+}
+
+// DeleteTag removes a tag from every expense and then deletes it.
+func (s *service) DeleteTag(id, userID uint) error {
+	if _, err := s.GetTag(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id, userID)
+}
+
+// MergeTags relabels every expense tagged with one of the source tags with the
+// target tag, then deletes the source tags. It returns the target.
+func (s *service) MergeTags(userID uint, sourceIDs []uint, targetID uint) (*Tag, error) {
+	target, err := s.GetTag(targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return nil, ErrInvalidMerge
+		}
+	}
+	sources, err := s.repo.GetByIDs(userID, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) != len(distinct(sourceIDs)) {
+		return nil, ErrTagNotFound
+	}
+	if err := s.repo.Merge(userID, sourceIDs, targetID); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// checkName verifies that name is usable for a tag of the user other than exceptID.
+func (s *service) checkName(userID uint, name string, exceptID uint) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+	existing, err := s.repo.GetByName(userID, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != exceptID {
+		return ErrTagExists
+	}
+	return nil
+}
+
+func distinct(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,145 @@
+package transfer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/account"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/money"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TransferController struct {
+	service Service
+}
+
+func NewTransferController(service Service) *TransferController {
+	return &TransferController{service: service}
+}
+
+// CreateTransfer moves money between two of the caller's accounts.
+func (ctrl *TransferController) CreateTransfer(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var request dto.TransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "Invalid request payload", err.Error())
+		return
+	}
+
+	if err := validators.Validate.Struct(request); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	date, err := utils.ParseDateOrNow(request.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date", err.Error())
+		return
+	}
+
+	transfer := &Transfer{
+		UserID:        userID,
+		FromAccountID: request.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Note:          request.Note,
+		Date:          date,
+	}
+
+	if err := ctrl.service.CreateTransfer(transfer); err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) || errors.Is(err, ErrSameAccount) {
+			response.BadRequest(c, "Invalid account", err.Error())
+			return
+		}
+		if errors.Is(err, currency.ErrNoRate) {
+			response.BadRequest(c, "Could not convert currency", err.Error())
+			return
+		}
+		if errors.Is(err, money.ErrInvalid) {
+			response.BadRequest(c, "Validation error", gin.H{"amount": err.Error()})
+			return
+		}
+		logger.Error("Failed to create transfer", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not create transfer", err.Error())
+		return
+	}
+	response.Created(c, "Transfer created successfully", transfer)
+}
+
+// GetAllTransfers lists the caller's transfer history.
+func (ctrl *TransferController) GetAllTransfers(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	pagination := utils.NewPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	transfers, page, err := ctrl.service.GetTransfersByUserPaginated(userID, pagination)
+	if err != nil {
+		logger.Error("Failed to retrieve transfers", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve transfers", err.Error())
+		return
+	}
+
+	responseData := pagination.Meta(page)
+	responseData["transfers"] = transfers
+	response.Success(c, http.StatusOK, "Transfers retrieved successfully", responseData)
+}
+
+// GetTransferByID retrieves a single transfer.
+func (ctrl *TransferController) GetTransferByID(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("Invalid transfer ID parameter", zap.String("id_param", idStr))
+		response.BadRequest(c, "Invalid transfer ID", err.Error())
+		return
+	}
+
+	transfer, err := ctrl.service.GetTransferByID(uint(id), userID)
+	if err != nil {
+		logger.Error("Failed to retrieve transfer", zap.Error(err), zap.Int("transferID", id))
+		response.InternalServerError(c, "Could not retrieve transfer", err.Error())
+		return
+	}
+	if transfer == nil {
+		response.NotFound(c, "Transfer not found", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "Transfer retrieved successfully", transfer)
+}
+
+// DeleteTransfer reverts the balance changes of a transfer and removes it.
+func (ctrl *TransferController) DeleteTransfer(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid transfer ID", err.Error())
+		return
+	}
+
+	if err := ctrl.service.DeleteTransfer(uint(id), userID); err != nil {
+		if errors.Is(err, ErrTransferNotFound) {
+			response.NotFound(c, "Transfer not found", nil)
+			return
+		}
+		logger.Error("Failed to delete transfer", zap.Error(err), zap.Int("transferID", id))
+		response.InternalServerError(c, "Could not delete transfer", err.Error())
+		return
+	}
+	response.Deleted(c, "Transfer deleted successfully")
+}
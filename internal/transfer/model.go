@@ -0,0 +1,42 @@
+package transfer
+
+import (
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/money"
+)
+
+// Transfer moves money between two accounts owned by the same user.
+// It changes balances but is neither an expense nor an income. Amount is in
+// the currency of the source account and ToAmount, what the destination
+// receives, in the currency of the destination, converted at the rates of Date.
+type Transfer struct {
+	ID       uint         `gorm:"primaryKey" json:"id"`
+	UserID   uint         `json:"user_id"`
+	Amount   money.Amount `json:"amount"`
+	ToAmount money.Amount `json:"to_amount"`
+	Note     string       `json:"note"`
+	Date     time.Time    `json:"date"`
+
+	FromAccountID uint             `json:"from_account_id"`
+	FromAccount   *account.Account `json:"-" gorm:"foreignKey:FromAccountID"`
+
+	ToAccountID uint             `json:"to_account_id"`
+	ToAccount   *account.Account `json:"-" gorm:"foreignKey:ToAccountID"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SortFields maps the sort names accepted by the list endpoint to columns.
+var SortFields = map[string]string{
+	"id":              "transfers.id",
+	"amount":          "transfers.amount",
+	"date":            "transfers.date",
+	"from_account_id": "transfers.from_account_id",
+	"to_account_id":   "transfers.to_account_id",
+	"created_at":      "transfers.created_at",
+}
+
+// DefaultSort is used when the request does not send ?sort=.
+const DefaultSort = "created_at:desc"
@@ -0,0 +1,95 @@
+package transfer
+
+import (
+	"errors"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository defines the methods needed to get and store transfers.
+type Repository interface {
+	Create(transfer *Transfer) error
+	GetByID(id, userID uint) (*Transfer, error)
+	GetByIDForUpdate(id, userID uint) (*Transfer, error)
+	Delete(id, userID uint) error
+	GetAllByUserPaginated(userID uint, p utils.Pagination) ([]Transfer, utils.Page, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new transfer repository with the given database connection.
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+// Create adds a new transfer to the database.
+func (r *repository) Create(transfer *Transfer) error {
+	if transfer == nil {
+		return errors.New("transfer is nil")
+	}
+	return r.db.Create(transfer).Error
+}
+
+// GetByID retrieves a transfer by its ID, ensuring it belongs to the user.
+func (r *repository) GetByID(id, userID uint) (*Transfer, error) {
+	var transfer Transfer
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// GetByIDForUpdate is like GetByID but locks the row until the surrounding
+// transaction finishes.
+func (r *repository) GetByIDForUpdate(id, userID uint) (*Transfer, error) {
+	var transfer Transfer
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Delete removes a transfer by its ID, ensuring it belongs to the user.
+func (r *repository) Delete(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid ID")
+	}
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Transfer{}).Error
+}
+
+func (r *repository) GetAllByUserPaginated(userID uint, p utils.Pagination) ([]Transfer, utils.Page, error) {
+	query := r.db.Model(&Transfer{}).
+		Where("user_id = ?", userID)
+
+	if p.Search != "" {
+		query = query.Where("note ILIKE ?", "%"+p.Search+"%")
+	}
+
+	return utils.FindPage[Transfer](query, p, "transfers.id")
+}
+
+// Transaction runs fn inside a database transaction.
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{tx}
+}
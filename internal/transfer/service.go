@@ -0,0 +1,159 @@
+package transfer
+
+import (
+	"errors"
+	"trackonomy/internal/account"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/money"
+	"trackonomy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTransferNotFound is returned when the transfer to delete does not exist.
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrSameAccount is returned when both sides of a transfer are the same account.
+	ErrSameAccount = errors.New("cannot transfer to the same account")
+)
+
+type Service interface {
+	CreateTransfer(transfer *Transfer) error
+	GetTransferByID(id, userID uint) (*Transfer, error)
+	DeleteTransfer(id, userID uint) error
+	GetTransfersByUserPaginated(userID uint, pagination utils.Pagination) ([]Transfer, utils.Page, error)
+}
+
+type service struct {
+	repo        Repository
+	accountRepo account.Repository
+	currency    currency.Service
+}
+
+func NewService(repo Repository, accountRepo account.Repository, currencies currency.Service) Service {
+	return &service{repo: repo, accountRepo: accountRepo, currency: currencies}
+}
+
+// CreateTransfer moves the amount from one account to the other and records
+// the transfer, all in one transaction. Between accounts kept in different
+// currencies the amount is converted at the rates of the transfer date; it
+// returns an error wrapping currency.ErrNoRate when a rate is missing.
+func (s *service) CreateTransfer(transfer *Transfer) error {
+	if transfer == nil {
+		return errors.New("transfer cannot be nil")
+	}
+	if transfer.FromAccountID == transfer.ToAccountID {
+		return ErrSameAccount
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		accounts := s.accountRepo.WithTx(tx)
+		if err := s.convert(accounts, transfer); err != nil {
+			return err
+		}
+		if err := move(accounts, transfer); err != nil {
+			return err
+		}
+		return s.repo.WithTx(tx).Create(transfer)
+	})
+}
+
+func (s *service) GetTransferByID(id, userID uint) (*Transfer, error) {
+	if id == 0 {
+		return nil, errors.New("invalid ID")
+	}
+	return s.repo.GetByID(id, userID)
+}
+
+// DeleteTransfer moves the amount back to the source account and removes the transfer.
+func (s *service) DeleteTransfer(id, userID uint) error {
+	if id == 0 {
+		return errors.New("invalid ID")
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		existing, err := repo.GetByIDForUpdate(id, userID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrTransferNotFound
+		}
+
+		if err := moveBack(s.accountRepo.WithTx(tx), existing); err != nil {
+			return err
+		}
+		return repo.Delete(id, userID)
+	})
+}
+
+func (s *service) GetTransfersByUserPaginated(userID uint, pagination utils.Pagination) ([]Transfer, utils.Page, error) {
+	return s.repo.GetAllByUserPaginated(userID, pagination)
+}
+
+// convert rounds the amount to the minor unit of the source account's currency
+// and works out ToAmount in the currency of the destination. An amount that
+// rounds to nothing returns an error wrapping money.ErrInvalid.
+func (s *service) convert(accounts account.Repository, transfer *Transfer) error {
+	from, err := accounts.GetByID(transfer.FromAccountID, transfer.UserID)
+	if err != nil {
+		return err
+	}
+	to, err := accounts.GetByID(transfer.ToAccountID, transfer.UserID)
+	if err != nil {
+		return err
+	}
+	if from == nil || to == nil {
+		return account.ErrAccountNotFound
+	}
+	if transfer.Amount, err = transfer.Amount.RoundPositive(from.Currency); err != nil {
+		return err
+	}
+	transfer.ToAmount, err = s.currency.Convert(transfer.Amount, from.Currency, to.Currency, transfer.Date)
+	return err
+}
+
+type leg struct {
+	accountID uint
+	delta     money.Amount
+}
+
+// legs returns the balance changes needed to debit the source account and
+// credit the destination. They are always ordered by account ID so that two
+// opposite transfers running at the same time cannot deadlock on each other's
+// rows.
+func legs(transfer *Transfer, debit, credit money.Amount) []leg {
+	l := []leg{
+		{transfer.FromAccountID, -debit},
+		{transfer.ToAccountID, credit},
+	}
+	if l[0].accountID > l[1].accountID {
+		l[0], l[1] = l[1], l[0]
+	}
+	return l
+}
+
+// move debits Amount from the source account and credits ToAmount to the destination.
+func move(accounts account.Repository, transfer *Transfer) error {
+	for _, l := range legs(transfer, transfer.Amount, transfer.ToAmount) {
+		if err := accounts.AdjustBalance(l.accountID, transfer.UserID, l.delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveBack reverses a stored transfer. An account that no longer exists has no
+// balance to restore, so it is skipped rather than blocking the deletion.
+// Trashed accounts are adjusted too, so that restoring one brings back the
+// right balance.
+func moveBack(accounts account.Repository, transfer *Transfer) error {
+	accounts = accounts.Unscoped()
+	for _, l := range legs(transfer, -transfer.Amount, -transfer.ToAmount) {
+		err := accounts.AdjustBalance(l.accountID, transfer.UserID, l.delta)
+		if err != nil && !errors.Is(err, account.ErrAccountNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package trash
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/category"
+	"trackonomy/internal/expense"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TrashController struct {
+	service Service
+}
+
+func NewTrashController(service Service) *TrashController {
+	return &TrashController{service: service}
+}
+
+// GetTrash lists the caller's deleted expenses, accounts and categories, or
+// only one kind with ?type=.
+func (tc *TrashController) GetTrash(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	trash, err := tc.service.List(userID, c.Query("type"))
+	if err != nil {
+		if errors.Is(err, ErrUnknownType) {
+			response.BadRequest(c, "Invalid type", err.Error())
+			return
+		}
+		logger.Error("Failed to retrieve trash", zap.Error(err), zap.Uint("userID", userID))
+		response.InternalServerError(c, "Could not retrieve trash", err.Error())
+		return
+	}
+	response.Success(c, http.StatusOK, "Trash retrieved successfully", trash)
+}
+
+// Restore takes the record /:type/:id out of the trash. Restoring an expense
+// debits its account again.
+func (tc *TrashController) Restore(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	kind := c.Param("type")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid ID", nil)
+		return
+	}
+
+	restored, err := tc.service.Restore(audit.Context(c), kind, uint(id), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownType):
+			response.BadRequest(c, "Invalid type", err.Error())
+		case errors.Is(err, expense.ErrExpenseNotFound),
+			errors.Is(err, category.ErrCategoryNotFound),
+			errors.Is(err, account.ErrAccountNotFound) && kind == TypeAccounts:
+			response.NotFound(c, "Not found in the trash", nil)
+		case errors.Is(err, account.ErrAccountNotFound):
+			response.Error(c, http.StatusConflict, "The account of the expense cannot be debited", err.Error())
+		default:
+			logger.Error("Failed to restore from trash", zap.Error(err),
+				zap.String("type", kind), zap.Int("id", id), zap.Uint("userID", userID))
+			response.InternalServerError(c, "Could not restore", err.Error())
+		}
+		return
+	}
+	response.Success(c, http.StatusOK, "Restored successfully", restored)
+}
@@ -0,0 +1,116 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"time"
+	"trackonomy/internal/account"
+	"trackonomy/internal/category"
+	"trackonomy/internal/expense"
+)
+
+// Kinds of records that can be in the trash, as used in the URLs.
+const (
+	TypeExpenses   = "expenses"
+	TypeAccounts   = "accounts"
+	TypeCategories = "categories"
+)
+
+// ErrUnknownType is returned for a type of record that has no trash.
+var ErrUnknownType = errors.New("type must be one of expenses, accounts or categories")
+
+// Trash holds the deleted records a user can restore. Only the requested
+// kinds are filled in.
+type Trash struct {
+	Expenses   []expense.Expense   `json:"expenses,omitempty"`
+	Accounts   []account.Account   `json:"accounts,omitempty"`
+	Categories []category.Category `json:"categories,omitempty"`
+}
+
+// Purged counts the records one purge removed for good.
+type Purged struct {
+	Expenses   int64 `json:"expenses"`
+	Accounts   int64 `json:"accounts"`
+	Categories int64 `json:"categories"`
+}
+
+// Total returns the number of records purged.
+func (p Purged) Total() int64 {
+	return p.Expenses + p.Accounts + p.Categories
+}
+
+type Service interface {
+	List(userID uint, kind string) (*Trash, error)
+	Restore(ctx context.Context, kind string, id, userID uint) (interface{}, error)
+	Purge(before time.Time) (Purged, error)
+}
+
+type service struct {
+	expenses   expense.Service
+	accounts   account.Service
+	categories category.Service
+}
+
+// NewService builds the trash on top of the services that own the records,
+// which also know how to restore them.
+func NewService(expenses expense.Service, accounts account.Service, categories category.Service) Service {
+	return &service{expenses: expenses, accounts: accounts, categories: categories}
+}
+
+// List returns the user's trashed records of the given kind, or of every kind
+// when kind is empty.
+func (s *service) List(userID uint, kind string) (*Trash, error) {
+	if kind != "" && kind != TypeExpenses && kind != TypeAccounts && kind != TypeCategories {
+		return nil, ErrUnknownType
+	}
+	trash := &Trash{}
+	var err error
+	if kind == "" || kind == TypeExpenses {
+		if trash.Expenses, err = s.expenses.GetDeletedExpenses(userID); err != nil {
+			return nil, err
+		}
+	}
+	if kind == "" || kind == TypeAccounts {
+		if trash.Accounts, err = s.accounts.GetDeletedAccounts(userID); err != nil {
+			return nil, err
+		}
+	}
+	if kind == "" || kind == TypeCategories {
+		if trash.Categories, err = s.categories.GetDeletedCategories(userID); err != nil {
+			return nil, err
+		}
+	}
+	return trash, nil
+}
+
+// Restore takes a record out of the trash and returns it.
+func (s *service) Restore(ctx context.Context, kind string, id, userID uint) (interface{}, error) {
+	switch kind {
+	case TypeExpenses:
+		return s.expenses.RestoreExpense(ctx, id, userID)
+	case TypeAccounts:
+		return s.accounts.RestoreAccount(ctx, id, userID)
+	case TypeCategories:
+		return s.categories.RestoreCategory(ctx, id, userID)
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+// Purge permanently removes the records trashed before the given time.
+// Expenses go first so that the accounts and categories only they still
+// pointed at can go in the same run.
+func (s *service) Purge(before time.Time) (Purged, error) {
+	var purged Purged
+	var err error
+	if purged.Expenses, err = s.expenses.PurgeExpenses(before); err != nil {
+		return purged, err
+	}
+	if purged.Categories, err = s.categories.PurgeCategories(before); err != nil {
+		return purged, err
+	}
+	if purged.Accounts, err = s.accounts.PurgeAccounts(before); err != nil {
+		return purged, err
+	}
+	return purged, nil
+}
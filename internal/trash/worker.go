@@ -0,0 +1,55 @@
+package trash
+
+import (
+	"context"
+	"time"
+	"trackonomy/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// Worker periodically purges records that have been in the trash longer than
+// the retention period.
+type Worker struct {
+	service   Service
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewWorker(service Service, retention, interval time.Duration) *Worker {
+	return &Worker{service: service, retention: retention, interval: interval}
+}
+
+// Run purges right away and then again on every tick until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	logger.Info("Trash purge worker started",
+		zap.Duration("retention", w.retention), zap.Duration("interval", w.interval))
+
+	w.runOnce()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Trash purge worker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce()
+		}
+	}
+}
+
+func (w *Worker) runOnce() {
+	purged, err := w.service.Purge(time.Now().Add(-w.retention))
+	if err != nil {
+		logger.Error("Trash purge failed", zap.Error(err))
+		return
+	}
+	if purged.Total() > 0 {
+		logger.Info("Purged trashed records",
+			zap.Int64("expenses", purged.Expenses),
+			zap.Int64("accounts", purged.Accounts),
+			zap.Int64("categories", purged.Categories))
+	}
+}
@@ -4,12 +4,33 @@ import (
 	"time"
 )
 
+// Roles a user can have.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin" // manages global categories and accounts, exchange rates and users
+)
+
 // User represents an application user.
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Username  string    `gorm:"uniqueIndex" json:"username"`
-	Email     string    `gorm:"uniqueIndex" json:"email"`
-	Password  string    `json:"-"` // do not expose password in JSON
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Username string `gorm:"uniqueIndex" json:"username"`
+	Email    string `gorm:"uniqueIndex" json:"email"`
+	Password string `json:"-"` // do not expose password in JSON
+	Role     string `json:"role" gorm:"size:20;not null;default:user"`
+	// BaseCurrency is the ISO 4217 code reports convert expenses to.
+	BaseCurrency string    `json:"base_currency" gorm:"size:3"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+// SortFields maps the sort names accepted by the user list to columns.
+var SortFields = map[string]string{
+	"id":         "users.id",
+	"username":   "users.username",
+	"email":      "users.email",
+	"role":       "users.role",
+	"created_at": "users.created_at",
+}
+
+// DefaultSort is used when the request does not send ?sort=.
+const DefaultSort = "id:asc"
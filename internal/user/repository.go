@@ -2,6 +2,7 @@ package user
 
 import (
 	"errors"
+	"trackonomy/internal/utils"
 
 	"gorm.io/gorm"
 )
@@ -10,6 +11,9 @@ type Repository interface {
 	Create(user *User) error
 	GetByEmail(email string) (*User, error)
 	GetByID(id uint) (*User, error)
+	GetAll(p utils.Pagination) ([]User, utils.Page, error)
+	SetRole(id uint, role string) error
+	CountByRole(role string) (int64, error)
 }
 
 type repository struct {
@@ -50,3 +54,27 @@ func (r *repository) GetByID(id uint) (*User, error) {
 	}
 	return &user, nil
 }
+
+// GetAll returns every user, one page at a time (every row when p.Limit is 0).
+func (r *repository) GetAll(p utils.Pagination) ([]User, utils.Page, error) {
+	return utils.FindPage[User](r.db.Model(&User{}), p, "users.id")
+}
+
+// SetRole changes the role of a user. It returns ErrUserNotFound when there is no such user.
+func (r *repository) SetRole(id uint, role string) error {
+	result := r.db.Model(&User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CountByRole returns how many users have the role.
+func (r *repository) CountByRole(role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
@@ -2,15 +2,26 @@ package user
 
 import (
 	"errors"
+	"trackonomy/internal/utils"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrUserNotFound is returned when a user does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrLastAdmin is returned when a change would leave no administrator.
+	ErrLastAdmin = errors.New("the last administrator cannot be demoted")
+)
+
 type Service interface {
 	RegisterUser(user *User) error
 	GetUserByEmail(email string) (*User, error)
 	ValidateCredentials(email, password string) (*User, error)
 	GetByID(id uint) (*User, error)
+	GetAllUsers(p utils.Pagination) ([]User, utils.Page, error)
+	SetRole(id uint, role string) (*User, error)
+	RoleOf(id uint) (string, error)
 }
 
 type service struct {
@@ -63,6 +74,46 @@ func (s *service) GetByID(id uint) (*User, error) {
 	return s.repository.GetByID(id)
 }
 
+func (s *service) GetAllUsers(p utils.Pagination) ([]User, utils.Page, error) {
+	return s.repository.GetAll(p)
+}
+
+// SetRole changes the role of a user and returns the user. Demoting the only
+// administrator left is refused with ErrLastAdmin.
+func (s *service) SetRole(id uint, role string) (*User, error) {
+	user, err := s.repository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Role == RoleAdmin && role != RoleAdmin {
+		admins, err := s.repository.CountByRole(RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, ErrLastAdmin
+		}
+	}
+	if err := s.repository.SetRole(id, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// RoleOf returns the current role of a user, or "" for an unknown user. It
+// lets auth.RequireRole see role changes without waiting for a new token.
+func (s *service) RoleOf(id uint) (string, error) {
+	user, err := s.repository.GetByID(id)
+	if err != nil || user == nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
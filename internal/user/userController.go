@@ -1,10 +1,18 @@
 package user
 
 import (
-	"github.com/gin-gonic/gin"
+	"errors"
 	"net/http"
+	"strconv"
 	"trackonomy/internal/auth"
 	"trackonomy/internal/dto"
+	"trackonomy/internal/logger"
+	"trackonomy/internal/response"
+	"trackonomy/internal/utils"
+	"trackonomy/internal/validators"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // UserController handles user-related requests.
@@ -40,6 +48,7 @@ func (uc *UserController) RegisterUser(c *gin.Context) {
 		"user": gin.H{
 			"username": user.Username,
 			"email":    user.Email,
+			"role":     user.Role,
 		},
 	})
 }
@@ -97,6 +106,88 @@ func (uc *UserController) GetProfile(c *gin.Context) {
 			"id":       user.ID,
 			"username": user.Username,
 			"email":    user.Email,
+			"role":     user.Role,
 		},
 	})
 }
+
+// GetAllUsers lists every user. It is reserved to administrators.
+func (uc *UserController) GetAllUsers(c *gin.Context) {
+	pagination := utils.NewOptionalPaginationFromRequest(c)
+	if errs := pagination.ParseOrder(SortFields, DefaultSort); len(errs) > 0 {
+		response.BadRequest(c, "Invalid pagination", errs)
+		return
+	}
+
+	users, page, err := uc.service.GetAllUsers(pagination)
+	if err != nil {
+		logger.Error("Failed to retrieve users", zap.Error(err))
+		response.InternalServerError(c, "Could not retrieve users", err.Error())
+		return
+	}
+	if pagination.Limit == 0 {
+		response.Success(c, http.StatusOK, "Users retrieved successfully", users)
+		return
+	}
+	data := pagination.Meta(page)
+	data["users"] = users
+	response.Success(c, http.StatusOK, "Users retrieved successfully", data)
+}
+
+// GetUserByID retrieves any user by ID. It is reserved to administrators.
+func (uc *UserController) GetUserByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid user ID", nil)
+		return
+	}
+
+	user, err := uc.service.GetByID(uint(id))
+	if err != nil {
+		logger.Error("Failed to retrieve user", zap.Error(err), zap.Int("userID", id))
+		response.InternalServerError(c, "Could not retrieve user", err.Error())
+		return
+	}
+	if user == nil {
+		response.NotFound(c, "User not found", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "User retrieved successfully", user)
+}
+
+// SetUserRole promotes a user to administrator or demotes them. It is
+// reserved to administrators.
+func (uc *UserController) SetUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid user ID", nil)
+		return
+	}
+
+	var req dto.UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid role data", err.Error())
+		return
+	}
+	if err := validators.Validate.Struct(req); err != nil {
+		response.BadRequest(c, "Validation error", utils.ParseValidationErrors(err))
+		return
+	}
+
+	user, err := uc.service.SetRole(uint(id), req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			response.NotFound(c, "User not found", nil)
+		case errors.Is(err, ErrLastAdmin):
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+		default:
+			logger.Error("Failed to change user role", zap.Error(err), zap.Int("userID", id))
+			response.InternalServerError(c, "Could not change user role", err.Error())
+		}
+		return
+	}
+	logger.Info("User role changed", zap.Int("userID", id), zap.String("role", req.Role),
+		zap.Uint("by", c.GetUint("userID")))
+	response.Updated(c, "User role updated successfully", user)
+}
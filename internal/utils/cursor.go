@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCursor is wrapped by every error returned when decoding a cursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var cursorSecret []byte
+
+// CursorTTL is how long a cursor stays valid after it was issued. Clients
+// paging through older cursors start again from the first page.
+const CursorTTL = 24 * time.Hour
+
+// now is the clock cursors are issued and checked against.
+var now = time.Now
+
+// SetCursorSecret sets the key used to sign and verify pagination cursors.
+// It is called once at startup.
+func SetCursorSecret(secret []byte) {
+	cursorSecret = secret
+}
+
+// cursor is the decoded content of a pagination token. Values holds the sort
+// key of the boundary row, one entry per field of the sort spec including the
+// id tie-breaker, rendered as text so the database does the type conversion.
+type cursor struct {
+	Sort    string   `json:"s"`
+	Values  []string `json:"v"`
+	Before  bool     `json:"b,omitempty"`
+	Expires int64    `json:"e"` // Unix time after which the cursor is refused
+}
+
+// encodeCursor signs c, valid for CursorTTL, and returns it as an opaque
+// URL-safe token.
+func encodeCursor(c cursor) (string, error) {
+	c.Expires = now().Add(CursorTTL).Unix()
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + base64.RawURLEncoding.EncodeToString(sign(body)), nil
+}
+
+// decodeCursor verifies the token signature and expiry and returns its content.
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, sign(body)) {
+		return c, fmt.Errorf("%w: bad signature", ErrInvalidCursor)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	if now().Unix() > c.Expires {
+		return c, fmt.Errorf("%w: expired, start again from the first page", ErrInvalidCursor)
+	}
+	return c, nil
+}
+
+func sign(body string) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// keysetCondition matches the rows strictly after (or, for before, strictly
+// preceding) the cursor position in the order given by spec:
+//
+//	(a > v1) OR (a = v1 AND b > v2) OR (a = v1 AND b = v2 AND id > v3)
+//
+// with each comparison flipped for descending fields.
+func keysetCondition(spec SortSpec, values []string, before bool) clause.Expression {
+	var or []clause.Expression
+	for i, f := range spec {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: column(spec[j].Column), Value: values[j]})
+		}
+		if f.Desc != before {
+			and = append(and, clause.Lt{Column: column(f.Column), Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column(f.Column), Value: values[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
+
+// sortValues reads the spec fields from row through its JSON encoding, which
+// is why sort field names must match the JSON names of the model.
+func sortValues(spec SortSpec, row any) ([]string, bool) {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	fields := map[string]any{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, false
+	}
+
+	values := make([]string, len(spec))
+	for i, f := range spec {
+		switch v := fields[f.Field].(type) {
+		case string:
+			values[i] = v
+		case json.Number:
+			values[i] = v.String()
+		case bool:
+			values[i] = fmt.Sprint(v)
+		default:
+			// Missing or null values cannot be used as a keyset position.
+			return nil, false
+		}
+	}
+	return values, true
+}
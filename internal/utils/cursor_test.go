@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withCursorClock signs cursors with a test key and a fixed clock for the
+// duration of a test.
+func withCursorClock(t *testing.T, at time.Time) *time.Time {
+	t.Helper()
+	secret, clock := cursorSecret, now
+	t.Cleanup(func() { cursorSecret, now = secret, clock })
+
+	SetCursorSecret([]byte("test-secret"))
+	current := at
+	now = func() time.Time { return current }
+	return &current
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	withCursorClock(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	in := cursor{Sort: "amount:desc,id:desc", Values: []string{"12.5000", "42"}, Before: true}
+	token, err := encodeCursor(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeCursor(token)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	in.Expires = time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC).Unix()
+	if !reflect.DeepEqual(got, in) {
+		t.Errorf("decodeCursor() = %+v, want %+v", got, in)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	clock := withCursorClock(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	token, err := encodeCursor(cursor{Sort: "date:desc,id:desc", Values: []string{"2024-02-01", "7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, sig, _ := strings.Cut(token, ".")
+
+	// A payload pointing elsewhere, re-encoded but not re-signed.
+	payload, _ := base64.RawURLEncoding.DecodeString(body)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), `"7"`, `"1"`, 1)))
+
+	// A cursor signed with another key.
+	SetCursorSecret([]byte("other-secret"))
+	otherKey, err := encodeCursor(cursor{Sort: "date:desc,id:desc", Values: []string{"2024-02-01", "7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetCursorSecret([]byte("test-secret"))
+
+	tests := []struct {
+		name  string
+		token string
+		at    time.Time
+	}{
+		{name: "no signature", token: body},
+		{name: "empty", token: ""},
+		{name: "tampered payload", token: forged + "." + sig},
+		{name: "tampered signature", token: body + "." + sig[:len(sig)-2] + "AA"},
+		{name: "signature not base64", token: body + ".!!!"},
+		{name: "other key", token: otherKey},
+		{name: "garbage", token: "bm90IGpzb24.c2ln"},
+		{name: "expired", token: token, at: time.Date(2024, 3, 2, 12, 0, 1, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*clock = time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+			if !tt.at.IsZero() {
+				*clock = tt.at
+			}
+			if _, err := decodeCursor(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	*clock = time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	if _, err := decodeCursor(token); err != nil {
+		t.Errorf("decodeCursor() at the expiry time: %v", err)
+	}
+}
+
+func TestDecodeCursorWithoutExpiry(t *testing.T) {
+	withCursorClock(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	// Cursors issued before they carried an expiry are signed but refused.
+	body := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"date:desc,id:desc","v":["2024-02-01","7"]}`))
+	token := body + "." + base64.RawURLEncoding.EncodeToString(sign(body))
+	if _, err := decodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestParseOrderCursorSortMismatch(t *testing.T) {
+	withCursorClock(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	token, err := encodeCursor(cursor{Sort: "amount:desc,id:desc", Values: []string{"12.5000", "42"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := Pagination{Sort: "amount:desc,id:desc", Cursor: token}
+	if errs := p.ParseOrder(testSortFields, "date:desc"); errs != nil {
+		t.Fatalf("ParseOrder() with the cursor's sort = %v", errs)
+	}
+
+	p = Pagination{Sort: "date:asc", Cursor: token}
+	errs := p.ParseOrder(testSortFields, "date:desc")
+	if errs["cursor"] == "" {
+		t.Errorf("ParseOrder() with another sort = %v, want a cursor error", errs)
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+// DateLayout is the format used for plain calendar dates in requests, e.g. "2024-01-31".
+const DateLayout = "2006-01-02"
+
+// ParseDateOrNow parses a DateLayout string, falling back to the current time
+// when the value is empty.
+func ParseDateOrNow(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.Parse(DateLayout, value)
+}
@@ -1,9 +1,15 @@
 package utils
 
 import (
+	"errors"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation.
+const uniqueViolation = "23505"
+
 func ParseValidationErrors(err error) map[string]string {
 	out := make(map[string]string)
 	if ve, ok := err.(validator.ValidationErrors); ok {
@@ -16,3 +22,9 @@ func ParseValidationErrors(err error) map[string]string {
 	}
 	return out
 }
+
+// IsUniqueViolation reports whether err was caused by a unique constraint.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
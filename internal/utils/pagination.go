@@ -1,17 +1,36 @@
 package utils
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Pagination holds pagination and sorting info. You can add filter fields if needed.
+//
+// Two modes are supported: offset mode with page and limit, and keyset mode
+// when a cursor from a previous response is sent. Keyset mode does not skip or
+// repeat rows when data changes between requests and stays fast on deep pages.
 type Pagination struct {
 	Page   int    `json:"page"`
 	Limit  int    `json:"limit"`
-	Sort   string `json:"sort"`   // e.g., "date asc" or "amount desc"
+	Sort   string `json:"sort"`   // raw query value, e.g. "date:asc,amount:desc"
 	Search string `json:"search"` // optional: for searching across title/description, etc.
+	Cursor string `json:"cursor,omitempty"`
+
+	// Order is the validated form of Sort; see ParseOrder.
+	Order SortSpec `json:"-"`
+
+	cursor *cursor
+}
+
+// Page describes where a page of results sits in the full list.
+type Page struct {
+	Total      int64 // only counted in offset mode
+	NextCursor string
+	PrevCursor string
 }
 
 // NewPaginationFromRequest parses query params from Gin context
@@ -21,7 +40,6 @@ func NewPaginationFromRequest(c *gin.Context) Pagination {
 	p := Pagination{
 		Page:  1,
 		Limit: 10, // change to your preference
-		Sort:  "created_at desc",
 	}
 
 	// Parse page
@@ -38,9 +56,9 @@ func NewPaginationFromRequest(c *gin.Context) Pagination {
 		}
 	}
 
-	// Parse sort (e.g. sort=title asc, or sort=amount desc)
+	// Parse sort (e.g. sort=title:asc or sort=amount:desc,date:asc).
+	// It is validated per resource by ParseOrder.
 	if sortStr := c.Query("sort"); sortStr != "" {
-		// You could validate that it matches <column> <asc/desc>, etc.
 		p.Sort = sortStr
 	}
 
@@ -49,5 +67,154 @@ func NewPaginationFromRequest(c *gin.Context) Pagination {
 		p.Search = searchStr
 	}
 
+	// Opaque next_cursor/prev_cursor value from a previous response
+	p.Cursor = c.Query("cursor")
+
 	return p
 }
+
+// NewOptionalPaginationFromRequest is NewPaginationFromRequest for list
+// endpoints that historically returned everything: unless the request sends
+// page, limit or cursor, Limit is 0 and the whole list is loaded.
+func NewOptionalPaginationFromRequest(c *gin.Context) Pagination {
+	p := NewPaginationFromRequest(c)
+	if c.Query("page") == "" && c.Query("limit") == "" && c.Query("cursor") == "" {
+		p.Limit = 0
+	}
+	return p
+}
+
+// ParseOrder validates Sort against a resource's sortable fields and stores the
+// result in Order. When Sort is empty, def is used. A cursor, if any, is
+// verified too and must have been issued for the same sort. Problems are
+// returned as field-level errors keyed by query parameter.
+func (p *Pagination) ParseOrder(whitelist map[string]string, def string) map[string]string {
+	order, err := ParseSort(p.Sort, whitelist, def)
+	if err != nil {
+		return map[string]string{"sort": err.Error()}
+	}
+	p.Order = order
+
+	if p.Cursor == "" {
+		return nil
+	}
+	c, err := decodeCursor(p.Cursor)
+	if err != nil {
+		return map[string]string{"cursor": err.Error()}
+	}
+	if c.Sort != order.String() {
+		return map[string]string{"cursor": fmt.Sprintf("%v: it was issued for sort %q", ErrInvalidCursor, c.Sort)}
+	}
+	p.cursor = &c
+	return nil
+}
+
+// Meta returns the pagination fields of a list response.
+func (p Pagination) Meta(page Page) gin.H {
+	meta := gin.H{
+		"limit":       p.Limit,
+		"sort":        p.Order.String(),
+		"next_cursor": nullable(page.NextCursor),
+		"prev_cursor": nullable(page.PrevCursor),
+	}
+	if p.cursor == nil {
+		meta["total"] = page.Total
+		meta["current_page"] = p.Page
+	}
+	return meta
+}
+
+// FindPage orders query by p.Order (with idColumn as tie-breaker), applies the
+// cursor or page offset, and loads one page into a slice of T. A zero Limit
+// loads every row.
+func FindPage[T any](query *gorm.DB, p Pagination, idColumn string) ([]T, Page, error) {
+	var (
+		rows []T
+		page Page
+	)
+
+	spec := p.Order.WithTieBreaker(idColumn)
+	if p.Limit <= 0 {
+		if err := p.Order.Apply(query, idColumn).Find(&rows).Error; err != nil {
+			return nil, page, err
+		}
+		page.Total = int64(len(rows))
+		return rows, page, nil
+	}
+
+	before := false
+	if p.cursor != nil {
+		if len(p.cursor.Values) != len(spec) {
+			return nil, page, ErrInvalidCursor
+		}
+		before = p.cursor.Before
+		query = query.Where(keysetCondition(spec, p.cursor.Values, before))
+	} else {
+		// Count total records (before pagination)
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, page, err
+		}
+		query = query.Offset((p.Page - 1) * p.Limit)
+	}
+
+	// Walking backwards reads the preceding rows in reverse order; one extra
+	// row tells whether there is anything beyond this page.
+	order := spec
+	if before {
+		order = spec.reversed()
+	}
+	if err := order.Apply(query, idColumn).Limit(p.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, page, err
+	}
+
+	more := len(rows) > p.Limit
+	if more {
+		rows = rows[:p.Limit]
+	}
+	if before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, page, nil
+	}
+
+	hasNext, hasPrev := more, p.Page > 1
+	switch {
+	case p.cursor != nil && before:
+		hasNext, hasPrev = true, more
+	case p.cursor != nil:
+		hasNext, hasPrev = more, true
+	}
+
+	var err error
+	if hasNext {
+		if page.NextCursor, err = cursorAt(p.Order, spec, rows[len(rows)-1], false); err != nil {
+			return nil, page, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = cursorAt(p.Order, spec, rows[0], true); err != nil {
+			return nil, page, err
+		}
+	}
+	return rows, page, nil
+}
+
+// cursorAt returns a token positioned on row, or "" when the row has no
+// usable sort key.
+func cursorAt(order, spec SortSpec, row any, before bool) (string, error) {
+	values, ok := sortValues(spec, row)
+	if !ok {
+		return "", nil
+	}
+	return encodeCursor(cursor{Sort: order.String(), Values: values, Before: before})
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
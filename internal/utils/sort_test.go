@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testSortFields = map[string]string{
+	"id":     "expenses.id",
+	"amount": "expenses.amount",
+	"date":   "expenses.date",
+	"title":  "expenses.title",
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string // SortSpec.String()
+	}{
+		{name: "default", raw: "", want: "date:desc"},
+		{name: "blank uses default", raw: "  ", want: "date:desc"},
+		{name: "direction defaults to asc", raw: "amount", want: "amount:asc"},
+		{name: "several fields", raw: "amount:desc,date:asc", want: "amount:desc,date:asc"},
+		{name: "older space form", raw: "amount desc", want: "amount:desc"},
+		{name: "case-insensitive direction", raw: "title:DESC", want: "title:desc"},
+		{name: "spaces and empty parts", raw: " amount:desc ,, title ", want: "amount:desc,title:asc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseSort(tt.raw, testSortFields, "date:desc")
+			if err != nil {
+				t.Fatalf("ParseSort(%q) error = %v", tt.raw, err)
+			}
+			if got := spec.String(); got != tt.want {
+				t.Errorf("ParseSort(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortColumns(t *testing.T) {
+	spec, err := ParseSort("amount:desc,title", testSortFields, "date:desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SortSpec{
+		{Field: "amount", Column: "expenses.amount", Desc: true},
+		{Field: "title", Column: "expenses.title"},
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("ParseSort() = %+v, want %+v", spec, want)
+	}
+}
+
+func TestParseSortRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "unknown field", raw: "password"},
+		{name: "column name instead of field", raw: "expenses.amount"},
+		{name: "sql injection", raw: "amount; DROP TABLE expenses"},
+		{name: "bad direction", raw: "amount:sideways"},
+		{name: "duplicate field", raw: "amount:asc,date,amount:desc"},
+		{name: "one bad field among good ones", raw: "date,nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseSort(tt.raw, testSortFields, "date:desc")
+			if !errors.Is(err, ErrInvalidSort) {
+				t.Fatalf("ParseSort(%q) = %v, %v; want ErrInvalidSort", tt.raw, spec, err)
+			}
+		})
+	}
+}
+
+func TestWithTieBreaker(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "amount:desc", want: "amount:desc,id:desc"},
+		{raw: "amount:desc,date:asc", want: "amount:desc,date:asc,id:asc"},
+		{raw: "id:desc,amount", want: "id:desc,amount:asc"},
+	}
+	for _, tt := range tests {
+		spec, err := ParseSort(tt.raw, testSortFields, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := spec.String()
+		if got := spec.WithTieBreaker("expenses.id").String(); got != tt.want {
+			t.Errorf("WithTieBreaker(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+		if got := spec.String(); got != before {
+			t.Errorf("WithTieBreaker modified the spec from %q to %q", before, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"context"
+	"trackonomy/config"
+	"trackonomy/internal/account"
+	"trackonomy/internal/audit"
+	"trackonomy/internal/category"
+	"trackonomy/internal/currency"
+	"trackonomy/internal/expense"
+	"trackonomy/internal/group"
+	"trackonomy/internal/recurring"
+	"trackonomy/internal/rule"
+	"trackonomy/internal/tag"
+	"trackonomy/internal/trash"
+	"trackonomy/internal/user"
+
+	"gorm.io/gorm"
+)
+
+// StartWorkers launches the application's background jobs. They stop when ctx is cancelled.
+func StartWorkers(ctx context.Context, db *gorm.DB, cfg *config.Config) {
+	accountRepo := account.NewRepository(db)
+	categoryRepo := category.NewRepository(db)
+	expenseRepo := expense.NewRepository(db)
+	groupService := group.NewService(group.NewRepository(db), user.NewRepository(db))
+	auditRepo := audit.NewRepository(db)
+	currencyService := currency.NewService(currency.NewRepository(db))
+	expenseService := expense.NewService(expenseRepo, accountRepo, categoryRepo, tag.NewRepository(db),
+		rule.NewRepository(db), groupService, auditRepo, currencyService)
+
+	// ====== Recurring Expenses ======
+	recurringService := recurring.NewService(recurring.NewRepository(db), expenseRepo, expenseService)
+	go recurring.NewWorker(recurringService, cfg.RecurringInterval).Run(ctx)
+
+	// ====== Trash Purge ======
+	trashService := trash.NewService(expenseService,
+		account.NewService(accountRepo, groupService, auditRepo, currencyService),
+		category.NewService(categoryRepo, groupService, auditRepo))
+	go trash.NewWorker(trashService, cfg.TrashRetention, cfg.TrashPurgeInterval).Run(ctx)
+}
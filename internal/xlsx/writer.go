@@ -0,0 +1,122 @@
+// Package xlsx writes simple single-sheet XLSX workbooks as a stream, so large
+// exports never have to be held in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+const workbookStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="`
+
+const workbookEnd = `" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetEnd = `</sheetData></worksheet>`
+
+// Writer streams rows into the only sheet of a workbook.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewWriter writes the workbook skeleton to w and returns a Writer ready for rows.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/workbook.xml", workbookStart + escape(sheetName) + workbookEnd},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	// The sheet is the last entry, so it can be streamed row by row.
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+	return &Writer{zip: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends a row. Numeric values (ints, uints and floats) become number
+// cells; everything else is written as text.
+func (w *Writer) WriteRow(values ...interface{}) error {
+	w.sheet.WriteString("<row>")
+	for _, v := range values {
+		switch n := v.(type) {
+		case float64:
+			w.sheet.WriteString(`<c><v>` + strconv.FormatFloat(n, 'f', -1, 64) + `</v></c>`)
+		case int:
+			w.sheet.WriteString(`<c><v>` + strconv.Itoa(n) + `</v></c>`)
+		case int64:
+			w.sheet.WriteString(`<c><v>` + strconv.FormatInt(n, 10) + `</v></c>`)
+		case uint:
+			w.sheet.WriteString(`<c><v>` + strconv.FormatUint(uint64(n), 10) + `</v></c>`)
+		case string:
+			w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escape(n) + `</t></is></c>`)
+		default:
+			w.sheet.WriteString(`<c t="inlineStr"><is><t></t></is></c>`)
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+// Close finishes the sheet and the zip archive. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// escape makes s safe for XML text, replacing characters XML cannot hold.
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}